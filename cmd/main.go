@@ -1 +1,11 @@
-package main
\ No newline at end of file
+package main
+
+import (
+	"os"
+
+	"github.com/alnah/task-tracker/internal/cli"
+)
+
+func main() {
+	os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
+}
@@ -1,3 +1,8 @@
 module github.com/alnah/task-tracker
 
 go 1.22.5
+
+require (
+	github.com/BurntSushi/toml v1.6.0
+	gopkg.in/yaml.v3 v3.0.1
+)
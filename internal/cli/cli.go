@@ -0,0 +1,1637 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alnah/task-tracker/internal/render"
+	st "github.com/alnah/task-tracker/internal/store"
+	tk "github.com/alnah/task-tracker/internal/task"
+)
+
+const (
+	ExitOK    = 0
+	ExitError = 1
+	ExitUsage = 2
+	ExitFound = 3 // overdue and due found tasks
+)
+
+const (
+	FileEnv         = "TASK_CLI_FILE"
+	LockTimeoutEnv  = "TASK_CLI_LOCK_TIMEOUT"
+	BackupKeepEnv   = "TASK_CLI_BACKUP_KEEP"
+	BackupMaxAgeEnv = "TASK_CLI_BACKUP_MAX_AGE"
+	WorkflowEnv     = "TASK_CLI_WORKFLOW"
+	AutoArchiveEnv  = "TASK_CLI_AUTO_ARCHIVE"
+	ColumnsEnv      = "COLUMNS"
+	DefaultFilename = "tasks.json"
+
+	DefaultBackupKeep = 10
+)
+
+// errFound makes a command that checks for tasks exit with ExitFound.
+var errFound = errors.New("tasks found")
+
+type UsageError struct {
+	Message string
+}
+
+func (e *UsageError) Error() string {
+	return fmt.Sprintf("usage: %s", e.Message)
+}
+
+type App struct {
+	Stdout       io.Writer
+	Stderr       io.Writer
+	Getenv       func(string) string
+	TimeProvider tk.TimeProvider
+}
+
+type command struct {
+	usage string
+	run   func(*App, []string) error
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"add": {
+			usage: "add <description> [--priority <priority>] [--due <date>] " +
+				"[--tag <tag>]... " + outputUsage,
+			run: (*App).runAdd,
+		},
+		"update": {
+			usage: "update <id> [<description>] [--priority <priority>] " +
+				"[--due <date>|none] [--tag <tag>]... [--untag <tag>]... " +
+				outputUsage,
+			run: (*App).runUpdate,
+		},
+		"delete": {
+			usage: "delete <id> " + outputUsage,
+			run:   (*App).runDelete,
+		},
+		"mark-in-progress": {
+			usage: "mark-in-progress <id> " + outputUsage,
+			run:   (*App).runMarkInProgress,
+		},
+		"mark-done": {
+			usage: "mark-done <id> " + outputUsage,
+			run:   (*App).runMarkDone,
+		},
+		"mark": {
+			usage: "mark <id> <status> " + outputUsage,
+			run:   (*App).runMark,
+		},
+		"reopen": {
+			usage: "reopen <id> " + outputUsage,
+			run:   (*App).runReopen,
+		},
+		"init": {
+			usage: "init",
+			run:   (*App).runInit,
+		},
+		"list": {
+			usage: "list [--archived] [--priority <min>] [--tag <tag>]... " +
+				"[--any-tag <tag>]... [--no-tag <tag>]... [--sort <keys>] " +
+				"[--limit <n> [--page <n>] | --group-by <fields>] " +
+				outputUsage + " [<query>]",
+			run: (*App).runList,
+		},
+		"tags": {
+			usage: "tags",
+			run:   (*App).runTags,
+		},
+		"overdue": {
+			usage: "overdue [--quiet] " + outputUsage,
+			run:   (*App).runOverdue,
+		},
+		"due": {
+			usage: "due [--within <age>] [--quiet] " + outputUsage,
+			run:   (*App).runDue,
+		},
+		"backups": {
+			usage: "backups list|restore <backup>",
+			run:   (*App).runBackups,
+		},
+		"restore": {
+			usage: "restore <id>|<backup> " + outputUsage,
+			run:   (*App).runRestore,
+		},
+		"trash": {
+			usage: "trash [purge [--older-than <age>]] " + outputUsage,
+			run:   (*App).runTrash,
+		},
+		"archive": {
+			usage: "archive [--older-than <age>] " + outputUsage,
+			run:   (*App).runArchive,
+		},
+		"unarchive": {
+			usage: "unarchive <id> " + outputUsage,
+			run:   (*App).runUnarchive,
+		},
+		"doctor": {
+			usage: "doctor [--repair] [--fix]",
+			run:   (*App).runDoctor,
+		},
+	}
+}
+
+func Run(args []string, stdout, stderr io.Writer) int {
+	app := &App{
+		Stdout:       stdout,
+		Stderr:       stderr,
+		Getenv:       os.Getenv,
+		TimeProvider: &tk.RealTimeProvider{},
+	}
+	return app.Run(args)
+}
+
+func (a *App) Run(args []string) int {
+	if len(args) == 0 {
+		a.printUsage(a.Stderr)
+		return ExitUsage
+	}
+
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		a.printUsage(a.Stdout)
+		return ExitOK
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(a.Stderr, "error: unknown command %q\n\n", args[0])
+		a.printUsage(a.Stderr)
+		return ExitUsage
+	}
+
+	if err := cmd.run(a, args[1:]); err != nil {
+		if errors.Is(err, errFound) {
+			return ExitFound
+		}
+		return a.printError(err)
+	}
+
+	return ExitOK
+}
+
+func (a *App) printUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "Usage: task-cli <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\n", commands[name].usage)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "An <id> is the numeric ID of a task, or a unique prefix "+
+		"of its long ID.")
+	fmt.Fprintf(w, "Tasks are stored in %s, or in the file named by $%s.\n",
+		DefaultFilename, FileEnv)
+}
+
+func (a *App) printError(err error) int {
+	var (
+		usageErr    *UsageError
+		descErr     *tk.DescriptionError
+		notFoundErr *tk.TaskNotFoundError
+		ambigErr    *tk.AmbiguousIDError
+		extErr      *st.FilenameExtError
+		initDataErr *st.InitDataError
+		lockErr     *st.LockTimeoutError
+		contentErr  *st.FileContentError
+		versionErr  *st.SchemaVersionError
+		backupErr   *st.BackupNotFoundError
+		corruptErr  *st.CorruptFileError
+		issuesErr   *tk.IntegrityError
+		moveErr     *tk.InvalidTransitionError
+		workflowErr *tk.WorkflowError
+		conflictErr *tk.TaskConflictError
+		queryErr    *tk.QueryError
+	)
+
+	switch {
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(a.Stderr, "error: %s\n", usageErr)
+		return ExitUsage
+	case errors.As(err, &queryErr):
+		fmt.Fprintf(a.Stderr, "error: %s\n  %s\n  %s^\n", queryErr,
+			queryErr.Query, strings.Repeat(" ", queryErr.Column()-1))
+		return ExitUsage
+	case errors.As(err, &descErr):
+		fmt.Fprintf(a.Stderr, "error: %s\n", descErr)
+	case errors.As(err, &notFoundErr):
+		fmt.Fprintf(a.Stderr, "error: %s\n", notFoundErr)
+	case errors.As(err, &ambigErr):
+		fmt.Fprintf(a.Stderr, "error: %s (type more of the long ID)\n",
+			ambigErr)
+	case errors.As(err, &moveErr):
+		fmt.Fprintf(a.Stderr, "error: %s\n", moveErr)
+	case errors.As(err, &workflowErr):
+		fmt.Fprintf(a.Stderr, "error: %s (check the file in $%s)\n",
+			workflowErr, WorkflowEnv)
+	case errors.As(err, &conflictErr):
+		fmt.Fprintf(a.Stderr, "error: %s (delete one of them first)\n",
+			conflictErr)
+	case errors.As(err, &extErr):
+		fmt.Fprintf(a.Stderr, "error: %s (set $%s to a supported file)\n",
+			extErr, FileEnv)
+	case errors.As(err, &initDataErr):
+		fmt.Fprintf(a.Stderr, "error: %s\n", initDataErr)
+	case errors.As(err, &contentErr):
+		fmt.Fprintf(a.Stderr, "error: %s (fix or move it away)\n", contentErr)
+	case errors.As(err, &versionErr):
+		fmt.Fprintf(a.Stderr, "error: %s (upgrade task-cli to read it)\n",
+			versionErr)
+	case errors.As(err, &corruptErr):
+		fmt.Fprintf(a.Stderr, "error: %s (run task-cli doctor --repair)\n",
+			corruptErr)
+	case errors.As(err, &issuesErr):
+		hint := "edit the tasks file to fix them"
+		for _, issue := range issuesErr.Issues {
+			if issue.Fixable {
+				hint = "run task-cli doctor --fix"
+			}
+		}
+		fmt.Fprintf(a.Stderr, "error: %s (%s)\n", issuesErr, hint)
+	case errors.As(err, &backupErr):
+		fmt.Fprintf(a.Stderr, "error: %s (see task-cli backups list)\n",
+			backupErr)
+	case errors.As(err, &lockErr):
+		fmt.Fprintf(a.Stderr, "error: %s (raise $%s to wait longer)\n",
+			lockErr, LockTimeoutEnv)
+	default:
+		fmt.Fprintf(a.Stderr, "error: %s\n", flattenError(err))
+	}
+
+	return ExitError
+}
+
+// Wrapped errors are chained with "\n>" separators; on a terminal they read
+// better on a single line.
+func flattenError(err error) string {
+	return strings.ReplaceAll(err.Error(), ":\n>", ": ")
+}
+
+func (a *App) runInit(args []string) error {
+	args, err := a.parseFlags(commands["init"].usage, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 0 {
+		return &UsageError{Message: commands["init"].usage}
+	}
+
+	store, err := a.newStore()
+	if err != nil {
+		return err
+	}
+
+	path, created, err := store.InitFile()
+	if err != nil {
+		return fmt.Errorf("failed to initialize tasks file:\n>%w", err)
+	}
+
+	if created {
+		fmt.Fprintf(a.Stdout, "Created tasks file %s\n", path)
+	} else {
+		fmt.Fprintf(a.Stdout, "Reusing existing tasks file %s\n", path)
+	}
+	return nil
+}
+
+func (a *App) runAdd(args []string) error {
+	usage := commands["add"].usage
+	fs := flag.NewFlagSet(usage, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	priority := fs.String("priority", "", "set the priority")
+	due := fs.String("due", "", "set the due date")
+	var tags tagsFlag
+	fs.Var(&tags, "tag", "add a tag")
+	var out outputFlags
+	out.register(fs)
+
+	args, err := parseInterspersed(fs, usage, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return &UsageError{Message: usage}
+	}
+	p, err := a.newPrinter(out)
+	if err != nil {
+		return err
+	}
+
+	params := tk.CreateTaskParams{Description: args[0], Tags: tags}
+	if *priority != "" {
+		if params.Priority, err = parsePriority(*priority); err != nil {
+			return err
+		}
+	}
+	if *due != "" {
+		date, err := a.parseDue(*due)
+		if err != nil {
+			return err
+		}
+		if !date.IsZero() {
+			params.Due = &date
+		}
+	}
+
+	repo, err := a.openRepository()
+	if err != nil {
+		return err
+	}
+
+	task, err := repo.CreateTask(params)
+	if err != nil {
+		return err
+	}
+
+	if p.set {
+		return p.Task(a.Stdout, task)
+	}
+	fmt.Fprintf(a.Stdout, "Task added successfully (ID: %d)\n", task.ID)
+	return nil
+}
+
+func (a *App) runUpdate(args []string) error {
+	usage := commands["update"].usage
+	fs := flag.NewFlagSet(usage, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	priority := fs.String("priority", "", "set the priority")
+	due := fs.String("due", "", "set the due date, or clear it with none")
+	var tags, untags tagsFlag
+	fs.Var(&tags, "tag", "add a tag")
+	fs.Var(&untags, "untag", "remove a tag")
+	var out outputFlags
+	out.register(fs)
+
+	args, err := parseInterspersed(fs, usage, args)
+	if err != nil {
+		return err
+	}
+	flagged := *priority != "" || *due != "" || len(tags)+len(untags) > 0
+	if len(args) < 1 || len(args) > 2 || (len(args) == 1 && !flagged) {
+		return &UsageError{Message: usage}
+	}
+	p, err := a.newPrinter(out)
+	if err != nil {
+		return err
+	}
+
+	if err := checkID(args[0]); err != nil {
+		return err
+	}
+
+	update := tk.UpdateTaskParams{AddTags: tags, RemoveTags: untags}
+	if len(args) == 2 {
+		update.Description = &args[1]
+	}
+	if *priority != "" {
+		priority, err := parsePriority(*priority)
+		if err != nil {
+			return err
+		}
+		update.Priority = &priority
+	}
+	if *due != "" {
+		date, err := a.parseDue(*due)
+		if err != nil {
+			return err
+		}
+		update.Due = &date
+	}
+
+	repo, err := a.openRepository()
+	if err != nil {
+		return err
+	}
+
+	if update.ID, err = repo.ResolveID(args[0]); err != nil {
+		return err
+	}
+
+	task, err := repo.UpdateTask(update)
+	if err != nil {
+		return err
+	}
+
+	if p.set {
+		return p.Task(a.Stdout, task)
+	}
+	fmt.Fprintf(a.Stdout, "Task updated successfully (ID: %d)\n", task.ID)
+	return nil
+}
+
+func (a *App) runDelete(args []string) error {
+	args, p, err := a.parseOutputFlags(commands["delete"].usage, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return &UsageError{Message: commands["delete"].usage}
+	}
+
+	if err := checkID(args[0]); err != nil {
+		return err
+	}
+
+	repo, err := a.openRepository()
+	if err != nil {
+		return err
+	}
+
+	id, err := repo.ResolveID(args[0])
+	if err != nil {
+		return err
+	}
+
+	task, err := repo.DeleteTask(id)
+	if err != nil {
+		return err
+	}
+
+	if p.set {
+		return p.Task(a.Stdout, task)
+	}
+	fmt.Fprintf(a.Stdout, "Task moved to the trash (ID: %d)\n", id)
+	return nil
+}
+
+func (a *App) runMarkInProgress(args []string) error {
+	return a.markStatus("mark-in-progress", args, tk.InProgress)
+}
+
+func (a *App) runMarkDone(args []string) error {
+	return a.markStatus("mark-done", args, tk.Done)
+}
+
+func (a *App) runMark(args []string) error {
+	args, p, err := a.parseOutputFlags(commands["mark"].usage, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 2 {
+		return &UsageError{Message: commands["mark"].usage}
+	}
+	return a.setStatus(args[0], tk.Status(args[1]), p)
+}
+
+func (a *App) markStatus(name string, args []string, status tk.Status) error {
+	args, p, err := a.parseOutputFlags(commands[name].usage, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return &UsageError{Message: commands[name].usage}
+	}
+	return a.setStatus(args[0], status, p)
+}
+
+func (a *App) setStatus(arg string, status tk.Status, p printer) error {
+	if err := checkID(arg); err != nil {
+		return err
+	}
+
+	repo, err := a.openRepository()
+	if err != nil {
+		return err
+	}
+
+	if _, err := parseStatus(repo.Workflow, string(status)); err != nil {
+		return err
+	}
+
+	id, err := repo.ResolveID(arg)
+	if err != nil {
+		return err
+	}
+
+	task, err := repo.UpdateTask(tk.UpdateTaskParams{
+		ID:     id,
+		Status: &status,
+	})
+	if err != nil {
+		return err
+	}
+
+	if p.set {
+		return p.Task(a.Stdout, task)
+	}
+	fmt.Fprintf(a.Stdout, "Task marked as %s (ID: %d)\n", task.Status, task.ID)
+	return nil
+}
+
+func (a *App) runReopen(args []string) error {
+	args, p, err := a.parseOutputFlags(commands["reopen"].usage, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return &UsageError{Message: commands["reopen"].usage}
+	}
+
+	if err := checkID(args[0]); err != nil {
+		return err
+	}
+
+	repo, err := a.openRepository()
+	if err != nil {
+		return err
+	}
+
+	id, err := repo.ResolveID(args[0])
+	if err != nil {
+		return err
+	}
+
+	task, err := repo.TransitionTask(id, "reopen")
+	if err != nil {
+		return err
+	}
+
+	if p.set {
+		return p.Task(a.Stdout, task)
+	}
+	fmt.Fprintf(a.Stdout, "Task reopened as %s (ID: %d)\n", task.Status, task.ID)
+	return nil
+}
+
+func (a *App) runList(args []string) error {
+	usage := commands["list"].usage
+	fs := flag.NewFlagSet(usage, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	archived := fs.Bool("archived", false, "list the archived tasks")
+	priority := fs.String("priority", "", "list only tasks at least this urgent")
+	var filter tk.TaskFilter
+	fs.Var((*tagsFlag)(&filter.AllTags), "tag", "list only tasks with the tag")
+	fs.Var((*tagsFlag)(&filter.AnyTags), "any-tag",
+		"list only tasks with any of the tags")
+	fs.Var((*tagsFlag)(&filter.NoTags), "no-tag",
+		"list only tasks without the tag")
+	sortKeys := fs.String("sort", "", "sort by these keys")
+	limit := fs.Int("limit", 0, "list at most this many tasks")
+	page := fs.Int("page", 1, "list this page of --limit tasks")
+	groupBy := fs.String("group-by", "", "group the tasks by these fields")
+	var out outputFlags
+	out.register(fs)
+
+	args, err := parseInterspersed(fs, usage, args)
+	if err != nil {
+		return err
+	}
+
+	if *priority != "" {
+		if filter.MinPriority, err = parsePriority(*priority); err != nil {
+			return err
+		}
+	}
+
+	params := tk.ListTasksParams{Sort: defaultSort}
+	if *sortKeys != "" {
+		if params.Sort, err = tk.ParseSort(*sortKeys); err != nil {
+			return &UsageError{Message: err.Error()}
+		}
+	}
+	switch {
+	case *limit < 0:
+		return &UsageError{Message: fmt.Sprintf(
+			"--limit must be a number of tasks, but got %d", *limit)}
+	case *page < 1:
+		return &UsageError{Message: fmt.Sprintf(
+			"--page must be a page number from 1, but got %d", *page)}
+	case *page > 1 && *limit == 0:
+		return &UsageError{Message: "--page needs --limit"}
+	}
+	var by []string
+	if *groupBy != "" {
+		if *limit > 0 {
+			return &UsageError{Message: "--group-by can't be used with --limit"}
+		}
+		if by, err = tk.ParseGroupBy(*groupBy); err != nil {
+			return &UsageError{Message: err.Error()}
+		}
+	}
+	params.Limit = *limit
+	params.Offset = (*page - 1) * *limit
+	p, err := a.newPrinter(out)
+	if err != nil {
+		return err
+	}
+
+	repo, err := a.openRepository()
+	if err != nil {
+		return err
+	}
+
+	// the query may come in one argument or in several, such as
+	// list status:todo tag:backend
+	query, err := tk.CompileQuery(strings.Join(args, " "), a.TimeProvider,
+		repo.Workflow)
+	if err != nil {
+		return err
+	}
+	params.Filter = tk.AllOf(filter, query)
+
+	if by != nil {
+		return a.listGroups(repo, *archived, p, tk.GroupTasksParams{
+			By:     by,
+			Filter: params.Filter,
+			Sort:   params.Sort,
+		})
+	}
+
+	var result tk.TaskPage
+	if *archived {
+		var archive tk.Tasks
+		if archive, err = repo.ReadArchive(); err == nil {
+			result, err = tk.PageTasks(archive, params, repo.Workflow)
+		}
+	} else {
+		result, err = repo.ListTasks(params)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := p.Tasks(a.Stdout, result.Tasks); err != nil {
+		return err
+	}
+	if params.Limit > 0 && p.table {
+		pages := max(1, (result.Total+params.Limit-1)/params.Limit)
+		fmt.Fprintf(a.Stdout, "Page %d of %d (%d tasks)\n",
+			*page, pages, result.Total)
+	}
+	return nil
+}
+
+func (a *App) listGroups(
+	repo *tk.FileTaskRepository,
+	archived bool,
+	p printer,
+	params tk.GroupTasksParams,
+) error {
+	var tasks tk.Tasks
+	var err error
+	if archived {
+		tasks, err = repo.ReadArchive()
+	} else {
+		tasks, err = repo.ReadAllTasks()
+	}
+	if err != nil {
+		return err
+	}
+
+	groups, err := tk.GroupTasks(tasks, params, repo.Workflow)
+	if err != nil {
+		return err
+	}
+
+	return p.Groups(a.Stdout, params.By, groups)
+}
+
+func (a *App) runTags(args []string) error {
+	args, err := a.parseFlags(commands["tags"].usage, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 0 {
+		return &UsageError{Message: commands["tags"].usage}
+	}
+
+	repo, err := a.openRepository()
+	if err != nil {
+		return err
+	}
+
+	counts, err := repo.CountTags()
+	if err != nil {
+		return err
+	}
+
+	if len(counts) == 0 {
+		fmt.Fprintln(a.Stdout, "No tags found.")
+		return nil
+	}
+
+	tags := make([]string, 0, len(counts))
+	for tag := range counts {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	tw := tabwriter.NewWriter(a.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TAG\tTASKS")
+	for _, tag := range tags {
+		fmt.Fprintf(tw, "%s\t%d\n", tag, counts[tag])
+	}
+	tw.Flush()
+	return nil
+}
+
+func (a *App) runOverdue(args []string) error {
+	usage := commands["overdue"].usage
+	fs := flag.NewFlagSet(usage, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	quiet := fs.Bool("quiet", false, "print nothing, only set the exit code")
+	var out outputFlags
+	out.register(fs)
+
+	args, err := parseInterspersed(fs, usage, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 0 {
+		return &UsageError{Message: usage}
+	}
+	p, err := a.newPrinter(out)
+	if err != nil {
+		return err
+	}
+
+	return a.checkDue(0, *quiet, p, "No overdue tasks.")
+}
+
+func (a *App) runDue(args []string) error {
+	usage := commands["due"].usage
+	fs := flag.NewFlagSet(usage, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	within := fs.String("within", "24h", "list the tasks due within")
+	quiet := fs.Bool("quiet", false, "print nothing, only set the exit code")
+	var out outputFlags
+	out.register(fs)
+
+	args, err := parseInterspersed(fs, usage, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 0 {
+		return &UsageError{Message: usage}
+	}
+
+	age, err := parseAge(*within)
+	if err != nil {
+		return &UsageError{Message: fmt.Sprintf("%s (%s)", usage, err)}
+	}
+	p, err := a.newPrinter(out)
+	if err != nil {
+		return err
+	}
+
+	return a.checkDue(age, *quiet, p,
+		fmt.Sprintf("No tasks due within %s.", *within))
+}
+
+// checkDue prints the tasks due within the given time, and returns errFound
+// if there are any, so scripts can test for them.
+func (a *App) checkDue(
+	within time.Duration,
+	quiet bool,
+	p printer,
+	none string,
+) error {
+	repo, err := a.openRepository()
+	if err != nil {
+		return err
+	}
+
+	tasks, err := repo.ReadDueTasks(within)
+	if err != nil {
+		return err
+	}
+
+	if quiet {
+		if len(tasks) == 0 {
+			return nil
+		}
+		return errFound
+	}
+	if len(tasks) == 0 && p.table {
+		fmt.Fprintln(a.Stdout, none)
+		return nil
+	}
+
+	result, err := tk.PageTasks(tasks, tk.ListTasksParams{
+		Sort: []tk.SortKey{{Field: "due"}, {Field: "priority", Desc: true}},
+	}, repo.Workflow)
+	if err != nil {
+		return err
+	}
+	if err := p.Tasks(a.Stdout, result.Tasks); err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		return nil
+	}
+	return errFound
+}
+
+func (a *App) runBackups(args []string) error {
+	usage := commands["backups"].usage
+	args, err := a.parseFlags(usage, args)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(args) == 1 && args[0] == "list":
+		return a.listBackups()
+	case len(args) == 2 && args[0] == "restore":
+		return a.restoreBackup(args[1])
+	default:
+		return &UsageError{Message: usage}
+	}
+}
+
+func (a *App) listBackups() error {
+	repo, err := a.openRepository()
+	if err != nil {
+		return err
+	}
+
+	backups, err := repo.Backups()
+	if err != nil {
+		return err
+	}
+
+	if len(backups) == 0 {
+		fmt.Fprintln(a.Stdout, "No backups found.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(a.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tCREATED\tSIZE")
+	for _, backup := range backups {
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", backup.Name,
+			backup.CreatedAt.Format(time.RFC3339), backup.Size)
+	}
+	tw.Flush()
+	return nil
+}
+
+func (a *App) restoreBackup(name string) error {
+	repo, err := a.openRepository()
+	if err != nil {
+		return err
+	}
+
+	if err := repo.RestoreBackup(name); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.Stdout, "Backup restored successfully (%s)\n", name)
+	return nil
+}
+
+// runRestore restores a task from the trash, or, for an argument that can't
+// name a task, such as tasks-20060102T150405.000Z.json, a backup.
+func (a *App) runRestore(args []string) error {
+	args, p, err := a.parseOutputFlags(commands["restore"].usage, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return &UsageError{Message: commands["restore"].usage}
+	}
+
+	if checkID(args[0]) != nil {
+		return a.restoreBackup(args[0])
+	}
+
+	repo, err := a.openRepository()
+	if err != nil {
+		return err
+	}
+
+	id, err := repo.ResolveID(args[0], tk.IncludeTrashed)
+	if err != nil {
+		return err
+	}
+
+	task, err := repo.RestoreTask(id)
+	if err != nil {
+		return err
+	}
+
+	if p.set {
+		return p.Task(a.Stdout, task)
+	}
+	fmt.Fprintf(a.Stdout, "Task restored successfully (ID: %d)\n", task.ID)
+	return nil
+}
+
+func (a *App) runTrash(args []string) error {
+	usage := commands["trash"].usage
+	fs := flag.NewFlagSet(usage, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	olderThan := fs.String("older-than", "", "purge only tasks trashed before")
+	var out outputFlags
+	out.register(fs)
+
+	args, err := parseInterspersed(fs, usage, args)
+	if err != nil {
+		return err
+	}
+	p, err := a.newPrinter(out)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(args) == 0 && *olderThan == "":
+		return a.listTrash(p)
+	case len(args) == 1 && args[0] == "purge":
+		var age time.Duration
+		if *olderThan != "" {
+			if age, err = parseAge(*olderThan); err != nil {
+				return &UsageError{Message: fmt.Sprintf("%s (%s)", usage, err)}
+			}
+		}
+		return a.purgeTrash(age, p)
+	default:
+		return &UsageError{Message: usage}
+	}
+}
+
+func (a *App) listTrash(p printer) error {
+	repo, err := a.openRepository()
+	if err != nil {
+		return err
+	}
+
+	trash, err := repo.ReadTrash()
+	if err != nil {
+		return err
+	}
+	if p.set {
+		return p.Tasks(a.Stdout, sortedTasks(trash))
+	}
+
+	if len(trash) == 0 {
+		fmt.Fprintln(a.Stdout, "The trash is empty.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(a.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tUID\tDELETED\tDESCRIPTION")
+	for _, id := range sortedIDs(trash) {
+		task := trash[id]
+		var deletedAt string
+		if task.DeletedAt != nil {
+			deletedAt = task.DeletedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n",
+			task.ID, task.UID, deletedAt, task.Description)
+	}
+	tw.Flush()
+	return nil
+}
+
+func (a *App) purgeTrash(olderThan time.Duration, p printer) error {
+	repo, err := a.openRepository()
+	if err != nil {
+		return err
+	}
+
+	purged, err := repo.PurgeTrash(olderThan)
+	if err != nil {
+		return err
+	}
+	if p.set {
+		return p.Tasks(a.Stdout, sortedTasks(purged))
+	}
+
+	fmt.Fprintf(a.Stdout, "Purged %d tasks from the trash: %s\n",
+		len(purged), joinIDs(purged))
+	return nil
+}
+
+func (a *App) runArchive(args []string) error {
+	usage := commands["archive"].usage
+	fs := flag.NewFlagSet(usage, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	olderThan := fs.String("older-than", "", "archive only tasks done before")
+	var out outputFlags
+	out.register(fs)
+
+	args, err := parseInterspersed(fs, usage, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 0 {
+		return &UsageError{Message: usage}
+	}
+	p, err := a.newPrinter(out)
+	if err != nil {
+		return err
+	}
+
+	var age time.Duration
+	if *olderThan != "" {
+		if age, err = parseAge(*olderThan); err != nil {
+			return &UsageError{Message: fmt.Sprintf("%s (%s)", usage, err)}
+		}
+	}
+
+	repo, err := a.openRepository()
+	if err != nil {
+		return err
+	}
+
+	archived, err := repo.ArchiveTasks(age)
+	if err != nil {
+		return err
+	}
+	if p.set {
+		return p.Tasks(a.Stdout, sortedTasks(archived))
+	}
+
+	fmt.Fprintf(a.Stdout, "Archived %d tasks: %s\n",
+		len(archived), joinIDs(archived))
+	return nil
+}
+
+func (a *App) runUnarchive(args []string) error {
+	args, p, err := a.parseOutputFlags(commands["unarchive"].usage, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return &UsageError{Message: commands["unarchive"].usage}
+	}
+	if err := checkID(args[0]); err != nil {
+		return err
+	}
+
+	repo, err := a.openRepository()
+	if err != nil {
+		return err
+	}
+
+	id, err := repo.ResolveID(args[0], tk.IncludeArchived)
+	if err != nil {
+		return err
+	}
+
+	task, err := repo.UnarchiveTask(id)
+	if err != nil {
+		return err
+	}
+
+	if p.set {
+		return p.Task(a.Stdout, task)
+	}
+	fmt.Fprintf(a.Stdout, "Task unarchived successfully (ID: %d)\n", task.ID)
+	return nil
+}
+
+func (a *App) runDoctor(args []string) error {
+	usage := commands["doctor"].usage
+	fs := flag.NewFlagSet(usage, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	repair := fs.Bool("repair", false, "repair a corrupt tasks file")
+	fix := fs.Bool("fix", false, "normalise the issues that can be fixed safely")
+
+	args, err := parseInterspersed(fs, usage, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 0 {
+		return &UsageError{Message: usage}
+	}
+
+	// a corrupt file is what doctor is for, so don't refuse to open it
+	repo, err := a.openRepository()
+	var corruptErr *st.CorruptFileError
+	if errors.As(err, &corruptErr) {
+		repo, err = a.newRepository(corruptErr.Filename)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *repair {
+		report, err := repo.Repair()
+		if err != nil {
+			return err
+		}
+		if report.Repaired {
+			tasks, err := repo.ReadAllTasks()
+			if err != nil {
+				return err
+			}
+			a.printRepairReport("tasks file", repo.Filepath(), report, tasks)
+		}
+
+		report, err = repo.RepairArchive()
+		if err != nil {
+			return err
+		}
+		if report.Repaired {
+			archived, err := repo.ReadArchive()
+			if err != nil {
+				return err
+			}
+			a.printRepairReport("archive file", repo.Archive.Filepath, report,
+				archived)
+		}
+	}
+
+	archived, err := repo.ReadArchive()
+	if err != nil {
+		return err
+	}
+
+	var issues []tk.Issue
+	if *fix {
+		issues, err = repo.FixIntegrity()
+	} else {
+		issues, err = repo.CheckIntegrity()
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(issues) == 0 {
+		tasks, err := repo.ReadAllTasks()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(a.Stdout, "Tasks file %s is healthy (%d tasks)\n",
+			repo.Filepath(), len(tasks))
+		if _, err := os.Stat(repo.Archive.Filepath); err == nil {
+			fmt.Fprintf(a.Stdout, "Archive file %s is healthy (%d tasks)\n",
+				repo.Archive.Filepath, len(archived))
+		}
+		return nil
+	}
+
+	a.printIssues(issues)
+
+	var unresolved []tk.Issue
+	for _, issue := range issues {
+		if !issue.Fixed {
+			unresolved = append(unresolved, issue)
+		}
+	}
+	if len(unresolved) > 0 {
+		return &tk.IntegrityError{Issues: unresolved}
+	}
+	return nil
+}
+
+func (a *App) printIssues(issues []tk.Issue) {
+	tw := tabwriter.NewWriter(a.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TASK\tCODE\tSTATE\tPROBLEM")
+	for _, issue := range issues {
+		state := "manual"
+		switch {
+		case issue.Fixed:
+			state = "fixed"
+		case issue.Fixable:
+			state = "fixable"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n",
+			issue.Key, issue.Code, state, issue.Message)
+	}
+	tw.Flush()
+}
+
+// printRepairReport describes the repair of file, such as the tasks file.
+func (a *App) printRepairReport(
+	file string,
+	path string,
+	report st.RepairReport,
+	tasks tk.Tasks,
+) {
+	fmt.Fprintf(a.Stdout, "Repaired corrupt %s %s\n", file, path)
+
+	if report.Backup != "" {
+		fmt.Fprintf(a.Stdout, "Restored the newest valid backup %s; "+
+			"changes made after it are lost\n", report.Backup)
+	} else {
+		fmt.Fprintln(a.Stdout, "Salvaged the readable tasks from the file")
+	}
+
+	fmt.Fprintf(a.Stdout, "Recovered %d tasks: %s\n",
+		len(tasks), joinIDs(tasks))
+
+	for _, path := range report.Lost {
+		if path == st.TruncatedPath {
+			fmt.Fprintln(a.Stdout, "The file was cut short; what followed "+
+				"its last readable task is lost")
+		} else {
+			fmt.Fprintf(a.Stdout, "Lost %s\n", describeRecord(path))
+		}
+	}
+	if report.Backup == "" && len(report.Lost) == 0 {
+		fmt.Fprintln(a.Stdout, "Nothing was lost")
+	}
+
+	if report.Offer != "" {
+		held := make([]string, 0, len(report.Recoverable))
+		for _, path := range report.Recoverable {
+			held = append(held, describeRecord(path))
+		}
+		fmt.Fprintf(a.Stdout, "The backup %s still holds %s; "+
+			"task-cli backups restore %s brings them back, undoing the "+
+			"changes made since\n", report.Offer, strings.Join(held, ", "),
+			report.Offer)
+	}
+
+	fmt.Fprintf(a.Stdout, "The corrupt file was kept as %s\n",
+		report.Quarantine)
+}
+
+// describeRecord names the task at a path of the tasks file, such as
+// data.Tasks.7.
+func describeRecord(path string) string {
+	if id, ok := strings.CutPrefix(path, "data.Tasks."); ok && isID(id) {
+		return "task " + id
+	}
+	if id, ok := strings.CutPrefix(path, "data.Trash."); ok && isID(id) {
+		return "trashed task " + id
+	}
+	return path
+}
+
+func joinIDs(tasks tk.Tasks) string {
+	if len(tasks) == 0 {
+		return "none"
+	}
+
+	ids := make([]string, 0, len(tasks))
+	for _, id := range sortedIDs(tasks) {
+		ids = append(ids, strconv.FormatUint(uint64(id), 10))
+	}
+	return strings.Join(ids, ", ")
+}
+
+func sortedTasks(tasks tk.Tasks) []tk.Task {
+	sorted := make([]tk.Task, 0, len(tasks))
+	for _, id := range sortedIDs(tasks) {
+		sorted = append(sorted, tasks[id])
+	}
+	return sorted
+}
+
+func sortedIDs(tasks tk.Tasks) []uint {
+	ids := make([]uint, 0, len(tasks))
+	for id := range tasks {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// defaultSort lists the most urgent tasks first, then the oldest.
+var defaultSort = []tk.SortKey{{Field: "priority", Desc: true}}
+
+// tagsFlag collects the tags of a flag given several times or with commas,
+// such as --tag backend --tag docs,ops.
+type tagsFlag []string
+
+func (f *tagsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *tagsFlag) Set(value string) error {
+	*f = append(*f, strings.Split(value, ",")...)
+	return nil
+}
+
+const outputUsage = "[--output <format> | --format <template>]"
+
+// outputFlags are the --output and --format flags of the commands that
+// return tasks.
+type outputFlags struct {
+	output string
+	format string
+}
+
+func (f *outputFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.output, "output", "",
+		"print the tasks as a table, json, ndjson or csv")
+	fs.StringVar(&f.format, "format", "", "print each task with a template")
+}
+
+// printer prints tasks as the output flags ask, as a table without them. Set
+// tells if they were given: without them, commands that change tasks print a
+// message instead.
+type printer struct {
+	*render.Renderer
+	set   bool
+	table bool
+}
+
+func (a *App) newPrinter(f outputFlags) (printer, error) {
+	opts := render.Options{Format: render.Format(f.output)}
+	switch {
+	case f.format != "":
+		if f.output != "" && opts.Format != render.Template {
+			return printer{}, &UsageError{
+				Message: "--format can't be used with --output " + f.output}
+		}
+		opts.Format = render.Template
+		opts.Template = f.format
+	case opts.Format == render.Template:
+		return printer{}, &UsageError{
+			Message: "--output template needs --format"}
+	case opts.Format == "":
+		opts.Format = render.Table
+	}
+	if opts.Format == render.Table {
+		opts.Width = a.terminalWidth()
+	}
+
+	r, err := render.New(opts)
+	if err != nil {
+		return printer{}, &UsageError{Message: err.Error()}
+	}
+	return printer{
+		Renderer: r,
+		set:      f.output != "" || f.format != "",
+		table:    opts.Format == render.Table,
+	}, nil
+}
+
+// terminalWidth is the width tables fit in: $COLUMNS, or else the width of
+// the terminal, if any.
+func (a *App) terminalWidth() int {
+	columns, err := strconv.Atoi(a.Getenv(ColumnsEnv))
+	if err == nil && columns > 0 {
+		return columns
+	}
+	return render.TerminalWidth(a.Stdout)
+}
+
+// parseOutputFlags parses the arguments of a command whose only flags are
+// the output flags.
+func (a *App) parseOutputFlags(
+	usage string,
+	args []string,
+) ([]string, printer, error) {
+	fs := flag.NewFlagSet(usage, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var out outputFlags
+	out.register(fs)
+
+	args, err := parseInterspersed(fs, usage, args)
+	if err != nil {
+		return nil, printer{}, err
+	}
+	p, err := a.newPrinter(out)
+	if err != nil {
+		return nil, printer{}, err
+	}
+	return args, p, nil
+}
+
+func (a *App) parseFlags(usage string, args []string) ([]string, error) {
+	fs := flag.NewFlagSet(usage, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return parseInterspersed(fs, usage, args)
+}
+
+// The standard flag package stops at the first positional argument, which
+// would force every flag in front of the description. Parse positionals and
+// flags in any order instead.
+func parseInterspersed(
+	fs *flag.FlagSet,
+	usage string,
+	args []string,
+) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, &UsageError{Message: fmt.Sprintf("%s (%s)", usage, err)}
+		}
+		// Parse consumes the -- that ends the flags, so look at what it
+		// stopped on: everything after a -- is positional
+		rest := fs.Args()
+		if parsed := len(args) - len(rest); parsed > 0 && args[parsed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+func (a *App) openRepository() (*tk.FileTaskRepository, error) {
+	store, err := a.newStore()
+	if err != nil {
+		return nil, err
+	}
+
+	path, _, err := store.InitFile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize tasks file:\n>%w", err)
+	}
+
+	return a.newRepository(path)
+}
+
+// newRepository opens the tasks file at path as it is, without checking it.
+func (a *App) newRepository(path string) (*tk.FileTaskRepository, error) {
+	store, err := a.newStore()
+	if err != nil {
+		return nil, err
+	}
+
+	repo := tk.NewFileTaskRepository(
+		store,
+		path,
+		a.TimeProvider,
+		&tk.TaskIDGenerator{},
+	)
+
+	archivePath := archivePath(path)
+	repo.Archive = &tk.Archive{
+		Store: &st.FileStore[tk.TaskList]{
+			DestDir:    filepath.Dir(archivePath),
+			Filename:   filepath.Base(archivePath),
+			InitData:   store.InitData,
+			Migrations: store.Migrations,
+			Backup:     store.Backup,
+			Clock:      store.Clock,
+		},
+		Filepath: archivePath,
+	}
+
+	if value := a.Getenv(AutoArchiveEnv); value != "" {
+		after, err := parseAge(value)
+		if err != nil {
+			return nil, &UsageError{Message: fmt.Sprintf(
+				"$%s must be an age such as 30d or 12h, but got %q",
+				AutoArchiveEnv, value,
+			)}
+		}
+		repo.AutoArchive = &tk.ArchivePolicy{After: after}
+	}
+
+	if value := a.Getenv(WorkflowEnv); value != "" {
+		workflow, err := tk.LoadWorkflow(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load workflow:\n>%w", err)
+		}
+		repo.Workflow = workflow
+	}
+
+	if value := a.Getenv(LockTimeoutEnv); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout < 0 {
+			return nil, &UsageError{Message: fmt.Sprintf(
+				"$%s must be a duration such as 5s, but got %q",
+				LockTimeoutEnv, value,
+			)}
+		}
+		repo.LockTimeout = timeout
+	}
+
+	return repo, nil
+}
+
+func (a *App) newStore() (*st.FileStore[tk.TaskList], error) {
+	path := a.Getenv(FileEnv)
+	if path == "" {
+		path = DefaultFilename
+	}
+
+	policy, err := a.backupPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	return &st.FileStore[tk.TaskList]{
+		DestDir:    filepath.Dir(path),
+		Filename:   filepath.Base(path),
+		InitData:   st.EmptyObject,
+		Migrations: tk.Migrations,
+		Backup:     policy,
+		Clock:      a.TimeProvider,
+	}, nil
+}
+
+// archivePath puts the archive next to the tasks file: tasks.json is archived
+// in tasks.archive.json.
+func archivePath(path string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + ".archive" + ext
+}
+
+func (a *App) backupPolicy() (*st.BackupPolicy, error) {
+	policy := &st.BackupPolicy{Keep: DefaultBackupKeep}
+
+	if value := a.Getenv(BackupKeepEnv); value != "" {
+		keep, err := strconv.Atoi(value)
+		if err != nil || keep < 0 {
+			return nil, &UsageError{Message: fmt.Sprintf(
+				"$%s must be a number of backups such as 10, but got %q",
+				BackupKeepEnv, value,
+			)}
+		}
+		policy.Keep = keep
+	}
+
+	if value := a.Getenv(BackupMaxAgeEnv); value != "" {
+		maxAge, err := parseAge(value)
+		if err != nil {
+			return nil, &UsageError{Message: fmt.Sprintf(
+				"$%s must be an age such as 30d or 12h, but got %q",
+				BackupMaxAgeEnv, value,
+			)}
+		}
+		policy.MaxAge = maxAge
+	}
+
+	return policy, nil
+}
+
+// checkID rejects what can't name a task before the tasks file is opened.
+func checkID(arg string) error {
+	_, err := strconv.ParseUint(arg, 10, 0)
+	if err != nil && !tk.IsUIDPrefix(arg) {
+		return &UsageError{Message: fmt.Sprintf(
+			"expected a numeric task ID or a prefix of a long ID, but got %q", arg,
+		)}
+	}
+	return nil
+}
+
+// parseAge reads a duration, and also accepts whole days such as 30d, which
+// time.ParseDuration doesn't.
+func parseAge(arg string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(arg, "d"); ok {
+		n, err := strconv.ParseUint(days, 10, 16)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q", arg)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	age, err := time.ParseDuration(arg)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age %q", arg)
+	}
+	return age, nil
+}
+
+func isID(arg string) bool {
+	_, err := strconv.ParseUint(arg, 10, 0)
+	return err == nil
+}
+
+func parseStatus(workflow *tk.Workflow, arg string) (tk.Status, error) {
+	status := tk.Status(arg)
+	if !workflow.Has(status) {
+		var names []string
+		for _, s := range workflow.Statuses {
+			names = append(names, string(s))
+		}
+		return "", &UsageError{Message: fmt.Sprintf(
+			"expected a status among %s, but got %q",
+			strings.Join(names, ", "), arg,
+		)}
+	}
+	return status, nil
+}
+
+// parseDue reads a due date, or none, which is the zero time.
+func (a *App) parseDue(arg string) (time.Time, error) {
+	if arg == "none" {
+		return time.Time{}, nil
+	}
+	due, err := tk.ParseDate(arg, a.TimeProvider)
+	if err != nil {
+		return time.Time{}, &UsageError{Message: err.Error()}
+	}
+	return due, nil
+}
+
+func parsePriority(arg string) (tk.Priority, error) {
+	priority, err := tk.ParsePriority(arg)
+	if err != nil {
+		return tk.PriorityNone, &UsageError{Message: err.Error()}
+	}
+	return priority, nil
+}
@@ -0,0 +1,1295 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alnah/task-tracker/internal/cli"
+	st "github.com/alnah/task-tracker/internal/store"
+	tk "github.com/alnah/task-tracker/internal/task"
+	th "github.com/alnah/task-tracker/test_helpers"
+)
+
+func Test_App_Run_Happy(t *testing.T) {
+	t.Run("adds, updates, marks and lists tasks successfully", func(t *testing.T) {
+		app := setupApp(t)
+
+		runOK(t, app, "add", "buy groceries")
+		runOK(t, app, "add", "cook dinner")
+		runOK(t, app, "add", "wash dishes")
+		runOK(t, app, "update", "1", "buy groceries and cook dinner")
+		runOK(t, app, "mark-in-progress", "2")
+		runOK(t, app, "mark-done", "3")
+
+		out := runOK(t, app, "list")
+		for _, want := range []string{
+			"buy groceries and cook dinner",
+			"cook dinner",
+			"wash dishes",
+		} {
+			th.AssertContains(t, out, want)
+		}
+
+		out = runOK(t, app, "list", "done")
+		th.AssertContains(t, out, "wash dishes")
+		if strings.Contains(out, "cook dinner") {
+			t.Errorf("got %q, want only done tasks", out)
+		}
+	})
+
+	t.Run("prints the ID of the added task successfully", func(t *testing.T) {
+		app := setupApp(t)
+		out := runOK(t, app, "add", "buy groceries")
+		th.AssertContains(t, out, "(ID: 1)")
+	})
+
+	t.Run("deletes a task successfully", func(t *testing.T) {
+		app := setupApp(t)
+		runOK(t, app, "add", "buy groceries")
+		runOK(t, app, "delete", "1")
+
+		out := runOK(t, app, "list")
+		th.AssertContains(t, out, "No tasks found.")
+	})
+
+	t.Run("never reuses the ID of a deleted task successfully",
+		func(t *testing.T) {
+			app := setupApp(t)
+			runOK(t, app, "add", "buy groceries")
+			runOK(t, app, "add", "cook dinner")
+			runOK(t, app, "delete", "2")
+
+			out := runOK(t, app, "add", "wash dishes")
+			th.AssertContains(t, out, "(ID: 3)")
+		})
+
+	t.Run("accepts a prefix of the long ID successfully", func(t *testing.T) {
+		app := setupApp(t)
+		runOK(t, app, "add", "buy groceries")
+		runOK(t, app, "add", "cook dinner")
+
+		var uid string
+		for _, line := range strings.Split(runOK(t, app, "list"), "\n") {
+			if strings.Contains(line, "cook dinner") {
+				uid = strings.Fields(line)[1]
+			}
+		}
+
+		out := runOK(t, app, "mark-done", strings.ToLower(uid))
+		th.AssertContains(t, out, "(ID: 2)")
+
+		_, stderr, code := run(app, "delete", "0")
+		th.AssertDeepEqual(t, code, cli.ExitError)
+		th.AssertContains(t, stderr, "matches tasks 1, 2")
+	})
+
+	t.Run("accepts flags after positional arguments successfully",
+		func(t *testing.T) {
+			app := setupApp(t)
+			runOK(t, app, "add", "--", "-starts with a dash")
+
+			out := runOK(t, app, "list")
+			th.AssertContains(t, out, "-starts with a dash")
+		})
+
+	t.Run("takes everything after -- as positional successfully",
+		func(t *testing.T) {
+			app := setupApp(t)
+			runOK(t, app, "add", "read a book")
+
+			runOK(t, app, "update", "--", "1", "--not-a-flag")
+			out := runOK(t, app, "list")
+			th.AssertContains(t, out, "--not-a-flag")
+
+			runOK(t, app, "update", "1", "--priority", "high", "--",
+				"--priority")
+			out = runOK(t, app, "list", "--output", "csv")
+			th.AssertContains(t, out, ",--priority,todo,high,")
+		})
+}
+
+func Test_App_Run_Priority(t *testing.T) {
+	t.Run("lists the most urgent tasks first successfully", func(t *testing.T) {
+		app := setupApp(t)
+		runOK(t, app, "add", "water the plants", "--priority", "low")
+		runOK(t, app, "add", "read a book")
+		runOK(t, app, "add", "--priority", "critical", "pay the rent")
+		runOK(t, app, "add", "call the bank", "--priority", "low")
+		runOK(t, app, "update", "2", "--priority", "high")
+
+		out := runOK(t, app, "list")
+		var got []string
+		for _, line := range strings.Split(out, "\n")[1:] {
+			if fields := strings.Fields(line); len(fields) > 0 {
+				got = append(got, fields[0]+" "+fields[2])
+			}
+		}
+		th.AssertDeepEqual(t, got, []string{
+			"3 critical", "2 high", "1 low", "4 low",
+		})
+
+		out = runOK(t, app, "list", "todo", "--priority", "high")
+		th.AssertContains(t, out, "pay the rent")
+		th.AssertContains(t, out, "read a book")
+		if strings.Contains(out, "water the plants") {
+			t.Errorf("got %q, want only high and critical tasks", out)
+		}
+	})
+
+	t.Run("updates the description and the priority together successfully",
+		func(t *testing.T) {
+			app := setupApp(t)
+			runOK(t, app, "add", "read a book")
+			runOK(t, app, "update", "1", "read two books", "--priority", "medium")
+
+			out := runOK(t, app, "list")
+			th.AssertContains(t, out, "medium")
+			th.AssertContains(t, out, "read two books")
+		})
+
+	t.Run("returns a usage error successfully", func(t *testing.T) {
+		testCases := []struct {
+			name string
+			args []string
+		}{
+			{"rejects an unknown priority on add",
+				[]string{"add", "read a book", "--priority", "urgent"}},
+			{"rejects an unknown priority on update",
+				[]string{"update", "1", "--priority", "urgent"}},
+			{"rejects an unknown priority on list",
+				[]string{"list", "--priority", "urgent"}},
+			{"rejects an update without changes", []string{"update", "1"}},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+				app := setupApp(t)
+				runOK(t, app, "add", "read a book")
+
+				_, stderr, code := run(app, tc.args...)
+				th.AssertDeepEqual(t, code, cli.ExitUsage)
+				th.AssertContains(t, stderr, "usage")
+			})
+		}
+	})
+}
+
+func Test_App_Run_Due(t *testing.T) {
+	t.Run("sets, moves and clears due dates successfully", func(t *testing.T) {
+		app := setupApp(t)
+		runOK(t, app, "add", "pay the rent", "--due", "next friday")
+		runOK(t, app, "add", "call the bank", "--due", "in 2 hours")
+		runOK(t, app, "add", "read a book", "--due", "none")
+
+		out := runOK(t, app, "list")
+		th.AssertContains(t, out, "2006-01-06 ")
+		th.AssertContains(t, out, "2006-01-02 17:04")
+
+		runOK(t, app, "update", "1", "--due", "2006-02-01")
+		out = runOK(t, app, "list")
+		th.AssertContains(t, out, "2006-02-01")
+
+		runOK(t, app, "update", "1", "--due", "none")
+		out = runOK(t, app, "list")
+		if strings.Contains(out, "2006-02-01") {
+			t.Errorf("got %q, want the due date cleared", out)
+		}
+	})
+
+	t.Run("returns a usage error for an invalid date", func(t *testing.T) {
+		app := setupApp(t)
+		runOK(t, app, "add", "pay the rent")
+
+		for _, args := range [][]string{
+			{"add", "read a book", "--due", "someday"},
+			{"update", "1", "--due", "someday"},
+		} {
+			_, stderr, code := run(app, args...)
+			th.AssertDeepEqual(t, code, cli.ExitUsage)
+			th.AssertContains(t, stderr, "invalid date")
+		}
+	})
+}
+
+func Test_App_Run_Tags(t *testing.T) {
+	t.Run("tags, filters and counts tasks successfully", func(t *testing.T) {
+		app := setupApp(t)
+		runOK(t, app, "add", "fix the login", "--tag", "backend,ops")
+		runOK(t, app, "add", "write the guide", "--tag", "docs")
+		runOK(t, app, "add", "rotate the keys", "--tag", "ops")
+		runOK(t, app, "update", "3", "--tag", "backend", "--untag", "ops")
+
+		out := runOK(t, app, "list", "--tag", "backend", "--tag", "ops")
+		th.AssertContains(t, out, "fix the login")
+		if strings.Contains(out, "rotate the keys") {
+			t.Errorf("got %q, want only tasks with both tags", out)
+		}
+
+		out = runOK(t, app, "list", "--any-tag", "docs,ops")
+		th.AssertContains(t, out, "fix the login")
+		th.AssertContains(t, out, "write the guide")
+
+		out = runOK(t, app, "list", "--no-tag", "backend")
+		th.AssertContains(t, out, "write the guide")
+		if strings.Contains(out, "rotate the keys") {
+			t.Errorf("got %q, want no backend tasks", out)
+		}
+
+		out = runOK(t, app, "tags")
+		var got []string
+		for _, line := range strings.Split(out, "\n")[1:] {
+			if line != "" {
+				got = append(got, strings.Join(strings.Fields(line), " "))
+			}
+		}
+		th.AssertDeepEqual(t, got, []string{"backend 2", "docs 1", "ops 1"})
+	})
+
+	t.Run("reports no tags successfully", func(t *testing.T) {
+		app := setupApp(t)
+		runOK(t, app, "add", "read a book")
+
+		out := runOK(t, app, "tags")
+		th.AssertContains(t, out, "No tags found.")
+	})
+
+	t.Run("returns an error for an invalid tag", func(t *testing.T) {
+		app := setupApp(t)
+
+		_, stderr, code := run(app, "add", "read a book", "--tag", "Books")
+		th.AssertDeepEqual(t, code, cli.ExitError)
+		th.AssertContains(t, stderr, "invalid tag")
+	})
+}
+
+func Test_App_Run_Query(t *testing.T) {
+	t.Run("lists the tasks a query matches successfully", func(t *testing.T) {
+		app := setupApp(t)
+		runOK(t, app, "add", "fix the login", "--tag", "backend",
+			"--priority", "high", "--due", "tomorrow")
+		runOK(t, app, "add", "write the guide", "--tag", "docs")
+		runOK(t, app, "add", "rotate the keys", "--tag", "backend")
+		runOK(t, app, "mark-done", "3")
+
+		out := runOK(t, app, "list", "status:todo tag:backend")
+		th.AssertContains(t, out, "fix the login")
+		if strings.Contains(out, "rotate the keys") {
+			t.Errorf("got %q, want only todo backend tasks", out)
+		}
+
+		out = runOK(t, app, "list", "priority>=high", "OR", `desc~"^write"`)
+		th.AssertContains(t, out, "fix the login")
+		th.AssertContains(t, out, "write the guide")
+
+		out = runOK(t, app, "list", "NOT (due.before:friday OR done)")
+		th.AssertContains(t, out, "write the guide")
+		if strings.Contains(out, "fix the login") {
+			t.Errorf("got %q, want no task due before friday", out)
+		}
+
+		out = runOK(t, app, "list", "--tag", "docs", "todo")
+		th.AssertContains(t, out, "write the guide")
+	})
+
+	t.Run("points at the error in a query", func(t *testing.T) {
+		app := setupApp(t)
+
+		_, stderr, code := run(app, "list", "status:todo AND priority>=urgent")
+		th.AssertDeepEqual(t, code, cli.ExitUsage)
+		th.AssertContains(t, stderr, "column 27")
+		th.AssertContains(t, stderr, "\n  status:todo AND priority>=urgent\n"+
+			strings.Repeat(" ", 28)+"^\n")
+	})
+}
+
+func Test_App_Run_Sort(t *testing.T) {
+	t.Run("sorts and pages the tasks successfully", func(t *testing.T) {
+		app := setupApp(t)
+		runOK(t, app, "add", "read a book")
+		runOK(t, app, "add", "pay the rent", "--due", "tomorrow")
+		runOK(t, app, "add", "fix the login", "--priority", "high")
+		runOK(t, app, "add", "call the bank", "--due", "tomorrow",
+			"--priority", "low")
+
+		out := runOK(t, app, "list")
+		assertOrder(t, out, "fix the login", "call the bank", "read a book",
+			"pay the rent")
+
+		out = runOK(t, app, "list", "--sort", "due,priority:desc")
+		assertOrder(t, out, "call the bank", "pay the rent", "fix the login",
+			"read a book")
+
+		out = runOK(t, app, "list", "--sort", "id:desc", "--limit", "3")
+		assertOrder(t, out, "call the bank", "fix the login", "pay the rent")
+		th.AssertContains(t, out, "Page 1 of 2 (4 tasks)")
+
+		out = runOK(t, app, "list", "--sort", "id:desc", "--limit", "3",
+			"--page", "2")
+		th.AssertContains(t, out, "read a book")
+		th.AssertContains(t, out, "Page 2 of 2 (4 tasks)")
+		if strings.Contains(out, "pay the rent") {
+			t.Errorf("got %q, want only the second page", out)
+		}
+	})
+
+	t.Run("returns a usage error successfully", func(t *testing.T) {
+		testCases := []struct {
+			name    string
+			args    []string
+			wantErr string
+		}{
+			{"rejects an unknown sort key", []string{"--sort", "size"},
+				"size"},
+			{"rejects a negative limit", []string{"--limit", "-1"}, "--limit"},
+			{"rejects a page under 1", []string{"--limit", "2", "--page", "0"},
+				"--page"},
+			{"rejects a page without a limit", []string{"--page", "2"},
+				"--page needs --limit"},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				app := setupApp(t)
+				_, stderr, code := run(app, append([]string{"list"},
+					tc.args...)...)
+				th.AssertDeepEqual(t, code, cli.ExitUsage)
+				th.AssertContains(t, stderr, tc.wantErr)
+			})
+		}
+	})
+}
+
+func Test_App_Run_GroupBy(t *testing.T) {
+	t.Run("lists the tasks in groups successfully", func(t *testing.T) {
+		app := setupApp(t)
+		runOK(t, app, "add", "fix the login", "--tag", "backend,ops",
+			"--priority", "high")
+		runOK(t, app, "add", "write the guide")
+		runOK(t, app, "add", "rotate the keys", "--tag", "ops")
+		runOK(t, app, "mark-done", "3")
+
+		out := runOK(t, app, "list", "--group-by", "status")
+		assertOrder(t, out, "status: todo (2 tasks, oldest created "+
+			th.FixedTime.Format(time.RFC3339), "fix the login",
+			"write the guide", "status: done (1 tasks", "rotate the keys")
+
+		out = runOK(t, app, "list", "--group-by", "tag,priority", "todo")
+		assertOrder(t, out, "tag: backend, priority: high (1 tasks",
+			"tag: ops, priority: high (1 tasks",
+			"tag: (none), priority: none (1 tasks", "write the guide")
+
+		out = runOK(t, app, "list", "--group-by", "tag", "--output", "json")
+		var groups []tk.Group
+		th.AssertNoError(t, json.Unmarshal([]byte(out), &groups))
+		th.AssertDeepEqual(t, len(groups), 3)
+		th.AssertDeepEqual(t, groups[1].Key, map[string]string{"tag": "ops"})
+		th.AssertDeepEqual(t, groups[1].Count, 2)
+		th.AssertDeepEqual(t, groups[1].Tasks[1].Description,
+			"rotate the keys")
+	})
+
+	t.Run("lists the tasks as JSON successfully", func(t *testing.T) {
+		app := setupApp(t)
+		runOK(t, app, "add", "fix the login")
+
+		out := runOK(t, app, "list", "--output", "json")
+		var tasks []tk.Task
+		th.AssertNoError(t, json.Unmarshal([]byte(out), &tasks))
+		th.AssertDeepEqual(t, len(tasks), 1)
+		th.AssertDeepEqual(t, tasks[0].Description, "fix the login")
+
+		out = runOK(t, app, "list", "--output", "json", "done")
+		th.AssertDeepEqual(t, out, "[]\n")
+	})
+
+	t.Run("returns a usage error successfully", func(t *testing.T) {
+		testCases := []struct {
+			name    string
+			args    []string
+			wantErr string
+		}{
+			{"rejects an unknown field", []string{"--group-by", "due"},
+				"due"},
+			{"rejects a limit", []string{"--group-by", "tag", "--limit", "2"},
+				"--limit"},
+			{"rejects an unknown output", []string{"--output", "xml"},
+				"invalid output format"},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				app := setupApp(t)
+				_, stderr, code := run(app, append([]string{"list"},
+					tc.args...)...)
+				th.AssertDeepEqual(t, code, cli.ExitUsage)
+				th.AssertContains(t, stderr, tc.wantErr)
+			})
+		}
+	})
+}
+
+func Test_App_Run_Output(t *testing.T) {
+	t.Run("prints the changed task as asked successfully", func(t *testing.T) {
+		app := setupApp(t)
+
+		out := runOK(t, app, "add", "fix the login", "--output", "json")
+		var task tk.Task
+		th.AssertNoError(t, json.Unmarshal([]byte(out), &task))
+		th.AssertDeepEqual(t, task.Description, "fix the login")
+		th.AssertContains(t, out, `"CreatedAt": "2006-01-02T15:04:05Z"`)
+
+		out = runOK(t, app, "mark-done", "1", "--format", `{{.ID}}\t{{.Status}}`)
+		th.AssertDeepEqual(t, out, "1\tdone\n")
+
+		out = runOK(t, app, "reopen", "1", "--output", "csv")
+		th.AssertContains(t, out, "ID,UID,Description,")
+		th.AssertContains(t, out, ",fix the login,todo,none,")
+
+		out = runOK(t, app, "delete", "1", "--output", "table")
+		th.AssertContains(t, out, "fix the login")
+
+		out = runOK(t, app, "trash", "--output", "ndjson")
+		th.AssertContains(t, out, `"DeletedAt":"2006-01-02T15:04:05Z"`)
+	})
+
+	t.Run("prints the listed tasks as asked successfully", func(t *testing.T) {
+		app := setupApp(t)
+		runOK(t, app, "add", "fix the login", "--due", "yesterday")
+		runOK(t, app, "add", "write the guide", "--tag", "docs")
+
+		out := runOK(t, app, "list", "--sort", "id", "--format",
+			"{{.ID}} {{.Description}}")
+		th.AssertDeepEqual(t, out, "1 fix the login\n2 write the guide\n")
+
+		out = runOK(t, app, "list", "--limit", "1", "--output", "ndjson")
+		th.AssertDeepEqual(t, strings.Count(out, "\n"), 1)
+
+		out, _, code := run(app, "overdue", "--output", "json")
+		th.AssertDeepEqual(t, code, cli.ExitFound)
+		th.AssertContains(t, out, `"Due": "2006-01-01T23:59:59Z"`)
+
+		runOK(t, app, "mark-done", "1")
+		out = runOK(t, app, "overdue", "--output", "json")
+		th.AssertDeepEqual(t, out, "[]\n")
+	})
+
+	t.Run("fits tables in $COLUMNS successfully", func(t *testing.T) {
+		app := setupAppWithEnv(t, map[string]string{
+			cli.FileEnv:    filepath.Join(t.TempDir(), cli.DefaultFilename),
+			cli.ColumnsEnv: "60",
+		})
+		runOK(t, app, "add", "write a very long guide to the task tracker")
+
+		out := runOK(t, app, "list")
+		th.AssertContains(t, out, "  write a v…\n")
+	})
+
+	t.Run("returns a usage error successfully", func(t *testing.T) {
+		testCases := []struct {
+			name    string
+			args    []string
+			wantErr string
+		}{
+			{"rejects an unknown format",
+				[]string{"add", "x", "--output", "xml"}, "invalid output format"},
+			{"rejects a template that doesn't parse",
+				[]string{"list", "--format", "{{.ID"}, "invalid template"},
+			{"rejects a template with another output",
+				[]string{"list", "--output", "csv", "--format", "{{.ID}}"},
+				"--format can't be used with --output csv"},
+			{"rejects a template output without a template",
+				[]string{"due", "--output", "template"}, "needs --format"},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				app := setupApp(t)
+				_, stderr, code := run(app, tc.args...)
+				th.AssertDeepEqual(t, code, cli.ExitUsage)
+				th.AssertContains(t, stderr, tc.wantErr)
+			})
+		}
+	})
+}
+
+func Test_App_Run_Overdue(t *testing.T) {
+	t.Run("exits with ExitFound while tasks are overdue successfully",
+		func(t *testing.T) {
+			app := setupApp(t)
+			clock := app.TimeProvider.(*th.StubTimeProvider)
+			runOK(t, app, "add", "pay the rent", "--due", "tomorrow")
+			runOK(t, app, "add", "call the bank", "--due", "in 3 days")
+			runOK(t, app, "add", "read a book")
+
+			out := runOK(t, app, "overdue")
+			th.AssertContains(t, out, "No overdue tasks.")
+
+			clock.FixedTime = th.FixedTime.Add(48 * time.Hour)
+			out, _, code := run(app, "overdue")
+			th.AssertDeepEqual(t, code, cli.ExitFound)
+			th.AssertContains(t, out, "pay the rent")
+			if strings.Contains(out, "call the bank") {
+				t.Errorf("got %q, want only overdue tasks", out)
+			}
+
+			out, _, code = run(app, "overdue", "--quiet")
+			th.AssertDeepEqual(t, code, cli.ExitFound)
+			th.AssertDeepEqual(t, out, "")
+
+			runOK(t, app, "mark-done", "1")
+			out = runOK(t, app, "overdue", "--quiet")
+			th.AssertDeepEqual(t, out, "")
+		})
+
+	t.Run("exits with ExitFound while tasks are due soon successfully",
+		func(t *testing.T) {
+			app := setupApp(t)
+			runOK(t, app, "add", "pay the rent", "--due", "tomorrow")
+			runOK(t, app, "add", "call the bank", "--due", "in 3 days")
+
+			out := runOK(t, app, "due", "--within", "1h")
+			th.AssertContains(t, out, "No tasks due within 1h.")
+
+			out, _, code := run(app, "due", "--within", "48h")
+			th.AssertDeepEqual(t, code, cli.ExitFound)
+			th.AssertContains(t, out, "pay the rent")
+			if strings.Contains(out, "call the bank") {
+				t.Errorf("got %q, want only tasks due within 48h", out)
+			}
+
+			out, _, code = run(app, "due", "--within", "4d", "--quiet")
+			th.AssertDeepEqual(t, code, cli.ExitFound)
+			th.AssertDeepEqual(t, out, "")
+
+			_, stderr, code := run(app, "due", "--within", "soon")
+			th.AssertDeepEqual(t, code, cli.ExitUsage)
+			th.AssertContains(t, stderr, "soon")
+		})
+}
+
+func Test_App_Run_Sad(t *testing.T) {
+	testCases := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantErr  string
+	}{
+		{
+			name:     "returns a usage error without a command",
+			args:     []string{},
+			wantCode: cli.ExitUsage,
+			wantErr:  "Usage:",
+		},
+		{
+			name:     "returns a usage error for an unknown command",
+			args:     []string{"unknown"},
+			wantCode: cli.ExitUsage,
+			wantErr:  "unknown command",
+		},
+		{
+			name:     "returns a usage error for a non-numeric ID",
+			args:     []string{"delete", "one"},
+			wantCode: cli.ExitUsage,
+			wantErr:  "numeric task ID",
+		},
+		{
+			name:     "returns a usage error for an unknown status",
+			args:     []string{"list", "blocked"},
+			wantCode: cli.ExitUsage,
+			wantErr:  "blocked",
+		},
+		{
+			name:     "prints a DescriptionError message for an empty description",
+			args:     []string{"add", ""},
+			wantCode: cli.ExitError,
+			wantErr:  "invalid task description",
+		},
+		{
+			name:     "prints a TaskNotFoundError message for a missing task",
+			args:     []string{"mark-done", "42"},
+			wantCode: cli.ExitError,
+			wantErr:  "task with ID 42 not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			app := setupApp(t)
+			_, stderr, code := run(app, tc.args...)
+
+			if code != tc.wantCode {
+				t.Errorf("got exit code %d, want %d", code, tc.wantCode)
+			}
+			th.AssertContains(t, stderr, tc.wantErr)
+		})
+	}
+
+	t.Run("prints a FilenameExtError message for a bad tasks file",
+		func(t *testing.T) {
+			app := setupAppWithFile(t, filepath.Join(t.TempDir(), "tasks.txt"))
+			_, stderr, code := run(app, "list")
+
+			if code != cli.ExitError {
+				t.Errorf("got exit code %d, want %d", code, cli.ExitError)
+			}
+			th.AssertContains(t, stderr, "tasks.txt")
+			th.AssertContains(t, stderr, ".yaml")
+		})
+}
+
+func Test_App_Run_Init(t *testing.T) {
+	t.Run("reports whether the tasks file was created or reused successfully",
+		func(t *testing.T) {
+			app := setupApp(t)
+
+			out := runOK(t, app, "init")
+			th.AssertContains(t, out, "Created")
+
+			runOK(t, app, "add", "buy groceries")
+
+			out = runOK(t, app, "init")
+			th.AssertContains(t, out, "Reusing")
+
+			out = runOK(t, app, "list")
+			th.AssertContains(t, out, "buy groceries")
+		})
+
+	t.Run("stores tasks in the format of the file extension successfully",
+		func(t *testing.T) {
+			for _, name := range []string{"tasks.yaml", "tasks.toml"} {
+				path := filepath.Join(t.TempDir(), name)
+				app := setupAppWithFile(t, path)
+
+				runOK(t, app, "init")
+				runOK(t, app, "add", "buy groceries")
+
+				out := runOK(t, app, "list")
+				th.AssertContains(t, out, "buy groceries")
+
+				content, err := os.ReadFile(path)
+				th.AssertNoError(t, err)
+				th.AssertContains(t, string(content), "buy groceries")
+			}
+		})
+
+	t.Run("refuses to reuse a file that isn't a tasks file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "notes.json")
+		err := os.WriteFile(path, []byte("[1, 2, 3]"), 0644)
+		th.AssertNoError(t, err)
+
+		app := setupAppWithFile(t, path)
+		_, stderr, code := run(app, "init")
+		if code != cli.ExitError {
+			t.Errorf("got exit code %d, want %d", code, cli.ExitError)
+		}
+		th.AssertContains(t, stderr, "notes.json")
+
+		got, err := os.ReadFile(path)
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, string(got), "[1, 2, 3]")
+	})
+}
+
+func Test_App_Run_Schema(t *testing.T) {
+	t.Run("upgrades a legacy tasks file and keeps a backup successfully",
+		func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), cli.DefaultFilename)
+			legacy := `{"1": {"ID": 1, "Description": "buy groceries", ` +
+				`"Status": "todo", "CreatedAt": "2006-01-02T15:04:05Z", ` +
+				`"UpdatedAt": "2006-01-02T15:04:05Z"}}`
+			err := os.WriteFile(path, []byte(legacy), 0644)
+			th.AssertNoError(t, err)
+
+			app := setupAppWithFile(t, path)
+			out := runOK(t, app, "list")
+			th.AssertContains(t, out, "buy groceries")
+
+			content, err := os.ReadFile(path)
+			th.AssertNoError(t, err)
+			th.AssertContains(t, string(content), `"schemaVersion"`)
+
+			backup, err := os.ReadFile(path + ".v0.bak")
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, string(backup), legacy)
+		})
+
+	t.Run("refuses a tasks file from a newer version", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), cli.DefaultFilename)
+		err := os.WriteFile(path, []byte(`{"schemaVersion": 999, "data": {}}`), 0644)
+		th.AssertNoError(t, err)
+
+		_, stderr, code := run(setupAppWithFile(t, path), "list")
+		if code != cli.ExitError {
+			t.Errorf("got exit code %d, want %d", code, cli.ExitError)
+		}
+		th.AssertContains(t, stderr, "newer version")
+	})
+}
+
+func Test_App_Run_Workflow(t *testing.T) {
+	t.Run("refuses a transition the workflow doesn't allow", func(t *testing.T) {
+		app := setupApp(t)
+		runOK(t, app, "add", "buy groceries")
+		runOK(t, app, "mark-done", "1")
+
+		_, stderr, code := run(app, "mark-in-progress", "1")
+		th.AssertDeepEqual(t, code, cli.ExitError)
+		th.AssertContains(t, stderr, "from done to in-progress")
+		th.AssertContains(t, stderr, "only reopen moves it, to todo")
+
+		_, stderr, code = run(app, "mark", "1", "todo")
+		th.AssertDeepEqual(t, code, cli.ExitError)
+		th.AssertContains(t, stderr, "only reopen does")
+
+		out := runOK(t, app, "reopen", "1")
+		th.AssertContains(t, out, "reopened as todo")
+
+		_, stderr, code = run(app, "reopen", "1")
+		th.AssertDeepEqual(t, code, cli.ExitError)
+		th.AssertContains(t, stderr, "can't reopen a task that is todo")
+	})
+
+	t.Run("uses the custom statuses of a workflow file successfully",
+		func(t *testing.T) {
+			dir := t.TempDir()
+			workflow := filepath.Join(dir, "workflow.yaml")
+			err := os.WriteFile(workflow, []byte(
+				"statuses: [todo, in-progress, review, blocked, done]\n"+
+					"transitions:\n"+
+					"  - {name: start, from: [todo], to: in-progress}\n"+
+					"  - {name: submit, from: [in-progress], to: review}\n"+
+					"  - {name: block, from: [in-progress, review], to: blocked}\n"+
+					"  - {name: approve, from: [review], to: done}\n",
+			), 0644)
+			th.AssertNoError(t, err)
+			app := setupAppWithEnv(t, map[string]string{
+				cli.FileEnv:     filepath.Join(dir, cli.DefaultFilename),
+				cli.WorkflowEnv: workflow,
+			})
+
+			runOK(t, app, "add", "buy groceries")
+			runOK(t, app, "add", "cook dinner")
+			runOK(t, app, "mark-in-progress", "2")
+			out := runOK(t, app, "mark", "2", "review")
+			th.AssertContains(t, out, "marked as review")
+
+			out = runOK(t, app, "list", "review")
+			th.AssertContains(t, out, "cook dinner")
+			if strings.Contains(out, "buy groceries") {
+				t.Errorf("got %q, want only tasks in review", out)
+			}
+
+			_, stderr, code := run(app, "mark", "1", "blocked")
+			th.AssertDeepEqual(t, code, cli.ExitError)
+			th.AssertContains(t, stderr, "only move to in-progress")
+
+			_, stderr, code = run(app, "list", "banana")
+			th.AssertDeepEqual(t, code, cli.ExitUsage)
+			th.AssertContains(t, stderr, "todo, in-progress, review, blocked, done")
+		})
+
+	t.Run("prints a WorkflowError message for an invalid workflow file",
+		func(t *testing.T) {
+			dir := t.TempDir()
+			workflow := filepath.Join(dir, "workflow.json")
+			err := os.WriteFile(workflow, []byte(`{"statuses": ["To Do"]}`), 0644)
+			th.AssertNoError(t, err)
+			app := setupAppWithEnv(t, map[string]string{
+				cli.FileEnv:     filepath.Join(dir, cli.DefaultFilename),
+				cli.WorkflowEnv: workflow,
+			})
+
+			_, stderr, code := run(app, "list")
+			th.AssertDeepEqual(t, code, cli.ExitError)
+			th.AssertContains(t, stderr, "invalid workflow")
+			th.AssertContains(t, stderr, cli.WorkflowEnv)
+		})
+}
+
+func Test_App_Run_Lock(t *testing.T) {
+	t.Run("prints a LockTimeoutError message while another process writes",
+		func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), cli.DefaultFilename)
+			app := setupAppWithEnv(t, map[string]string{
+				cli.FileEnv:        path,
+				cli.LockTimeoutEnv: "10ms",
+			})
+			runOK(t, app, "add", "buy groceries")
+
+			lock, err := st.LockFile(path+".lock", time.Second)
+			th.AssertNoError(t, err)
+			defer lock.Unlock()
+
+			_, stderr, code := run(app, "add", "cook dinner")
+			if code != cli.ExitError {
+				t.Errorf("got exit code %d, want %d", code, cli.ExitError)
+			}
+			th.AssertContains(t, stderr, "timed out")
+		})
+
+	t.Run("returns a usage error for a bad lock timeout", func(t *testing.T) {
+		app := setupAppWithEnv(t, map[string]string{
+			cli.FileEnv:        filepath.Join(t.TempDir(), cli.DefaultFilename),
+			cli.LockTimeoutEnv: "soon",
+		})
+		_, stderr, code := run(app, "list")
+		if code != cli.ExitUsage {
+			t.Errorf("got exit code %d, want %d", code, cli.ExitUsage)
+		}
+		th.AssertContains(t, stderr, cli.LockTimeoutEnv)
+	})
+}
+
+func Test_App_Run_Backups(t *testing.T) {
+	t.Run("restores the tasks file from a listed backup successfully",
+		func(t *testing.T) {
+			app := setupApp(t)
+			clock := app.TimeProvider.(*th.StubTimeProvider)
+
+			out := runOK(t, app, "backups", "list")
+			th.AssertContains(t, out, "No backups found.")
+
+			runOK(t, app, "add", "buy groceries")
+			clock.FixedTime = th.FixedTime.Add(time.Minute)
+			runOK(t, app, "delete", "1")
+
+			out = runOK(t, app, "backups", "list")
+			th.AssertContains(t, out, "tasks-20060102T150505.000Z.json")
+
+			out = runOK(t, app, "backups", "restore",
+				"tasks-20060102T150505.000Z.json")
+			th.AssertContains(t, out, "restored")
+
+			out = runOK(t, app, "list")
+			th.AssertContains(t, out, "buy groceries")
+		})
+
+	t.Run("keeps the number of backups set in the environment",
+		func(t *testing.T) {
+			app := setupAppWithEnv(t, map[string]string{
+				cli.FileEnv:       filepath.Join(t.TempDir(), "tasks.json"),
+				cli.BackupKeepEnv: "2",
+			})
+
+			for range 5 {
+				runOK(t, app, "add", "buy groceries")
+			}
+
+			out := runOK(t, app, "backups", "list")
+			th.AssertDeepEqual(t, strings.Count(out, "\n"), 3)
+		})
+
+	t.Run("prints errors for a bad backup or policy", func(t *testing.T) {
+		app := setupApp(t)
+		_, stderr, code := run(app, "restore", "missing.json")
+		th.AssertDeepEqual(t, code, cli.ExitError)
+		th.AssertContains(t, stderr, "backups list")
+
+		_, stderr, code = run(app, "backups", "restore")
+		th.AssertDeepEqual(t, code, cli.ExitUsage)
+		th.AssertContains(t, stderr, "backups list|restore <backup>")
+
+		app = setupAppWithEnv(t, map[string]string{
+			cli.FileEnv:         filepath.Join(t.TempDir(), "tasks.json"),
+			cli.BackupMaxAgeEnv: "a month",
+		})
+		_, stderr, code = run(app, "list")
+		th.AssertDeepEqual(t, code, cli.ExitUsage)
+		th.AssertContains(t, stderr, cli.BackupMaxAgeEnv)
+	})
+}
+
+func Test_App_Run_Trash(t *testing.T) {
+	t.Run("moves deleted tasks to the trash and back successfully",
+		func(t *testing.T) {
+			app := setupApp(t)
+			runOK(t, app, "add", "buy groceries")
+			runOK(t, app, "add", "cook dinner")
+
+			out := runOK(t, app, "trash")
+			th.AssertContains(t, out, "The trash is empty.")
+
+			out = runOK(t, app, "delete", "2")
+			th.AssertContains(t, out, "moved to the trash")
+
+			out = runOK(t, app, "list")
+			if strings.Contains(out, "cook dinner") {
+				t.Errorf("got %q, want no trashed tasks", out)
+			}
+
+			out = runOK(t, app, "trash")
+			th.AssertContains(t, out, "cook dinner")
+			th.AssertContains(t, out, "2006-01-02T15:04:05Z")
+
+			out = runOK(t, app, "restore", "2")
+			th.AssertContains(t, out, "Task restored successfully (ID: 2)")
+
+			out = runOK(t, app, "list")
+			th.AssertContains(t, out, "cook dinner")
+
+			_, stderr, code := run(app, "restore", "2")
+			th.AssertDeepEqual(t, code, cli.ExitError)
+			th.AssertContains(t, stderr, "task with ID 2 not found")
+		})
+
+	t.Run("purges the tasks trashed before an age successfully",
+		func(t *testing.T) {
+			app := setupApp(t)
+			clock := app.TimeProvider.(*th.StubTimeProvider)
+			runOK(t, app, "add", "buy groceries")
+			runOK(t, app, "add", "cook dinner")
+			runOK(t, app, "delete", "1")
+			clock.FixedTime = th.FixedTime.Add(40 * 24 * time.Hour)
+			runOK(t, app, "delete", "2")
+
+			out := runOK(t, app, "trash", "purge", "--older-than", "30d")
+			th.AssertContains(t, out, "Purged 1 tasks from the trash: 1")
+
+			out = runOK(t, app, "trash")
+			th.AssertContains(t, out, "cook dinner")
+
+			out = runOK(t, app, "trash", "purge")
+			th.AssertContains(t, out, "Purged 1 tasks from the trash: 2")
+
+			_, stderr, code := run(app, "trash", "purge", "--older-than", "soon")
+			th.AssertDeepEqual(t, code, cli.ExitUsage)
+			th.AssertContains(t, stderr, "soon")
+		})
+}
+
+func Test_App_Run_Archive(t *testing.T) {
+	t.Run("creates no archive file until a task is archived", func(t *testing.T) {
+		for _, name := range []string{"t.json", "t.yaml", "t.toml"} {
+			dir := t.TempDir()
+			app := setupAppWithFile(t, filepath.Join(dir, name))
+			runOK(t, app, "add", "buy groceries")
+			runOK(t, app, "list", "--archived")
+
+			entries, err := os.ReadDir(dir)
+			th.AssertNoError(t, err)
+			for _, entry := range entries {
+				if strings.Contains(entry.Name(), ".archive") {
+					t.Errorf("got %s, want no archive file", entry.Name())
+				}
+			}
+		}
+	})
+
+	t.Run("never gives an archived ID to a new task successfully",
+		func(t *testing.T) {
+			app := setupApp(t)
+			clock := app.TimeProvider.(*th.StubTimeProvider)
+			for i, description := range []string{
+				"buy groceries", "cook dinner", "walk the dog",
+			} {
+				clock.FixedTime = th.FixedTime.Add(time.Duration(i) * time.Minute)
+				runOK(t, app, "add", description)
+			}
+			clock.FixedTime = th.FixedTime.Add(3 * time.Minute)
+			runOK(t, app, "mark-done", "3")
+			runOK(t, app, "archive")
+
+			// the backup taken before the third task was added
+			runOK(t, app, "backups", "restore", "tasks-20060102T150605.000Z.json")
+
+			out := runOK(t, app, "add", "feed the cat")
+			th.AssertContains(t, out, "ID: 4")
+			out = runOK(t, app, "add", "water the plants")
+			th.AssertContains(t, out, "ID: 5")
+
+			out = runOK(t, app, "unarchive", "3")
+			th.AssertContains(t, out, "Task unarchived successfully (ID: 3)")
+		})
+
+	t.Run("moves done tasks to the archive and back successfully",
+		func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), cli.DefaultFilename)
+			app := setupAppWithFile(t, path)
+			clock := app.TimeProvider.(*th.StubTimeProvider)
+			runOK(t, app, "add", "buy groceries")
+			runOK(t, app, "add", "cook dinner")
+			runOK(t, app, "add", "walk the dog")
+			runOK(t, app, "mark-done", "1")
+			clock.FixedTime = th.FixedTime.Add(40 * 24 * time.Hour)
+			runOK(t, app, "mark-done", "2")
+
+			out := runOK(t, app, "archive", "--older-than", "30d")
+			th.AssertContains(t, out, "Archived 1 tasks: 1")
+			out = runOK(t, app, "archive")
+			th.AssertContains(t, out, "Archived 1 tasks: 2")
+
+			_, err := os.Stat(filepath.Join(filepath.Dir(path),
+				"tasks.archive.json"))
+			th.AssertNoError(t, err)
+
+			out = runOK(t, app, "list")
+			if strings.Contains(out, "buy groceries") {
+				t.Errorf("got %q, want no archived tasks", out)
+			}
+
+			out = runOK(t, app, "list", "--archived", "done")
+			th.AssertContains(t, out, "buy groceries")
+			th.AssertContains(t, out, "cook dinner")
+
+			out = runOK(t, app, "unarchive", "1")
+			th.AssertContains(t, out, "Task unarchived successfully (ID: 1)")
+
+			out = runOK(t, app, "list", "done")
+			th.AssertContains(t, out, "buy groceries")
+
+			out = runOK(t, app, "add", "feed the cat")
+			th.AssertContains(t, out, "ID: 4")
+
+			_, stderr, code := run(app, "unarchive", "1")
+			th.AssertDeepEqual(t, code, cli.ExitError)
+			th.AssertContains(t, stderr, "task with ID 1 not found")
+		})
+
+	t.Run("archives done tasks on save with $TASK_CLI_AUTO_ARCHIVE "+
+		"successfully", func(t *testing.T) {
+		app := setupAppWithEnv(t, map[string]string{
+			cli.FileEnv:        filepath.Join(t.TempDir(), cli.DefaultFilename),
+			cli.AutoArchiveEnv: "7d",
+		})
+		clock := app.TimeProvider.(*th.StubTimeProvider)
+		runOK(t, app, "add", "buy groceries")
+		runOK(t, app, "mark-done", "1")
+
+		clock.FixedTime = th.FixedTime.Add(8 * 24 * time.Hour)
+		runOK(t, app, "add", "cook dinner")
+
+		out := runOK(t, app, "list")
+		if strings.Contains(out, "buy groceries") {
+			t.Errorf("got %q, want the done task archived", out)
+		}
+		out = runOK(t, app, "list", "--archived")
+		th.AssertContains(t, out, "buy groceries")
+	})
+
+	t.Run("returns a usage error successfully", func(t *testing.T) {
+		testCases := []struct {
+			name string
+			args []string
+			env  string
+		}{
+			{"rejects an invalid age", []string{"archive", "--older-than",
+				"soon"}, ""},
+			{"rejects an argument", []string{"archive", "1"}, ""},
+			{"rejects a missing ID", []string{"unarchive"}, ""},
+			{"rejects an invalid auto archive age", []string{"list"}, "soon"},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+				app := setupAppWithEnv(t, map[string]string{
+					cli.FileEnv: filepath.Join(t.TempDir(),
+						cli.DefaultFilename),
+					cli.AutoArchiveEnv: tc.env,
+				})
+
+				_, _, code := run(app, tc.args...)
+				th.AssertDeepEqual(t, code, cli.ExitUsage)
+			})
+		}
+	})
+}
+
+func Test_App_Run_Doctor(t *testing.T) {
+	t.Run("repairs a truncated tasks file and reports the loss successfully",
+		func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), cli.DefaultFilename)
+			app := setupAppWithFile(t, path)
+
+			runOK(t, app, "add", "buy groceries")
+			runOK(t, app, "add", "walk the dog")
+
+			out := runOK(t, app, "doctor")
+			th.AssertContains(t, out, "healthy (2 tasks)")
+
+			content, err := os.ReadFile(path)
+			th.AssertNoError(t, err)
+			truncated := content[:strings.Index(string(content), "walk")]
+			err = os.WriteFile(path, []byte(truncated), 0644)
+			th.AssertNoError(t, err)
+
+			_, stderr, code := run(app, "list")
+			th.AssertDeepEqual(t, code, cli.ExitError)
+			th.AssertContains(t, stderr, "doctor --repair")
+
+			_, stderr, code = run(app, "doctor")
+			th.AssertDeepEqual(t, code, cli.ExitError)
+			th.AssertContains(t, stderr, "corrupt")
+
+			out = runOK(t, app, "doctor", "--repair")
+			th.AssertContains(t, out, "Recovered 1 tasks: 1")
+			th.AssertContains(t, out, "Lost task 2")
+			th.AssertContains(t, out, path+".corrupt")
+
+			out = runOK(t, app, "list")
+			th.AssertContains(t, out, "buy groceries")
+		})
+
+	t.Run("repairs a corrupt archive file successfully", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, cli.DefaultFilename)
+		archive := filepath.Join(dir, "tasks.archive.json")
+		app := setupAppWithFile(t, path)
+		runOK(t, app, "add", "buy groceries")
+		runOK(t, app, "add", "walk the dog")
+		runOK(t, app, "mark-done", "1")
+		runOK(t, app, "mark-done", "2")
+		runOK(t, app, "archive")
+
+		out := runOK(t, app, "doctor")
+		th.AssertContains(t, out, "Archive file "+archive+" is healthy (2 tasks)")
+
+		content, err := os.ReadFile(archive)
+		th.AssertNoError(t, err)
+		truncated := content[:strings.Index(string(content), "walk")]
+		err = os.WriteFile(archive, truncated, 0644)
+		th.AssertNoError(t, err)
+
+		_, stderr, code := run(app, "add", "call mum")
+		th.AssertDeepEqual(t, code, cli.ExitError)
+		th.AssertContains(t, stderr, "doctor --repair")
+
+		_, stderr, code = run(app, "doctor")
+		th.AssertDeepEqual(t, code, cli.ExitError)
+		th.AssertContains(t, stderr, "corrupt")
+
+		out = runOK(t, app, "doctor", "--repair")
+		th.AssertContains(t, out, "Repaired corrupt archive file "+archive)
+		th.AssertContains(t, out, "Recovered 1 tasks: 1")
+		th.AssertContains(t, out, "Lost task 2")
+
+		out = runOK(t, app, "add", "call mum")
+		th.AssertContains(t, out, "ID: 3")
+	})
+
+	t.Run("reports the tasks cut off between records and offers a backup",
+		func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), cli.DefaultFilename)
+			app := setupAppWithFile(t, path)
+			runOK(t, app, "add", "buy groceries")
+			runOK(t, app, "add", "walk the dog")
+			runOK(t, app, "add", "call mum")
+			runOK(t, app, "update", "1", "buy groceries and cook")
+
+			content, err := os.ReadFile(path)
+			th.AssertNoError(t, err)
+			truncated := content[:strings.Index(string(content), `"2":`)]
+			err = os.WriteFile(path, truncated, 0644)
+			th.AssertNoError(t, err)
+
+			out := runOK(t, app, "doctor", "--repair")
+			th.AssertContains(t, out, "Recovered 1 tasks: 1")
+			th.AssertContains(t, out, "cut short")
+			th.AssertContains(t, out, "Lost task 2\nLost task 3\n")
+			th.AssertContains(t, out, "still holds task 2, task 3")
+			if strings.Contains(out, "Nothing was lost") {
+				t.Errorf("got %q, want the lost tasks only", out)
+			}
+
+			start := strings.Index(out, "The backup ") + len("The backup ")
+			backup := out[start : start+strings.Index(out[start:], " ")]
+			runOK(t, app, "backups", "restore", backup)
+			out = runOK(t, app, "list")
+			th.AssertContains(t, out, "walk the dog")
+			th.AssertContains(t, out, "call mum")
+		})
+}
+
+func Test_App_Run_Doctor_Integrity(t *testing.T) {
+	t.Run("reports and fixes integrity issues of a hand-edited file",
+		func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), cli.DefaultFilename)
+			edited := `{"schemaVersion": 1, "data": {` +
+				`"1": {"ID": 1, "Description": "buy groceries", "Status": "DONE", ` +
+				`"CreatedAt": "2006-01-02T15:04:05Z", ` +
+				`"UpdatedAt": "2006-01-02T15:04:05Z"}, ` +
+				`"2": {"ID": 7, "Description": "", "Status": "todo", ` +
+				`"CreatedAt": "2006-01-02T15:04:05Z", ` +
+				`"UpdatedAt": "2006-01-01T15:04:05Z"}}}`
+			err := os.WriteFile(path, []byte(edited), 0644)
+			th.AssertNoError(t, err)
+			app := setupAppWithFile(t, path)
+
+			out, stderr, code := run(app, "doctor")
+			th.AssertDeepEqual(t, code, cli.ExitError)
+			th.AssertContains(t, out, "invalid-status")
+			th.AssertContains(t, out, "id-mismatch")
+			th.AssertContains(t, out, "updated-before-created")
+			th.AssertContains(t, stderr, "found 4 integrity issues")
+			th.AssertContains(t, stderr, "doctor --fix")
+
+			out, stderr, code = run(app, "doctor", "--fix")
+			th.AssertDeepEqual(t, code, cli.ExitError)
+			th.AssertContains(t, out, "fixed")
+			th.AssertContains(t, stderr, "found 1 integrity issues")
+			th.AssertContains(t, stderr, "edit the tasks file")
+
+			out = runOK(t, app, "list", "done")
+			th.AssertContains(t, out, "buy groceries")
+
+			runOK(t, app, "update", "2", "walk the dog")
+			out = runOK(t, app, "doctor")
+			th.AssertContains(t, out, "healthy (2 tasks)")
+		})
+}
+
+func run(app *cli.App, args ...string) (string, string, int) {
+	var stdout, stderr bytes.Buffer
+	app.Stdout = &stdout
+	app.Stderr = &stderr
+	code := app.Run(args)
+	return stdout.String(), stderr.String(), code
+}
+
+func runOK(t testing.TB, app *cli.App, args ...string) string {
+	t.Helper()
+	stdout, stderr, code := run(app, args...)
+	if code != cli.ExitOK {
+		t.Fatalf("got exit code %d for %v, want %d: %s",
+			code, args, cli.ExitOK, stderr)
+	}
+	return stdout
+}
+
+func setupApp(t testing.TB) *cli.App {
+	t.Helper()
+	return setupAppWithFile(t, filepath.Join(t.TempDir(), cli.DefaultFilename))
+}
+
+func setupAppWithFile(t testing.TB, path string) *cli.App {
+	t.Helper()
+	return setupAppWithEnv(t, map[string]string{cli.FileEnv: path})
+}
+
+func setupAppWithEnv(t testing.TB, env map[string]string) *cli.App {
+	t.Helper()
+	return &cli.App{
+		Getenv:       func(key string) string { return env[key] },
+		TimeProvider: &th.StubTimeProvider{FixedTime: th.FixedTime},
+	}
+}
+
+// assertOrder checks that the substrings appear in out in order.
+func assertOrder(t testing.TB, out string, substrings ...string) {
+	t.Helper()
+	last := -1
+	for _, sub := range substrings {
+		i := strings.Index(out, sub)
+		if i <= last {
+			t.Fatalf("got %q, want %q in order", out, substrings)
+		}
+		last = i
+	}
+}
@@ -1,10 +1,12 @@
 package integration_test
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 
 	st "github.com/alnah/task-tracker/internal/store"
@@ -15,15 +17,11 @@ import (
 // TODO: add a get filepath method for store?
 // TODO: tester le store
 
-// Edge
-// abrupt shutdowns
-// recovery procedures
-
 func Test_Integration_Happy(t *testing.T) {
 	t.Parallel()
 
 	t.Run("creates multiple tasks successfully", func(t *testing.T) {
-		taskRepo, filepath := setupTaskRepository(t)
+		taskRepo := setupTaskRepository(t)
 
 		var wantTasks = tk.Tasks{}
 		var gotTasks = tk.Tasks{}
@@ -32,7 +30,9 @@ func Test_Integration_Happy(t *testing.T) {
 			id := uint(i)
 			desc := getTaskDesc(id)
 
-			gotTask, err := taskRepo.CreateTask(filepath, desc)
+			gotTask, err := taskRepo.CreateTask(tk.CreateTaskParams{
+				Description: desc,
+			})
 			th.AssertNoError(t, err)
 
 			gotTasks[id] = gotTask
@@ -43,7 +43,7 @@ func Test_Integration_Happy(t *testing.T) {
 	})
 
 	t.Run("updates multiple tasks successfully", func(t *testing.T) {
-		taskRepo, filepath := setupTaskRepository(t)
+		taskRepo := setupTaskRepository(t)
 
 		var wantTasks = tk.Tasks{}
 		var gotTasks = tk.Tasks{}
@@ -57,25 +57,37 @@ func Test_Integration_Happy(t *testing.T) {
 				updateStatus = tk.InProgress // even tasks are updated to "in-progress"
 			}
 
-			_, err := taskRepo.CreateTask(filepath, desc)
+			_, err := taskRepo.CreateTask(tk.CreateTaskParams{
+				Description: desc,
+			})
 			th.AssertNoError(t, err)
 
-			updatedTask, err := taskRepo.UpdateTask(filepath, tk.UpdateTaskParams{
+			updatedTask, err := taskRepo.UpdateTask(tk.UpdateTaskParams{
 				ID:          id,
 				Description: &updateDesc,
 				Status:      &updateStatus,
 			})
 			th.AssertNoError(t, err)
 
+			wantTask := th.NewTestTask(id, updateDesc, updateStatus)
+			wantTask.History = []tk.StatusChange{
+				{From: tk.Todo, To: updateStatus, At: th.FixedTime},
+			}
+			if updateStatus == tk.Done {
+				wantTask.CompletedAt = &th.FixedTime
+			} else {
+				wantTask.StartedAt = &th.FixedTime
+			}
+
 			gotTasks[id] = updatedTask
-			wantTasks[id] = th.NewTestTask(id, updateDesc, updateStatus)
+			wantTasks[id] = wantTask
 		}
 
 		th.AssertDeepEqual(t, gotTasks, wantTasks)
 	})
 
 	t.Run("deletes multiple tasks successfully", func(t *testing.T) {
-		taskRepo, filepath := setupTaskRepository(t)
+		taskRepo := setupTaskRepository(t)
 
 		var wantTasks = tk.Tasks{}
 		var gotTasks = tk.Tasks{}
@@ -84,7 +96,9 @@ func Test_Integration_Happy(t *testing.T) {
 			id := uint(i)
 			desc := getTaskDesc(id)
 
-			gotTask, err := taskRepo.CreateTask(filepath, desc)
+			gotTask, err := taskRepo.CreateTask(tk.CreateTaskParams{
+				Description: desc,
+			})
 			th.AssertNoError(t, err)
 
 			if i%2 == 0 { // delete even tasks
@@ -97,7 +111,7 @@ func Test_Integration_Happy(t *testing.T) {
 	})
 
 	t.Run("reads all tasks successfully", func(t *testing.T) {
-		taskRepo, filepath := setupTaskRepository(t)
+		taskRepo := setupTaskRepository(t)
 
 		var wantTasks = tk.Tasks{}
 
@@ -105,18 +119,20 @@ func Test_Integration_Happy(t *testing.T) {
 			id := uint(i)
 			desc := getTaskDesc(id)
 
-			wantTask, err := taskRepo.CreateTask(filepath, desc)
+			wantTask, err := taskRepo.CreateTask(tk.CreateTaskParams{
+				Description: desc,
+			})
 			th.AssertNoError(t, err)
 			wantTasks[id] = wantTask
 		}
 
-		gotTasks, err := taskRepo.ReadAllTasks(filepath)
+		gotTasks, err := taskRepo.ReadAllTasks()
 		th.AssertNoError(t, err)
 		th.AssertDeepEqual(t, gotTasks, wantTasks)
 	})
 
 	t.Run("reads multiple tasks by status successfully", func(t *testing.T) {
-		taskRepo, filepath := setupTaskRepository(t)
+		taskRepo := setupTaskRepository(t)
 
 		gotTasksByStatus := map[tk.Status]tk.Tasks{}
 		wantTasksByStatus := map[tk.Status]tk.Tasks{}
@@ -140,10 +156,12 @@ func Test_Integration_Happy(t *testing.T) {
 				updatedStatus = tk.Done
 			}
 
-			_, err := taskRepo.CreateTask(filepath, desc)
+			_, err := taskRepo.CreateTask(tk.CreateTaskParams{
+				Description: desc,
+			})
 			th.AssertNoError(t, err)
 
-			gotTask, err := taskRepo.UpdateTask(filepath, tk.UpdateTaskParams{
+			gotTask, err := taskRepo.UpdateTask(tk.UpdateTaskParams{
 				ID:          id,
 				Description: &desc,
 				Status:      &updatedStatus,
@@ -154,7 +172,7 @@ func Test_Integration_Happy(t *testing.T) {
 		}
 
 		for status := range gotTasksByStatus {
-			tasks, err := taskRepo.ReadManyTasks(filepath, status)
+			tasks, err := taskRepo.ReadManyTasks(tk.TaskFilter{Status: status})
 			th.AssertNoError(t, err)
 			gotTasksByStatus[status] = tasks
 		}
@@ -213,15 +231,17 @@ func Test_Integration_Sad(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
-			store := setupJSONFileStore(t, tc.storeParams)
-			_, err := store.InitFile()
+			store := setupFileStore(t, tc.storeParams)
+			_, _, err := store.InitFile()
 			th.AssertError(t, err, tc.wantErr)
 		})
 	}
 
 	t.Run("fails to load data from the store", func(t *testing.T) {
-		taskRepo, filepath := setupTaskRepository(t)
-		_, err := taskRepo.CreateTask(filepath, "test_task")
+		taskRepo := setupTaskRepository(t)
+		_, err := taskRepo.CreateTask(tk.CreateTaskParams{
+			Description: "test_task",
+		})
 		th.AssertNoError(t, err)
 
 		_, err = taskRepo.Store.LoadData("bad_file.json")
@@ -229,14 +249,16 @@ func Test_Integration_Sad(t *testing.T) {
 	})
 
 	t.Run("fails to save data to the store", func(t *testing.T) {
-		taskRepo, filepath := setupTaskRepository(t)
-		_, err := taskRepo.CreateTask(filepath, "test_task")
+		taskRepo := setupTaskRepository(t)
+		_, err := taskRepo.CreateTask(tk.CreateTaskParams{
+			Description: "test_task",
+		})
 		th.AssertNoError(t, err)
 
-		tasks, err := taskRepo.ReadAllTasks(filepath)
+		list, err := taskRepo.Store.LoadData(taskRepo.Filepath())
 		th.AssertNoError(t, err)
 
-		err = taskRepo.Store.SaveData(tasks, "bad_file.json")
+		err = taskRepo.Store.SaveData(list, "bad_file.json")
 		th.AssertError(t, err, &os.PathError{})
 	})
 
@@ -262,22 +284,26 @@ func Test_Integration_Sad(t *testing.T) {
 
 		for _, tc := range testCases {
 			t.Run(tc.name, func(t *testing.T) {
-				taskRepo, filepath := setupTaskRepository(t)
+				taskRepo := setupTaskRepository(t)
 
-				_, err := taskRepo.CreateTask(filepath, tc.description)
+				_, err := taskRepo.CreateTask(tk.CreateTaskParams{
+					Description: tc.description,
+				})
 				th.AssertError(t, err, tc.wantErr)
 			})
 		}
 	})
 
 	t.Run("handles update task error conditions", func(t *testing.T) {
-		taskRepo, filepath := setupTaskRepository(t)
+		taskRepo := setupTaskRepository(t)
 		updateDescription := "update_test_task"
 
 		emptyString := new(string)
 		longDescription := strings.Repeat("a", 301)
 
-		_, err := taskRepo.CreateTask(filepath, "test_task")
+		_, err := taskRepo.CreateTask(tk.CreateTaskParams{
+			Description: "test_task",
+		})
 		th.AssertNoError(t, err)
 
 		tests := []struct {
@@ -320,7 +346,7 @@ func Test_Integration_Sad(t *testing.T) {
 				if tc.wantErr != nil {
 					expectedError = tc.wantErr.(error)
 				}
-				_, err := taskRepo.UpdateTask(filepath, updateParams)
+				_, err := taskRepo.UpdateTask(updateParams)
 				th.AssertError(t, err, expectedError)
 			})
 		}
@@ -328,13 +354,146 @@ func Test_Integration_Sad(t *testing.T) {
 
 	t.Run("returns a TaskNotFoundError when deleting a task with a non-existing ID",
 		func(t *testing.T) {
-			taskRepo, filepath := setupTaskRepository(t)
+			taskRepo := setupTaskRepository(t)
 
-			_, err := taskRepo.DeleteTask(filepath, 0)
+			_, err := taskRepo.DeleteTask(0)
 			th.AssertError(t, err, &tk.TaskNotFoundError{})
 		})
 }
 
+func Test_Integration_Concurrency(t *testing.T) {
+	t.Run("creates tasks from concurrent processes without losing any or "+
+		"reusing an ID",
+		func(t *testing.T) {
+			taskRepo := setupTaskRepository(t)
+
+			const workers, tasksPerWorker = 8, 10
+			var wg sync.WaitGroup
+			errs := make(chan error, workers*tasksPerWorker)
+
+			for w := range workers {
+				// each worker stands for a separate task-cli process with its own
+				// store, lock handle and ID generator
+				workerRepo := newTaskRepository(t, taskRepo.Filepath())
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := range tasksPerWorker {
+						_, err := workerRepo.CreateTask(tk.CreateTaskParams{
+							Description: fmt.Sprintf("task_%d_%d", w, i),
+						})
+						errs <- err
+					}
+				}()
+			}
+			wg.Wait()
+			close(errs)
+
+			for err := range errs {
+				th.AssertNoError(t, err)
+			}
+
+			tasks, err := taskRepo.ReadAllTasks()
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, len(tasks), workers*tasksPerWorker)
+			for id, task := range tasks {
+				th.AssertDeepEqual(t, task.ID, id)
+			}
+		})
+}
+
+func Test_Integration_Edge(t *testing.T) {
+	t.Run("keeps working after an abrupt shutdown left a temporary file behind",
+		func(t *testing.T) {
+			taskRepo := setupTaskRepository(t)
+			wantTask, err := taskRepo.CreateTask(tk.CreateTaskParams{
+				Description: "test_task",
+			})
+			th.AssertNoError(t, err)
+
+			// a killed save leaves its temporary sibling, never a truncated file
+			dir, name := filepath.Split(taskRepo.Filepath())
+			stray := filepath.Join(dir, "."+name+".tmp-123")
+			err = os.WriteFile(stray, []byte(`{"1": {"ID": 1, "Desc`), 0644)
+			th.AssertNoError(t, err)
+
+			gotTasks, err := taskRepo.ReadAllTasks()
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, gotTasks, tk.Tasks{1: wantTask})
+
+			_, err = taskRepo.CreateTask(tk.CreateTaskParams{
+				Description: "test_task_2",
+			})
+			th.AssertNoError(t, err)
+		})
+
+	t.Run("salvages the complete tasks of a truncated file", func(t *testing.T) {
+		taskRepo := setupTaskRepository(t)
+		var wantTasks = make(tk.Tasks)
+		for i := range 3 {
+			task, err := taskRepo.CreateTask(tk.CreateTaskParams{
+				Description: getTaskDesc(uint(i + 1)),
+			})
+			th.AssertNoError(t, err)
+			wantTasks[task.ID] = task
+		}
+
+		content, err := os.ReadFile(taskRepo.Filepath())
+		th.AssertNoError(t, err)
+		truncated := content[:bytes.Index(content, []byte(`"3":`))+20]
+		err = os.WriteFile(taskRepo.Filepath(), truncated, 0644)
+		th.AssertNoError(t, err)
+
+		_, err = taskRepo.ReadAllTasks()
+		th.AssertError(t, err, &st.CorruptFileError{})
+
+		report, err := taskRepo.Repair()
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, report.Lost,
+			[]string{"data.Tasks.3", st.TruncatedPath})
+
+		delete(wantTasks, 3)
+		gotTasks, err := taskRepo.ReadAllTasks()
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, gotTasks, wantTasks)
+	})
+
+	t.Run("reports the tasks of a file truncated between them",
+		func(t *testing.T) {
+			taskRepo := setupTaskRepository(t)
+			for i := range 3 {
+				_, err := taskRepo.CreateTask(tk.CreateTaskParams{
+					Description: getTaskDesc(uint(i + 1)),
+				})
+				th.AssertNoError(t, err)
+			}
+			desc := "test_task_1, edited"
+			_, err := taskRepo.UpdateTask(tk.UpdateTaskParams{
+				ID:          1,
+				Description: &desc,
+			})
+			th.AssertNoError(t, err)
+
+			content, err := os.ReadFile(taskRepo.Filepath())
+			th.AssertNoError(t, err)
+			truncated := content[:bytes.Index(content, []byte(`"2":`))]
+			err = os.WriteFile(taskRepo.Filepath(), truncated, 0644)
+			th.AssertNoError(t, err)
+
+			report, err := taskRepo.Repair()
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, report.Backup, "")
+			th.AssertDeepEqual(t, report.Lost, []string{
+				st.TruncatedPath, "data.Tasks.2", "data.Tasks.3",
+			})
+
+			gotTasks, err := taskRepo.ReadAllTasks()
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, gotTasks[1].Description, desc)
+			th.AssertDeepEqual(t, len(gotTasks), 1)
+		})
+}
+
 const repetitions = 100
 
 type storeParams struct {
@@ -343,52 +502,68 @@ type storeParams struct {
 	InitData st.JSONInitData
 }
 
-func setupJSONFileStore(
+func setupFileStore(
 	t testing.TB,
 	params storeParams,
-) st.JSONFileStore[tk.Tasks] {
+) st.FileStore[tk.TaskList] {
 	t.Helper()
-	store := st.JSONFileStore[tk.Tasks]{
-		DestDir:  params.DestDir,
-		Filename: params.Filename,
-		InitData: params.InitData,
+	store := st.FileStore[tk.TaskList]{
+		DestDir:    params.DestDir,
+		Filename:   params.Filename,
+		InitData:   params.InitData,
+		Migrations: tk.Migrations,
 	}
 	t.Cleanup(func() { os.RemoveAll(store.DestDir) })
 	return store
 }
 
-func setupTaskRepository(t testing.TB) (tk.JSONFileTaskRepository, string) {
+func setupTaskRepository(t testing.TB) *tk.FileTaskRepository {
 	t.Helper()
 	tempDir := t.TempDir()
 	// setup store
-	store := st.JSONFileStore[tk.Tasks]{
-		DestDir:  tempDir,
-		Filename: "integration_test_tasks.json",
-		InitData: st.EmptyObject,
+	store := st.FileStore[tk.TaskList]{
+		DestDir:    tempDir,
+		Filename:   "integration_test_tasks.json",
+		InitData:   st.EmptyObject,
+		Migrations: tk.Migrations,
 	}
 	filepath := filepath.Join(store.DestDir, store.Filename)
 	t.Cleanup(func() { os.RemoveAll(store.DestDir) })
 
-	_, err := store.InitFile()
+	_, _, err := store.InitFile()
 	th.AssertNoError(t, err)
 
+	return newTaskRepository(t, filepath)
+}
+
+func newTaskRepository(t testing.TB, file string) *tk.FileTaskRepository {
+	t.Helper()
+	dir, filename := filepath.Split(file)
+	store := st.FileStore[tk.TaskList]{
+		DestDir:    dir,
+		Filename:   filename,
+		InitData:   st.EmptyObject,
+		Migrations: tk.Migrations,
+	}
+
 	// setup stub time provider
 	timeProvider := th.StubTimeProvider{FixedTime: th.FixedTime}
 
 	// setup task id generator
 	idGenerator := tk.TaskIDGenerator{}
-	tasks, err := store.LoadData(filepath)
+	list, err := store.LoadData(file)
 	th.AssertNoError(t, err)
-	idGenerator.Init(tasks)
+	lastID := idGenerator.Init(list)
 
 	// setup JSON file task repository
-	taskRepository := tk.JSONFileTaskRepository{
-		Store:        &store,
-		TimeProvider: &timeProvider,
-		IDGenerator:  &idGenerator,
-	}
-
-	return taskRepository, filepath
+	taskRepo := tk.NewFileTaskRepository(
+		&store,
+		file,
+		&timeProvider,
+		&idGenerator,
+	)
+	taskRepo.UIDGenerator = &th.StubUIDGenerator{Last: lastID}
+	return taskRepo
 }
 
 func getTaskDesc(id uint) string {
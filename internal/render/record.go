@@ -0,0 +1,167 @@
+package render
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	tk "github.com/alnah/task-tracker/internal/task"
+)
+
+// taskRecord is a task as machine formats print it: the fields of the tasks
+// file, with times as RFC 3339 and every tag list present.
+type taskRecord struct {
+	ID          uint
+	UID         string
+	Description string
+	Status      tk.Status
+	Priority    tk.Priority
+	Due         string `json:",omitempty"`
+	Tags        []string
+	CreatedAt   string
+	UpdatedAt   string
+	StartedAt   string         `json:",omitempty"`
+	CompletedAt string         `json:",omitempty"`
+	DeletedAt   string         `json:",omitempty"`
+	ArchivedAt  string         `json:",omitempty"`
+	History     []changeRecord `json:",omitempty"`
+}
+
+type changeRecord struct {
+	From tk.Status
+	To   tk.Status
+	At   string
+}
+
+type groupRecord struct {
+	Key           map[string]string
+	Count         int
+	OldestCreated string
+	NewestUpdated string
+	Tasks         []taskRecord
+}
+
+func newTaskRecord(task tk.Task) taskRecord {
+	record := taskRecord{
+		ID:          task.ID,
+		UID:         task.UID,
+		Description: task.Description,
+		Status:      task.Status,
+		Priority:    task.Priority,
+		Due:         formatTimePtr(task.Due),
+		Tags:        append([]string{}, task.Tags...),
+		CreatedAt:   formatTime(task.CreatedAt),
+		UpdatedAt:   formatTime(task.UpdatedAt),
+		StartedAt:   formatTimePtr(task.StartedAt),
+		CompletedAt: formatTimePtr(task.CompletedAt),
+		DeletedAt:   formatTimePtr(task.DeletedAt),
+		ArchivedAt:  formatTimePtr(task.ArchivedAt),
+	}
+	for _, change := range task.History {
+		record.History = append(record.History, changeRecord{
+			From: change.From,
+			To:   change.To,
+			At:   formatTime(change.At),
+		})
+	}
+	return record
+}
+
+func newGroupRecord(group tk.Group) groupRecord {
+	record := groupRecord{
+		Key:           group.Key,
+		Count:         group.Count,
+		OldestCreated: formatTime(group.OldestCreated),
+		NewestUpdated: formatTime(group.NewestUpdated),
+		Tasks:         make([]taskRecord, 0, len(group.Tasks)),
+	}
+	for _, task := range group.Tasks {
+		record.Tasks = append(record.Tasks, newTaskRecord(task))
+	}
+	return record
+}
+
+func formatTime(t time.Time) string {
+	return t.Format(time.RFC3339)
+}
+
+func formatTimePtr(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return formatTime(*t)
+}
+
+func writeJSON(w io.Writer, v any) error {
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON:\n>%w", err)
+	}
+	_, err = fmt.Fprintf(w, "%s\n", content)
+	return err
+}
+
+// writeNDJSON writes each record as JSON on its own line.
+func writeNDJSON[T any](w io.Writer, records []T) error {
+	encoder := json.NewEncoder(w)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("failed to encode JSON:\n>%w", err)
+		}
+	}
+	return nil
+}
+
+// csvHeader holds the columns of a task in CSV. A task's history doesn't fit
+// in a row, so it's left out.
+var csvHeader = []string{
+	"ID", "UID", "Description", "Status", "Priority", "Due", "Tags",
+	"CreatedAt", "UpdatedAt", "StartedAt", "CompletedAt", "DeletedAt",
+	"ArchivedAt",
+}
+
+// writeCSV writes a header and a row per task, with tags separated by
+// commas. Rows start with the values of keys, in columns named after by,
+// such as GroupStatus.
+func writeCSV(
+	w io.Writer,
+	by []string,
+	keys [][]string,
+	tasks []taskRecord,
+) error {
+	header := make([]string, 0, len(by)+len(csvHeader))
+	for _, field := range by {
+		header = append(header, "Group"+strings.ToUpper(field[:1])+field[1:])
+	}
+	header = append(header, csvHeader...)
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV:\n>%w", err)
+	}
+	for i, task := range tasks {
+		var row []string
+		if keys != nil {
+			row = append(row, keys[i]...)
+		}
+		row = append(row,
+			strconv.FormatUint(uint64(task.ID), 10), task.UID,
+			task.Description, string(task.Status), task.Priority.String(),
+			task.Due, strings.Join(task.Tags, ","), task.CreatedAt,
+			task.UpdatedAt, task.StartedAt, task.CompletedAt, task.DeletedAt,
+			task.ArchivedAt,
+		)
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV:\n>%w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV:\n>%w", err)
+	}
+	return nil
+}
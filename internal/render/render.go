@@ -0,0 +1,161 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	tk "github.com/alnah/task-tracker/internal/task"
+)
+
+// Format is how a Renderer prints tasks.
+type Format string
+
+const (
+	Table    Format = "table"
+	JSON     Format = "json"
+	NDJSON   Format = "ndjson"
+	CSV      Format = "csv"
+	Template Format = "template"
+)
+
+// Formats are the formats Options.Format accepts, Template aside, which
+// comes with Options.Template.
+var Formats = []Format{Table, JSON, NDJSON, CSV}
+
+// Options configure a Renderer. Template is a text/template run for each
+// task, or each group, where \t and \n stand for a tab and a newline. Width
+// is the width a table must fit in, or 0 for no limit.
+type Options struct {
+	Format   Format
+	Template string
+	Width    int
+}
+
+type FormatError struct {
+	Format Format
+}
+
+func (e *FormatError) Error() string {
+	names := make([]string, 0, len(Formats))
+	for _, format := range Formats {
+		names = append(names, string(format))
+	}
+	return fmt.Sprintf("invalid output format %q, expected one of %s",
+		e.Format, strings.Join(names, ", "))
+}
+
+type TemplateError struct {
+	Template string
+	Err      error
+}
+
+func (e *TemplateError) Error() string {
+	return fmt.Sprintf("invalid template %q: %v", e.Template, e.Err)
+}
+
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// Renderer prints tasks, and groups of tasks, in one format. Machine formats,
+// JSON, NDJSON and CSV, as well as templates, print times as RFC 3339.
+type Renderer struct {
+	opts     Options
+	template *template.Template
+}
+
+func New(opts Options) (*Renderer, error) {
+	r := &Renderer{opts: opts}
+	switch opts.Format {
+	case Table, JSON, NDJSON, CSV:
+	case Template:
+		text := strings.NewReplacer(`\t`, "\t", `\n`, "\n").
+			Replace(opts.Template)
+		tmpl, err := template.New("format").Option("missingkey=error").
+			Parse(text)
+		if err != nil {
+			return nil, &TemplateError{Template: opts.Template, Err: err}
+		}
+		r.template = tmpl
+	default:
+		return nil, &FormatError{Format: opts.Format}
+	}
+	return r, nil
+}
+
+// Task prints a task, as a one-row table or a single JSON object.
+func (r *Renderer) Task(w io.Writer, task tk.Task) error {
+	if r.opts.Format == JSON {
+		return writeJSON(w, newTaskRecord(task))
+	}
+	return r.Tasks(w, []tk.Task{task})
+}
+
+func (r *Renderer) Tasks(w io.Writer, tasks []tk.Task) error {
+	records := make([]taskRecord, 0, len(tasks))
+	for _, task := range tasks {
+		records = append(records, newTaskRecord(task))
+	}
+
+	switch r.opts.Format {
+	case Table:
+		return writeTable(w, tasks, r.opts.Width)
+	case JSON:
+		return writeJSON(w, records)
+	case NDJSON:
+		return writeNDJSON(w, records)
+	case CSV:
+		return writeCSV(w, nil, nil, records)
+	default:
+		return execute(w, r.template, records)
+	}
+}
+
+// Groups prints groups of tasks by the fields by. Tables print each group
+// under a header, and CSV prints a row per task with the group key first.
+func (r *Renderer) Groups(w io.Writer, by []string, groups []tk.Group) error {
+	records := make([]groupRecord, 0, len(groups))
+	for _, group := range groups {
+		records = append(records, newGroupRecord(group))
+	}
+
+	switch r.opts.Format {
+	case Table:
+		return writeGroupsTable(w, by, groups, r.opts.Width)
+	case JSON:
+		return writeJSON(w, records)
+	case NDJSON:
+		return writeNDJSON(w, records)
+	case CSV:
+		var keys [][]string
+		var tasks []taskRecord
+		for _, group := range records {
+			key := make([]string, 0, len(by))
+			for _, field := range by {
+				key = append(key, group.Key[field])
+			}
+			for _, task := range group.Tasks {
+				keys = append(keys, key)
+				tasks = append(tasks, task)
+			}
+		}
+		return writeCSV(w, by, keys, tasks)
+	default:
+		return execute(w, r.template, records)
+	}
+}
+
+// execute runs the template for each item, each on its own line.
+func execute[T any](w io.Writer, tmpl *template.Template, items []T) error {
+	for _, item := range items {
+		if err := tmpl.Execute(w, item); err != nil {
+			return fmt.Errorf("failed to execute template:\n>%w", err)
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
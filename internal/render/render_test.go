@@ -0,0 +1,266 @@
+package render_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alnah/task-tracker/internal/render"
+	tk "github.com/alnah/task-tracker/internal/task"
+	th "github.com/alnah/task-tracker/test_helpers"
+)
+
+// stamp is th.FixedTime as machine formats print it.
+const stamp = "2006-01-02T15:04:05Z"
+
+func newRenderTestTasks() []tk.Task {
+	due := th.FixedTime.Add(24 * time.Hour)
+	done := th.NewTestTask(1, "fix the login", tk.Done)
+	done.Priority = tk.PriorityHigh
+	done.Due = &due
+	done.Tags = []string{"backend", "ops"}
+	done.CompletedAt = &th.FixedTime
+	done.History = []tk.StatusChange{
+		{From: tk.Todo, To: tk.Done, At: th.FixedTime},
+	}
+	return []tk.Task{done, th.NewTestTask(2, `say "hi", then go`, tk.Todo)}
+}
+
+func newRenderer(t testing.TB, opts render.Options) *render.Renderer {
+	t.Helper()
+	r, err := render.New(opts)
+	th.AssertNoError(t, err)
+	return r
+}
+
+func Test_FormatError_Error(t *testing.T) {
+	t.Run("returns a string containing the format", func(t *testing.T) {
+		err := &render.FormatError{Format: "xml"}
+		th.AssertErrorMessage(t, err, err.Error(), "xml")
+	})
+}
+
+func Test_TemplateError_Error(t *testing.T) {
+	t.Run("returns a string containing the template", func(t *testing.T) {
+		err := &render.TemplateError{Template: "{{.ID"}
+		th.AssertErrorMessage(t, err, err.Error(), "{{.ID")
+	})
+}
+
+func Test_New(t *testing.T) {
+	testCases := []struct {
+		name    string
+		opts    render.Options
+		wantErr error
+	}{
+		{"happy: accepts a format", render.Options{Format: render.CSV}, nil},
+		{"happy: accepts a template", render.Options{
+			Format:   render.Template,
+			Template: "{{.ID}}",
+		}, nil},
+		{"sad: rejects an unknown format", render.Options{Format: "xml"},
+			&render.FormatError{}},
+		{"sad: rejects a template that doesn't parse", render.Options{
+			Format:   render.Template,
+			Template: "{{.ID",
+		}, &render.TemplateError{}},
+		{"edge: rejects no format", render.Options{}, &render.FormatError{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := render.New(tc.opts)
+			if tc.wantErr != nil {
+				th.AssertError(t, err, tc.wantErr)
+				return
+			}
+			th.AssertNoError(t, err)
+		})
+	}
+}
+
+func Test_Renderer_Tasks(t *testing.T) {
+	t.Run("prints pretty JSON with RFC 3339 times successfully",
+		func(t *testing.T) {
+			r := newRenderer(t, render.Options{Format: render.JSON})
+			var buf bytes.Buffer
+			th.AssertNoError(t, r.Tasks(&buf, newRenderTestTasks()))
+
+			th.AssertContains(t, buf.String(), "[\n  {\n    \"ID\": 1,")
+			th.AssertContains(t, buf.String(),
+				`"Due": "2006-01-03T15:04:05Z"`)
+			th.AssertContains(t, buf.String(), `"Priority": "none"`)
+			th.AssertContains(t, buf.String(), `"Tags": []`)
+
+			var tasks []tk.Task
+			th.AssertNoError(t, json.Unmarshal(buf.Bytes(), &tasks))
+			th.AssertDeepEqual(t, tasks[0].Tags, []string{"backend", "ops"})
+			th.AssertDeepEqual(t, tasks[0].History[0].At, th.FixedTime)
+		})
+
+	t.Run("prints a task per line in NDJSON successfully", func(t *testing.T) {
+		r := newRenderer(t, render.Options{Format: render.NDJSON})
+		var buf bytes.Buffer
+		th.AssertNoError(t, r.Tasks(&buf, newRenderTestTasks()))
+
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		th.AssertDeepEqual(t, len(lines), 2)
+		var task tk.Task
+		th.AssertNoError(t, json.Unmarshal([]byte(lines[1]), &task))
+		th.AssertDeepEqual(t, task.Description, `say "hi", then go`)
+	})
+
+	t.Run("prints CSV with a header successfully", func(t *testing.T) {
+		r := newRenderer(t, render.Options{Format: render.CSV})
+		var buf bytes.Buffer
+		th.AssertNoError(t, r.Tasks(&buf, newRenderTestTasks()))
+
+		th.AssertDeepEqual(t, buf.String(), "ID,UID,Description,Status,"+
+			"Priority,Due,Tags,CreatedAt,UpdatedAt,StartedAt,CompletedAt,"+
+			"DeletedAt,ArchivedAt\n"+
+			"1,"+th.NewTestUID(1)+",fix the login,done,high,"+
+			"2006-01-03T15:04:05Z,\"backend,ops\","+stamp+","+stamp+",,"+
+			stamp+",,\n"+
+			"2,"+th.NewTestUID(2)+",\"say \"\"hi\"\", then go\",todo,none,,,"+
+			stamp+","+stamp+",,,,\n")
+	})
+
+	t.Run("prints a template per task successfully", func(t *testing.T) {
+		r := newRenderer(t, render.Options{
+			Format:   render.Template,
+			Template: `{{.ID}}\t{{.Priority}}\t{{.CreatedAt}}`,
+		})
+		var buf bytes.Buffer
+		th.AssertNoError(t, r.Tasks(&buf, newRenderTestTasks()))
+
+		th.AssertDeepEqual(t, buf.String(),
+			"1\thigh\t"+stamp+"\n2\tnone\t"+stamp+"\n")
+	})
+
+	t.Run("prints no tasks successfully", func(t *testing.T) {
+		testCases := []struct {
+			format render.Format
+			want   string
+		}{
+			{render.Table, "No tasks found.\n"},
+			{render.JSON, "[]\n"},
+			{render.NDJSON, ""},
+			{render.CSV, "ID,UID,Description,Status,Priority,Due,Tags," +
+				"CreatedAt,UpdatedAt,StartedAt,CompletedAt,DeletedAt," +
+				"ArchivedAt\n"},
+		}
+
+		for _, tc := range testCases {
+			t.Run(string(tc.format), func(t *testing.T) {
+				r := newRenderer(t, render.Options{Format: tc.format})
+				var buf bytes.Buffer
+				th.AssertNoError(t, r.Tasks(&buf, nil))
+				th.AssertDeepEqual(t, buf.String(), tc.want)
+			})
+		}
+	})
+
+	t.Run("returns an error when a template fails", func(t *testing.T) {
+		r := newRenderer(t, render.Options{
+			Format:   render.Template,
+			Template: "{{.Size}}",
+		})
+		var buf bytes.Buffer
+		th.AssertNotNil(t, r.Tasks(&buf, newRenderTestTasks()))
+	})
+}
+
+func Test_Renderer_Task(t *testing.T) {
+	t.Run("prints a task as a JSON object successfully", func(t *testing.T) {
+		r := newRenderer(t, render.Options{Format: render.JSON})
+		var buf bytes.Buffer
+		th.AssertNoError(t, r.Task(&buf, newRenderTestTasks()[1]))
+
+		var task tk.Task
+		th.AssertNoError(t, json.Unmarshal(buf.Bytes(), &task))
+		th.AssertDeepEqual(t, task.ID, uint(2))
+	})
+
+	t.Run("prints a task as a row successfully", func(t *testing.T) {
+		r := newRenderer(t, render.Options{Format: render.NDJSON})
+		var buf bytes.Buffer
+		th.AssertNoError(t, r.Task(&buf, newRenderTestTasks()[1]))
+		th.AssertDeepEqual(t, strings.Count(buf.String(), "\n"), 1)
+	})
+}
+
+func Test_Renderer_Groups(t *testing.T) {
+	tasks := newRenderTestTasks()
+	groups := []tk.Group{
+		{
+			Key:           map[string]string{"status": "todo", "tag": ""},
+			Count:         1,
+			OldestCreated: th.FixedTime,
+			NewestUpdated: th.FixedTime,
+			Tasks:         tasks[1:],
+		},
+		{
+			Key:           map[string]string{"status": "done", "tag": "ops"},
+			Count:         1,
+			OldestCreated: th.FixedTime,
+			NewestUpdated: th.FixedTime,
+			Tasks:         tasks[:1],
+		},
+	}
+	by := []string{"status", "tag"}
+
+	t.Run("prints groups as a table successfully", func(t *testing.T) {
+		r := newRenderer(t, render.Options{Format: render.Table})
+		var buf bytes.Buffer
+		th.AssertNoError(t, r.Groups(&buf, by, groups))
+
+		th.AssertContains(t, buf.String(), "status: todo, tag: (none) "+
+			"(1 tasks, oldest created "+stamp+", newest updated "+stamp+
+			")\nID")
+		th.AssertContains(t, buf.String(), "then go\n\nstatus: done, tag: ops")
+	})
+
+	t.Run("prints groups as JSON successfully", func(t *testing.T) {
+		r := newRenderer(t, render.Options{Format: render.JSON})
+		var buf bytes.Buffer
+		th.AssertNoError(t, r.Groups(&buf, by, groups))
+
+		var got []tk.Group
+		th.AssertNoError(t, json.Unmarshal(buf.Bytes(), &got))
+		th.AssertDeepEqual(t, got[1].Key, groups[1].Key)
+		th.AssertDeepEqual(t, got[1].OldestCreated, th.FixedTime)
+		th.AssertDeepEqual(t, got[1].Tasks[0].Description, "fix the login")
+	})
+
+	t.Run("prints a group per line in NDJSON successfully",
+		func(t *testing.T) {
+			r := newRenderer(t, render.Options{Format: render.NDJSON})
+			var buf bytes.Buffer
+			th.AssertNoError(t, r.Groups(&buf, by, groups))
+			th.AssertDeepEqual(t, strings.Count(buf.String(), "\n"), 2)
+		})
+
+	t.Run("prints the group key in CSV successfully", func(t *testing.T) {
+		r := newRenderer(t, render.Options{Format: render.CSV})
+		var buf bytes.Buffer
+		th.AssertNoError(t, r.Groups(&buf, by, groups))
+
+		lines := strings.Split(buf.String(), "\n")
+		th.AssertContains(t, lines[0], "GroupStatus,GroupTag,ID,UID,")
+		th.AssertContains(t, lines[1], "todo,,2,")
+		th.AssertContains(t, lines[2], "done,ops,1,")
+	})
+
+	t.Run("prints a template per group successfully", func(t *testing.T) {
+		r := newRenderer(t, render.Options{
+			Format:   render.Template,
+			Template: "{{.Key.status}}: {{.Count}}",
+		})
+		var buf bytes.Buffer
+		th.AssertNoError(t, r.Groups(&buf, by, groups))
+		th.AssertDeepEqual(t, buf.String(), "todo: 1\ndone: 1\n")
+	})
+}
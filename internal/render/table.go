@@ -0,0 +1,130 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tk "github.com/alnah/task-tracker/internal/task"
+)
+
+// minDescriptionWidth is the narrowest a table shrinks descriptions to, so a
+// narrow terminal still shows something of each.
+const minDescriptionWidth = 10
+
+var tableHeader = []string{
+	"ID", "UID", "PRIORITY", "STATUS", "DUE", "TAGS", "DESCRIPTION",
+}
+
+// writeTable writes tasks in aligned columns. Descriptions too long for width
+// are cut, ending with an ellipsis.
+func writeTable(w io.Writer, tasks []tk.Task, width int) error {
+	if len(tasks) == 0 {
+		_, err := fmt.Fprintln(w, "No tasks found.")
+		return err
+	}
+
+	rows := [][]string{tableHeader}
+	for _, task := range tasks {
+		rows = append(rows, []string{
+			strconv.FormatUint(uint64(task.ID), 10), task.UID,
+			task.Priority.String(), string(task.Status), formatDue(task.Due),
+			strings.Join(task.Tags, ","), task.Description,
+		})
+	}
+
+	widths := make([]int, len(tableHeader))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+
+	last := len(widths) - 1
+	if width > 0 {
+		others := 0
+		for _, w := range widths[:last] {
+			others += w + 2
+		}
+		widths[last] = min(widths[last], max(minDescriptionWidth, width-others))
+	}
+
+	var b strings.Builder
+	for _, row := range rows {
+		for i, cell := range row[:last] {
+			b.WriteString(cell)
+			pad := widths[i] - utf8.RuneCountInString(cell) + 2
+			b.WriteString(strings.Repeat(" ", pad))
+		}
+		b.WriteString(truncate(row[last], widths[last]))
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeGroupsTable writes each group under a header with its key and
+// aggregates, the empty tag showing as (none).
+func writeGroupsTable(
+	w io.Writer,
+	by []string,
+	groups []tk.Group,
+	width int,
+) error {
+	if len(groups) == 0 {
+		_, err := fmt.Fprintln(w, "No tasks found.")
+		return err
+	}
+
+	for i, group := range groups {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+
+		key := make([]string, 0, len(by))
+		for _, field := range by {
+			value := group.Key[field]
+			if value == "" {
+				value = "(none)"
+			}
+			key = append(key, field+": "+value)
+		}
+		_, err := fmt.Fprintf(w, "%s (%d tasks, oldest created %s, "+
+			"newest updated %s)\n", strings.Join(key, ", "), group.Count,
+			formatTime(group.OldestCreated), formatTime(group.NewestUpdated))
+		if err != nil {
+			return err
+		}
+
+		if err := writeTable(w, group.Tasks, width); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatDue shows a due date as a day, with its time unless it's the end of
+// the day.
+func formatDue(due *time.Time) string {
+	if due == nil {
+		return ""
+	}
+	if due.Hour() == 23 && due.Minute() == 59 {
+		return due.Format(time.DateOnly)
+	}
+	return due.Format("2006-01-02 15:04")
+}
+
+// truncate cuts s to width runes, ending with an ellipsis when it's cut.
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}
@@ -0,0 +1,65 @@
+package render_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/alnah/task-tracker/internal/render"
+	tk "github.com/alnah/task-tracker/internal/task"
+	th "github.com/alnah/task-tracker/test_helpers"
+)
+
+func Test_Renderer_Tasks_Table(t *testing.T) {
+	uid1, uid2 := th.NewTestUID(1), th.NewTestUID(2)
+	tasks := newRenderTestTasks()
+	at := time.Date(2006, 1, 3, 9, 30, 0, 0, time.UTC)
+	tasks[1].Due = &at
+
+	testCases := []struct {
+		name  string
+		width int
+		want  string
+	}{
+		{"aligns the columns", 0, "" +
+			"ID  UID                         PRIORITY  STATUS  DUE               TAGS         DESCRIPTION\n" +
+			"1   " + uid1 + "  high      done    2006-01-03 15:04  backend,ops  fix the login\n" +
+			"2   " + uid2 + "  none      todo    2006-01-03 09:30               say \"hi\", then go\n"},
+		{"cuts the descriptions to fit", 94, "" +
+			"ID  UID                         PRIORITY  STATUS  DUE               TAGS         DESCRIPTION\n" +
+			"1   " + uid1 + "  high      done    2006-01-03 15:04  backend,ops  fix the login\n" +
+			"2   " + uid2 + "  none      todo    2006-01-03 09:30               say \"hi\", th…\n"},
+		{"keeps some of the descriptions", 40, "" +
+			"ID  UID                         PRIORITY  STATUS  DUE               TAGS         DESCRIPTI…\n" +
+			"1   " + uid1 + "  high      done    2006-01-03 15:04  backend,ops  fix the l…\n" +
+			"2   " + uid2 + "  none      todo    2006-01-03 09:30               say \"hi\",…\n"},
+		{"leaves descriptions that fit", 200, "" +
+			"ID  UID                         PRIORITY  STATUS  DUE               TAGS         DESCRIPTION\n" +
+			"1   " + uid1 + "  high      done    2006-01-03 15:04  backend,ops  fix the login\n" +
+			"2   " + uid2 + "  none      todo    2006-01-03 09:30               say \"hi\", then go\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := newRenderer(t, render.Options{
+				Format: render.Table,
+				Width:  tc.width,
+			})
+			var buf bytes.Buffer
+			th.AssertNoError(t, r.Tasks(&buf, tasks))
+			th.AssertDeepEqual(t, buf.String(), tc.want)
+		})
+	}
+
+	t.Run("shows a day without the end of day time", func(t *testing.T) {
+		endOfDay := time.Date(2006, 1, 3, 23, 59, 59, 0, time.UTC)
+		task := th.NewTestTask(1, "pay the rent", tk.Todo)
+		task.Due = &endOfDay
+
+		r := newRenderer(t, render.Options{Format: render.Table})
+		var buf bytes.Buffer
+		th.AssertNoError(t, r.Task(&buf, task))
+		th.AssertContains(t, buf.String(), "  2006-01-03  ")
+	})
+}
@@ -0,0 +1,11 @@
+//go:build !(linux || darwin)
+
+package render
+
+import "io"
+
+// Other platforms don't report the terminal width; tables then use all the
+// width they need.
+func TerminalWidth(w io.Writer) int {
+	return 0
+}
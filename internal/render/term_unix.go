@@ -0,0 +1,27 @@
+//go:build linux || darwin
+
+package render
+
+import (
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// TerminalWidth returns the width of the terminal w writes to, or 0 when w
+// isn't a terminal.
+func TerminalWidth(w io.Writer) int {
+	file, ok := w.(*os.File)
+	if !ok {
+		return 0
+	}
+
+	var size struct{ rows, cols, x, y uint16 }
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(),
+		uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&size)))
+	if errno != 0 {
+		return 0
+	}
+	return int(size.cols)
+}
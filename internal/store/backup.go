@@ -0,0 +1,266 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultBackupDir = "backups"
+
+	backupTimeFormat = "20060102T150405.000Z"
+)
+
+type Clock interface {
+	Now() time.Time
+}
+
+// BackupPolicy keeps a copy of the file before each save. Keep and MaxAge
+// bound how many backups survive; zero means no bound.
+type BackupPolicy struct {
+	Dir    string
+	Keep   int
+	MaxAge time.Duration
+}
+
+// Backup is a copy of the file, named `<name>-<UTC time><ext>`.
+type Backup struct {
+	Name      string
+	Path      string
+	CreatedAt time.Time
+	Size      int64
+	seq       int
+}
+
+type BackupStore interface {
+	Backups(string) ([]Backup, error)
+	RestoreBackup(string, string) error
+}
+
+type BackupNotFoundError struct {
+	Name string
+}
+
+func (e *BackupNotFoundError) Error() string {
+	return fmt.Sprintf("backup `%s` not found", e.Name)
+}
+
+// Backups lists the backups of the file at path, newest first.
+func (fs *FileStore[T]) Backups(path string) ([]Backup, error) {
+	dir := fs.backupDir(path)
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backups directory:\n>%w", err)
+	}
+
+	var backups []Backup
+	for _, entry := range entries {
+		createdAt, seq, ok := parseBackupName(path, entry.Name())
+		if !ok || !entry.Type().IsRegular() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue // removed while listing
+		}
+
+		backups = append(backups, Backup{
+			Name:      entry.Name(),
+			Path:      filepath.Join(dir, entry.Name()),
+			CreatedAt: createdAt,
+			Size:      info.Size(),
+			seq:       seq,
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].CreatedAt.Equal(backups[j].CreatedAt) {
+			return backups[i].CreatedAt.After(backups[j].CreatedAt)
+		}
+		return backups[i].seq > backups[j].seq
+	})
+
+	return backups, nil
+}
+
+// RestoreBackup replaces the file with the named backup, once the backup is
+// known to hold data this store can load. The replaced content is backed up
+// in turn, so a restore can be undone.
+func (fs *FileStore[T]) RestoreBackup(name string, path string) error {
+	backups, err := fs.Backups(path)
+	if err != nil {
+		return err
+	}
+
+	var backup *Backup
+	for i := range backups {
+		if backups[i].Name == name {
+			backup = &backups[i]
+		}
+	}
+	if backup == nil {
+		return &BackupNotFoundError{Name: name}
+	}
+
+	content, err := os.ReadFile(backup.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read backup:\n>%w", err)
+	}
+
+	if err := fs.validateContent(backup.Path, content); err != nil {
+		return err
+	}
+
+	info, err := fs.statFile(path)
+	if err != nil {
+		return err
+	}
+
+	if err := fs.backupFile(path, content); err != nil {
+		return err
+	}
+
+	return fs.writeFileAtomic(path, content, info.Mode().Perm())
+}
+
+// validateContent checks that content holds data this store can load, and
+// returns a CorruptFileError when it doesn't.
+func (fs *FileStore[T]) validateContent(path string, content []byte) error {
+	codec, err := fs.codecFor(path)
+	if err != nil {
+		return err
+	}
+
+	env, err := decodeEnvelope(content, codec)
+	if err != nil {
+		return &CorruptFileError{Filename: path, Err: errors.Unwrap(err)}
+	}
+
+	migrated, err := migrate(env, fs.Migrations)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fs.unmarshall(migrated.Data); err != nil {
+		return &CorruptFileError{Filename: path, Err: errors.Unwrap(err)}
+	}
+
+	return nil
+}
+
+// backupFile copies the current content of the file into the backups
+// directory, unless it's empty or about to be saved unchanged, then prunes
+// the backups the policy no longer keeps.
+func (fs *FileStore[T]) backupFile(path string, next []byte) error {
+	if fs.Backup == nil {
+		return nil
+	}
+
+	current, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file content:\n>%w", err)
+	}
+	if len(current) == 0 || bytes.Equal(current, next) {
+		return nil
+	}
+
+	dir := fs.backupDir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create backups directory:\n>%w", err)
+	}
+
+	stem, ext := splitExt(path)
+	stamp := fs.now().UTC().Format(backupTimeFormat)
+	_, err = fs.writeNewFile(func(n int) string {
+		if n == 0 {
+			return filepath.Join(dir, fmt.Sprintf("%s-%s%s", stem, stamp, ext))
+		}
+		return filepath.Join(dir, fmt.Sprintf("%s-%s-%d%s", stem, stamp, n, ext))
+	}, current)
+	if err != nil {
+		return fmt.Errorf("failed to back up file:\n>%w", err)
+	}
+
+	return fs.pruneBackups(path)
+}
+
+func (fs *FileStore[T]) pruneBackups(path string) error {
+	backups, err := fs.Backups(path)
+	if err != nil {
+		return err
+	}
+
+	now := fs.now()
+	for i, backup := range backups {
+		tooMany := fs.Backup.Keep > 0 && i >= fs.Backup.Keep
+		tooOld := fs.Backup.MaxAge > 0 &&
+			now.Sub(backup.CreatedAt) > fs.Backup.MaxAge
+		if i == 0 || !(tooMany || tooOld) {
+			continue // the newest backup always survives
+		}
+
+		err := os.Remove(backup.Path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove old backup:\n>%w", err)
+		}
+	}
+
+	return nil
+}
+
+func (fs *FileStore[T]) backupDir(path string) string {
+	if fs.Backup != nil && fs.Backup.Dir != "" {
+		return fs.Backup.Dir
+	}
+	return filepath.Join(filepath.Dir(path), DefaultBackupDir)
+}
+
+func (fs *FileStore[T]) now() time.Time {
+	if fs.Clock != nil {
+		return fs.Clock.Now()
+	}
+	return time.Now()
+}
+
+func parseBackupName(path, name string) (time.Time, int, bool) {
+	stem, ext := splitExt(path)
+	if !strings.HasPrefix(name, stem+"-") || !strings.HasSuffix(name, ext) {
+		return time.Time{}, 0, false
+	}
+
+	rest := strings.TrimSuffix(strings.TrimPrefix(name, stem+"-"), ext)
+	stamp, suffix, _ := strings.Cut(rest, "-")
+
+	createdAt, err := time.Parse(backupTimeFormat, stamp)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+
+	var seq int
+	if suffix != "" {
+		seq, err = strconv.Atoi(suffix)
+		if err != nil || seq < 1 {
+			return time.Time{}, 0, false
+		}
+	}
+
+	return createdAt, seq, true
+}
+
+func splitExt(path string) (string, string) {
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	return strings.TrimSuffix(base, ext), ext
+}
@@ -0,0 +1,230 @@
+package store_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	st "github.com/alnah/task-tracker/internal/store"
+	th "github.com/alnah/task-tracker/test_helpers"
+)
+
+func Test_BackupNotFoundError_Error(t *testing.T) {
+	t.Run("returns a string containing the backup name successfully",
+		func(t *testing.T) {
+			err := &st.BackupNotFoundError{Name: "tasks-20060102T150405.000Z.json"}
+			th.AssertErrorMessage(t, err, err.Error(), err.Name)
+		})
+}
+
+func Test_FileStore_SaveData_Backups(t *testing.T) {
+	t.Run("keeps the previous content before each save successfully",
+		func(t *testing.T) {
+			fs, path, clock := setupBackupStore(t, &st.BackupPolicy{})
+
+			saveAt(t, fs, path, clock, th.FixedTime, map[string]any{"n": 1})
+			first, err := os.ReadFile(path)
+			th.AssertNoError(t, err)
+
+			later := th.FixedTime.Add(time.Minute)
+			saveAt(t, fs, path, clock, later, map[string]any{"n": 2})
+
+			backups, err := fs.Backups(path)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, len(backups), 2)
+			th.AssertDeepEqual(t, backups[0].Name,
+				"tasks-20060102T150505.000Z.json")
+			th.AssertDeepEqual(t, backups[0].CreatedAt, later)
+
+			content, err := os.ReadFile(backups[0].Path)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, string(content), string(first))
+		})
+
+	t.Run("keeps backups taken within the same instant successfully",
+		func(t *testing.T) {
+			fs, path, clock := setupBackupStore(t, &st.BackupPolicy{})
+
+			for n := range 3 {
+				saveAt(t, fs, path, clock, th.FixedTime, map[string]any{"n": n})
+			}
+
+			backups, err := fs.Backups(path)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, len(backups), 3)
+			th.AssertDeepEqual(t, backups[0].Name,
+				"tasks-20060102T150405.000Z-2.json")
+		})
+
+	t.Run("doesn't back up an unchanged file", func(t *testing.T) {
+		fs, path, clock := setupBackupStore(t, &st.BackupPolicy{})
+
+		saveAt(t, fs, path, clock, th.FixedTime, map[string]any{"n": 1})
+		saveAt(t, fs, path, clock, th.FixedTime, map[string]any{"n": 1})
+
+		backups, err := fs.Backups(path)
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, len(backups), 1)
+	})
+
+	t.Run("doesn't back up without a policy", func(t *testing.T) {
+		fs, path, clock := setupBackupStore(t, nil)
+
+		saveAt(t, fs, path, clock, th.FixedTime, map[string]any{"n": 1})
+
+		_, err := os.Stat(filepath.Join(filepath.Dir(path), st.DefaultBackupDir))
+		th.AssertError(t, err, &os.PathError{})
+	})
+
+	t.Run("keeps the newest backups up to the count", func(t *testing.T) {
+		fs, path, clock := setupBackupStore(t, &st.BackupPolicy{Keep: 2})
+
+		for n := range 5 {
+			now := th.FixedTime.Add(time.Duration(n) * time.Minute)
+			saveAt(t, fs, path, clock, now, map[string]any{"n": n})
+		}
+
+		backups, err := fs.Backups(path)
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, len(backups), 2)
+		th.AssertDeepEqual(t, backups[1].CreatedAt,
+			th.FixedTime.Add(3*time.Minute))
+	})
+
+	t.Run("removes backups older than the age", func(t *testing.T) {
+		fs, path, clock := setupBackupStore(t, &st.BackupPolicy{
+			MaxAge: 24 * time.Hour,
+		})
+
+		saveAt(t, fs, path, clock, th.FixedTime, map[string]any{"n": 1})
+		saveAt(t, fs, path, clock, th.FixedTime.Add(time.Hour),
+			map[string]any{"n": 2})
+		saveAt(t, fs, path, clock, th.FixedTime.Add(48*time.Hour),
+			map[string]any{"n": 3})
+
+		backups, err := fs.Backups(path)
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, len(backups), 1)
+		th.AssertDeepEqual(t, backups[0].CreatedAt,
+			th.FixedTime.Add(48*time.Hour))
+	})
+
+	t.Run("uses the configured directory", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "history")
+		fs, path, clock := setupBackupStore(t, &st.BackupPolicy{Dir: dir})
+
+		saveAt(t, fs, path, clock, th.FixedTime, map[string]any{"n": 1})
+
+		entries, err := os.ReadDir(dir)
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, len(entries), 1)
+	})
+}
+
+func Test_FileStore_RestoreBackup(t *testing.T) {
+	t.Run("restores a backup and backs up the replaced file successfully",
+		func(t *testing.T) {
+			fs, path, clock := setupBackupStore(t, &st.BackupPolicy{})
+
+			saveAt(t, fs, path, clock, th.FixedTime, map[string]any{"n": 1})
+			saveAt(t, fs, path, clock, th.FixedTime.Add(time.Minute),
+				map[string]any{"n": 2})
+
+			backups, err := fs.Backups(path)
+			th.AssertNoError(t, err)
+
+			clock.FixedTime = th.FixedTime.Add(time.Hour)
+			err = fs.RestoreBackup(backups[0].Name, path)
+			th.AssertNoError(t, err)
+
+			got, err := fs.LoadData(path)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got, map[string]any{"n": float64(1)})
+
+			backups, err = fs.Backups(path)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, len(backups), 3)
+		})
+
+	t.Run("returns a BackupNotFoundError for an unknown backup",
+		func(t *testing.T) {
+			fs, path, _ := setupBackupStore(t, &st.BackupPolicy{})
+
+			err := fs.RestoreBackup("tasks-20060102T150405.000Z.json", path)
+			th.AssertError(t, err, &st.BackupNotFoundError{})
+
+			err = fs.RestoreBackup("../tasks.json", path)
+			th.AssertError(t, err, &st.BackupNotFoundError{})
+		})
+
+	t.Run("refuses an invalid backup and leaves the file untouched",
+		func(t *testing.T) {
+			fs, path, clock := setupBackupStore(t, &st.BackupPolicy{})
+			saveAt(t, fs, path, clock, th.FixedTime, map[string]any{"n": 1})
+			original, err := os.ReadFile(path)
+			th.AssertNoError(t, err)
+
+			dir := filepath.Join(filepath.Dir(path), st.DefaultBackupDir)
+			name := "tasks-20060102T150405.000Z.json"
+			err = os.MkdirAll(dir, 0755)
+			th.AssertNoError(t, err)
+			err = os.WriteFile(filepath.Join(dir, name), []byte(`{"n": `), 0644)
+			th.AssertNoError(t, err)
+
+			err = fs.RestoreBackup(name, path)
+			th.AssertError(t, err, &st.CorruptFileError{})
+
+			got, err := os.ReadFile(path)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, string(got), string(original))
+		})
+
+	t.Run("refuses a backup written by a newer version", func(t *testing.T) {
+		fs, path, _ := setupBackupStore(t, &st.BackupPolicy{})
+
+		dir := filepath.Join(filepath.Dir(path), st.DefaultBackupDir)
+		name := "tasks-20060102T150405.000Z.json"
+		err := os.MkdirAll(dir, 0755)
+		th.AssertNoError(t, err)
+		err = os.WriteFile(filepath.Join(dir, name),
+			[]byte(`{"schemaVersion": 9, "data": {}}`), 0644)
+		th.AssertNoError(t, err)
+
+		err = fs.RestoreBackup(name, path)
+		th.AssertError(t, err, &st.SchemaVersionError{})
+	})
+}
+
+func setupBackupStore(
+	t *testing.T,
+	policy *st.BackupPolicy,
+) (*st.FileStore[any], string, *th.StubTimeProvider) {
+	clock := &th.StubTimeProvider{FixedTime: th.FixedTime}
+	fs := &st.FileStore[any]{
+		DestDir:  t.TempDir(),
+		Filename: "tasks.json",
+		InitData: st.EmptyObject,
+		Backup:   policy,
+		Clock:    clock,
+	}
+
+	path, _, err := fs.InitFile()
+	th.AssertNoError(t, err)
+
+	return fs, path, clock
+}
+
+func saveAt(
+	t testing.TB,
+	fs *st.FileStore[any],
+	path string,
+	clock *th.StubTimeProvider,
+	now time.Time,
+	data any,
+) {
+	t.Helper()
+	clock.FixedTime = now
+	err := fs.SaveData(data, path)
+	th.AssertNoError(t, err)
+}
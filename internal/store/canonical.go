@@ -0,0 +1,202 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// CanonicalJSONCodec writes JSON meant to live in version control: the same
+// data always gives the same bytes. Struct fields keep their declared order,
+// map keys are sorted, numerically when they're IDs, and an object keyed by
+// IDs gets one record per line, so changing a task changes one line.
+type CanonicalJSONCodec struct{}
+
+func (CanonicalJSONCodec) Marshal(v any) ([]byte, error) {
+	var raw bytes.Buffer
+	encoder := json.NewEncoder(&raw)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := writeCanonical(&buf, raw.Bytes(), "", false); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+
+	return buf.Bytes(), nil
+}
+
+func (CanonicalJSONCodec) Unmarshal(data []byte, v any) error {
+	return decodeJSON(data, v)
+}
+
+func (CanonicalJSONCodec) Extensions() []string {
+	return []string{".json"}
+}
+
+type member struct {
+	key   string
+	value json.RawMessage
+}
+
+// writeCanonical writes a JSON value indented under indent, or on a single
+// line when compact is set.
+func writeCanonical(
+	buf *bytes.Buffer,
+	raw json.RawMessage,
+	indent string,
+	compact bool,
+) error {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return fmt.Errorf("unexpected end of JSON input")
+	}
+
+	switch raw[0] {
+	case '{':
+		members, err := decodeMembers(raw)
+		if err != nil {
+			return err
+		}
+		return writeObject(buf, members, indent, compact)
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return err
+		}
+		return writeArray(buf, items, indent, compact)
+	default:
+		return json.Compact(buf, raw)
+	}
+}
+
+func writeObject(
+	buf *bytes.Buffer,
+	members []member,
+	indent string,
+	compact bool,
+) error {
+	if len(members) == 0 {
+		buf.WriteString("{}")
+		return nil
+	}
+
+	// records keyed by ID stay on one line each, anything else is indented
+	records := allIDs(members)
+	if records {
+		// encoding/json sorts map keys as strings, which puts 10 before 2
+		sort.SliceStable(members, func(i, j int) bool {
+			return lessID(members[i].key, members[j].key)
+		})
+	}
+
+	buf.WriteByte('{')
+	for i, m := range members {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if !compact {
+			buf.WriteString("\n" + indent + "  ")
+		}
+
+		key, err := json.Marshal(m.key)
+		if err != nil {
+			return err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		if !compact {
+			buf.WriteByte(' ')
+		}
+
+		err = writeCanonical(buf, m.value, indent+"  ", compact || records)
+		if err != nil {
+			return err
+		}
+	}
+	if !compact {
+		buf.WriteString("\n" + indent)
+	}
+	buf.WriteByte('}')
+
+	return nil
+}
+
+func writeArray(
+	buf *bytes.Buffer,
+	items []json.RawMessage,
+	indent string,
+	compact bool,
+) error {
+	if len(items) == 0 {
+		buf.WriteString("[]")
+		return nil
+	}
+
+	buf.WriteByte('[')
+	for i, item := range items {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if !compact {
+			buf.WriteString("\n" + indent + "  ")
+		}
+		if err := writeCanonical(buf, item, indent+"  ", compact); err != nil {
+			return err
+		}
+	}
+	if !compact {
+		buf.WriteString("\n" + indent)
+	}
+	buf.WriteByte(']')
+
+	return nil
+}
+
+// decodeMembers reads the members of an object in their written order.
+func decodeMembers(raw json.RawMessage) ([]member, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+
+	var members []member
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+
+		members = append(members, member{key: token.(string), value: value})
+	}
+
+	return members, nil
+}
+
+func lessID(a, b string) bool {
+	idA, _ := strconv.ParseUint(a, 10, 64)
+	idB, _ := strconv.ParseUint(b, 10, 64)
+	if idA != idB {
+		return idA < idB
+	}
+	return a < b // leading zeros
+}
+
+func allIDs(members []member) bool {
+	for _, m := range members {
+		if !isID(m.key) {
+			return false
+		}
+	}
+	return true
+}
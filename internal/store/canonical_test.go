@@ -0,0 +1,153 @@
+package store_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	st "github.com/alnah/task-tracker/internal/store"
+	th "github.com/alnah/task-tracker/test_helpers"
+)
+
+func Test_CanonicalJSONCodec_Marshal_Happy(t *testing.T) {
+	testCases := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{
+			"writes empty containers on one line",
+			map[string]any{"data": map[string]any{}, "list": []any{}},
+			"{\n  \"data\": {},\n  \"list\": []\n}\n",
+		},
+		{
+			"sorts IDs numerically with one record per line",
+			map[uint]record{
+				10: {ID: 10, Name: "tenth"},
+				2:  {ID: 2, Name: "second"},
+			},
+			"{\n" +
+				`  "2": {"id":2,"name":"second","done":false,"note":null,` +
+				`"createdAt":"0001-01-01T00:00:00Z"},` + "\n" +
+				`  "10": {"id":10,"name":"tenth","done":false,"note":null,` +
+				`"createdAt":"0001-01-01T00:00:00Z"}` + "\n" +
+				"}\n",
+		},
+		{
+			"indents objects that aren't keyed by IDs",
+			map[string]any{"b": []any{1, "x"}, "a": true},
+			"{\n  \"a\": true,\n  \"b\": [\n    1,\n    \"x\"\n  ]\n}\n",
+		},
+		{
+			"keeps the order of struct fields",
+			struct {
+				Version int `json:"version"`
+				Data    any `json:"data"`
+			}{1, map[string]any{}},
+			"{\n  \"version\": 1,\n  \"data\": {}\n}\n",
+		},
+		{
+			"doesn't escape HTML characters",
+			"buy <milk> & eggs",
+			"\"buy <milk> & eggs\"\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := st.CanonicalJSONCodec{}.Marshal(tc.value)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, string(got), tc.want)
+		})
+	}
+}
+
+func Test_CanonicalJSONCodec_Marshal_Edge(t *testing.T) {
+	created := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+	data := records{}
+	for id := uint(1); id <= 12; id++ {
+		data[id] = record{ID: id, Name: "task", CreatedAt: created}
+	}
+
+	t.Run("writes identical bytes for identical data", func(t *testing.T) {
+		first, err := st.CanonicalJSONCodec{}.Marshal(data)
+		th.AssertNoError(t, err)
+
+		var decoded records
+		err = st.CanonicalJSONCodec{}.Unmarshal(first, &decoded)
+		th.AssertNoError(t, err)
+
+		again, err := st.CanonicalJSONCodec{}.Marshal(decoded)
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, string(again), string(first))
+	})
+
+	t.Run("round-trips through a file idempotently", func(t *testing.T) {
+		fs := &st.FileStore[records]{
+			DestDir:  t.TempDir(),
+			Filename: "tasks.json",
+			InitData: st.EmptyObject,
+		}
+		path, _, err := fs.InitFile()
+		th.AssertNoError(t, err)
+
+		err = fs.SaveData(data, path)
+		th.AssertNoError(t, err)
+		first, err := os.ReadFile(path)
+		th.AssertNoError(t, err)
+
+		for range 3 {
+			loaded, err := fs.LoadData(path)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, loaded, data)
+
+			err = fs.SaveData(loaded, path)
+			th.AssertNoError(t, err)
+			again, err := os.ReadFile(path)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, string(again), string(first))
+		}
+
+		if !bytes.HasSuffix(first, []byte("}\n")) {
+			t.Errorf("got %q, want a trailing newline", first)
+		}
+	})
+
+	t.Run("changes a single line when a single record changes",
+		func(t *testing.T) {
+			before, err := st.CanonicalJSONCodec{}.Marshal(data)
+			th.AssertNoError(t, err)
+
+			changed := records{}
+			for id, r := range data {
+				changed[id] = r
+			}
+			changed[7] = record{ID: 7, Name: "renamed", CreatedAt: created}
+			after, err := st.CanonicalJSONCodec{}.Marshal(changed)
+			th.AssertNoError(t, err)
+
+			beforeLines := strings.Split(string(before), "\n")
+			afterLines := strings.Split(string(after), "\n")
+			th.AssertDeepEqual(t, len(afterLines), len(beforeLines))
+
+			var diff int
+			for i := range beforeLines {
+				if beforeLines[i] != afterLines[i] {
+					diff++
+				}
+			}
+			th.AssertDeepEqual(t, diff, 1)
+		})
+
+	t.Run("writes valid JSON", func(t *testing.T) {
+		got, err := st.CanonicalJSONCodec{}.Marshal(data)
+		th.AssertNoError(t, err)
+		if !json.Valid(got) {
+			t.Errorf("got invalid JSON %q", got)
+		}
+	})
+}
@@ -0,0 +1,314 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Codec turns values into file content and back. Every codec follows the JSON
+// data model, so field names come from `json` tags whatever the file format,
+// and migrations see the same decoded data for all formats.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+	Extensions() []string
+}
+
+var DefaultCodecs = []Codec{CanonicalJSONCodec{}, YAMLCodec{}, TOMLCodec{}}
+
+func CodecFor(filename string, codecs []Codec) (Codec, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, codec := range codecs {
+		for _, codecExt := range codec.Extensions() {
+			if ext == codecExt {
+				return codec, nil
+			}
+		}
+	}
+
+	return nil, &FilenameExtError{
+		Filename: filename,
+		Accepted: extensions(codecs),
+	}
+}
+
+func extensions(codecs []Codec) []string {
+	var exts []string
+	for _, codec := range codecs {
+		for _, ext := range codec.Extensions() {
+			if !contains(exts, ext) {
+				exts = append(exts, ext)
+			}
+		}
+	}
+	return exts
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return decodeJSON(data, v)
+}
+
+func (JSONCodec) Extensions() []string {
+	return []string{".json"}
+}
+
+type IndentedJSONCodec struct {
+	Indent string
+}
+
+func (c IndentedJSONCodec) Marshal(v any) ([]byte, error) {
+	indent := c.Indent
+	if indent == "" {
+		indent = "  "
+	}
+
+	content, err := json.MarshalIndent(v, "", indent)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(content, '\n'), nil
+}
+
+func (IndentedJSONCodec) Unmarshal(data []byte, v any) error {
+	return decodeJSON(data, v)
+}
+
+func (IndentedJSONCodec) Extensions() []string {
+	return []string{".json"}
+}
+
+type YAMLCodec struct{}
+
+func (YAMLCodec) Marshal(v any) ([]byte, error) {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// building the node from JSON tokens keeps the order of struct fields,
+	// which a decoded map would lose
+	node, err := yamlNode(json.NewDecoder(bytes.NewReader(content)))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (YAMLCodec) Unmarshal(data []byte, v any) error {
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	return viaJSON(raw, v)
+}
+
+func (YAMLCodec) Extensions() []string {
+	return []string{".yaml", ".yml"}
+}
+
+type TOMLCodec struct{}
+
+func (TOMLCodec) Marshal(v any) ([]byte, error) {
+	var raw any
+	if err := viaJSON(v, &raw); err != nil {
+		return nil, err
+	}
+
+	table, ok := tomlValue(raw).(map[string]any)
+	if !ok {
+		return nil, errors.New("toml: expected an object at the top level")
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(table); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (TOMLCodec) Unmarshal(data []byte, v any) error {
+	var raw map[string]any
+	if _, err := toml.Decode(string(data), &raw); err != nil {
+		return err
+	}
+	return viaJSON(raw, v)
+}
+
+func (TOMLCodec) Extensions() []string {
+	return []string{".toml"}
+}
+
+// decodeJSON keeps numbers as json.Number, so IDs and counters decoded into
+// untyped values don't lose precision as float64.
+func decodeJSON(data []byte, v any) error {
+	if !json.Valid(data) {
+		var invalid any
+		return json.Unmarshal(data, &invalid) // reports the syntax error
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// viaJSON converts a value into v through its JSON representation.
+func viaJSON(value any, v any) error {
+	content, err := json.Marshal(jsonValue(value))
+	if err != nil {
+		return err
+	}
+	return decodeJSON(content, v)
+}
+
+// jsonValue turns the mappings YAML decodes with non-string keys, such as
+// hand-written task IDs, into objects JSON can encode.
+func jsonValue(value any) any {
+	switch v := value.(type) {
+	case map[any]any:
+		object := make(map[string]any, len(v))
+		for key, item := range v {
+			object[fmt.Sprint(key)] = jsonValue(item)
+		}
+		return object
+	case map[string]any:
+		for key, item := range v {
+			v[key] = jsonValue(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = jsonValue(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// tomlValue prepares decoded JSON for TOML, which has no null and needs
+// native numbers.
+func tomlValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		table := make(map[string]any, len(v))
+		for key, item := range v {
+			if item != nil {
+				table[key] = tomlValue(item)
+			}
+		}
+		return table
+	case []any:
+		array := make([]any, 0, len(v))
+		for _, item := range v {
+			if item != nil {
+				array = append(array, tomlValue(item))
+			}
+		}
+		return array
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	default:
+		return v
+	}
+}
+
+func yamlNode(decoder *json.Decoder) (*yaml.Node, error) {
+	decoder.UseNumber()
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := yamlNode(decoder)
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(
+					node.Content,
+					yamlScalar("!!str", key.(string)),
+					value,
+				)
+			}
+			_, err := decoder.Token()
+			return node, err
+		case '[':
+			node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			for decoder.More() {
+				value, err := yamlNode(decoder)
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, value)
+			}
+			_, err := decoder.Token()
+			return node, err
+		}
+	case json.Number:
+		if _, err := t.Int64(); err == nil {
+			return yamlScalar("!!int", t.String()), nil
+		}
+		return yamlScalar("!!float", t.String()), nil
+	case string:
+		return yamlScalar("!!str", t), nil
+	case bool:
+		return yamlScalar("!!bool", fmt.Sprint(t)), nil
+	case nil:
+		return yamlScalar("!!null", "null"), nil
+	}
+
+	return nil, fmt.Errorf("unexpected JSON token %v", token)
+}
+
+func yamlScalar(tag, value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value}
+}
@@ -0,0 +1,176 @@
+package store_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	st "github.com/alnah/task-tracker/internal/store"
+	th "github.com/alnah/task-tracker/test_helpers"
+)
+
+type record struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Done      bool      `json:"done"`
+	Note      *string   `json:"note"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type records map[uint]record
+
+func Test_CodecFor(t *testing.T) {
+	testCases := []struct {
+		name     string
+		filename string
+		want     st.Codec
+	}{
+		{"selects canonical JSON for a .json file", "tasks.json",
+			st.CanonicalJSONCodec{}},
+		{"selects YAML for a .yaml file", "tasks.yaml", st.YAMLCodec{}},
+		{"selects YAML for a .yml file", "tasks.yml", st.YAMLCodec{}},
+		{"selects TOML for a .toml file", "tasks.toml", st.TOMLCodec{}},
+		{"ignores the extension case", "TASKS.YAML", st.YAMLCodec{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := st.CodecFor(tc.filename, st.DefaultCodecs)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got, tc.want)
+		})
+	}
+
+	t.Run("returns a FilenameExtError listing accepted extensions",
+		func(t *testing.T) {
+			_, err := st.CodecFor("tasks.txt", st.DefaultCodecs)
+			th.AssertError(t, err, &st.FilenameExtError{})
+			for _, ext := range []string{".json", ".yaml", ".yml", ".toml"} {
+				th.AssertContains(t, err.Error(), ext)
+			}
+		})
+}
+
+func Test_Codec_RoundTrip(t *testing.T) {
+	note := "with a note"
+	created := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+	data := records{
+		1:  {ID: 1, Name: "first", Done: true, Note: &note, CreatedAt: created},
+		2:  {ID: 2, Name: "second: with a colon", CreatedAt: created},
+		10: {ID: 10, Name: "tenth", CreatedAt: created},
+	}
+
+	testCases := []struct {
+		name     string
+		filename string
+		codec    st.Codec
+	}{
+		{"round-trips a canonical JSON file", "tasks.json", nil},
+		{"round-trips a compact JSON file", "tasks.json", st.JSONCodec{}},
+		{"round-trips an indented JSON file", "tasks.json",
+			st.IndentedJSONCodec{}},
+		{"round-trips a YAML file", "tasks.yaml", nil},
+		{"round-trips a YML file", "tasks.yml", nil},
+		{"round-trips a TOML file", "tasks.toml", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			fs := &st.FileStore[records]{
+				DestDir:  t.TempDir(),
+				Filename: tc.filename,
+				InitData: st.EmptyObject,
+				Codec:    tc.codec,
+			}
+
+			path, _, err := fs.InitFile()
+			th.AssertNoError(t, err)
+
+			got, err := fs.LoadData(path)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, len(got), 0)
+
+			err = fs.SaveData(data, path)
+			th.AssertNoError(t, err)
+
+			got, err = fs.LoadData(path)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got, data)
+		})
+	}
+
+	t.Run("uses JSON field names in YAML files", func(t *testing.T) {
+		fs := &st.FileStore[records]{
+			DestDir:  t.TempDir(),
+			Filename: "tasks.yaml",
+			InitData: st.EmptyObject,
+		}
+		path, _, err := fs.InitFile()
+		th.AssertNoError(t, err)
+
+		err = fs.SaveData(data, path)
+		th.AssertNoError(t, err)
+
+		content, err := os.ReadFile(path)
+		th.AssertNoError(t, err)
+		th.AssertContains(t, string(content), "schemaVersion: 1")
+		th.AssertContains(t, string(content), "createdAt:")
+	})
+
+	t.Run("rejects a file whose codec doesn't match its extension",
+		func(t *testing.T) {
+			fs := &st.FileStore[records]{
+				DestDir:  t.TempDir(),
+				Filename: "tasks.yaml",
+				InitData: st.EmptyObject,
+				Codec:    st.JSONCodec{},
+			}
+			_, _, err := fs.InitFile()
+			th.AssertError(t, err, &st.FilenameExtError{})
+		})
+}
+
+func Test_Codec_Migrations(t *testing.T) {
+	t.Run("upgrades a legacy YAML file successfully", func(t *testing.T) {
+		fs, path := setupFileStore(t, "tasks.yaml")
+		fs.Migrations = fakeMigrations
+
+		legacy := "1:\n  ID: 1\n  Name: first\n2:\n  ID: 2\n  Name: second\n"
+		err := os.WriteFile(path, []byte(legacy), 0644)
+		th.AssertNoError(t, err)
+
+		got, err := fs.LoadData(path)
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, got.(map[string]any)["count"], float64(2))
+
+		content, err := os.ReadFile(path)
+		th.AssertNoError(t, err)
+		th.AssertContains(t, string(content), "schemaVersion: 3")
+
+		backup, err := os.ReadFile(path + ".v0.bak")
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, string(backup), legacy)
+	})
+
+	t.Run("upgrades a TOML file successfully", func(t *testing.T) {
+		fs, path := setupFileStore(t, "tasks.toml")
+		fs.Migrations = fakeMigrations
+
+		v1 := "schemaVersion = 1\n\n[data]\n  [data.1]\n    ID = 1\n"
+		err := os.WriteFile(path, []byte(v1), 0644)
+		th.AssertNoError(t, err)
+
+		got, err := fs.LoadData(path)
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, got.(map[string]any)["count"], float64(1))
+
+		content, err := os.ReadFile(path)
+		th.AssertNoError(t, err)
+		if !strings.HasPrefix(string(content), "schemaVersion = 3") {
+			t.Errorf("got %q, want a schema version 3 TOML file", content)
+		}
+	})
+}
@@ -0,0 +1,27 @@
+package store
+
+import "os"
+
+type FileOps = fileOps
+
+func NewFileOps(
+	write func(*os.File, []byte) (int, error),
+	sync func(*os.File) error,
+	rename func(string, string) error,
+) *FileOps {
+	ops := defaultFileOps
+	if write != nil {
+		ops.write = write
+	}
+	if sync != nil {
+		ops.sync = sync
+	}
+	if rename != nil {
+		ops.rename = rename
+	}
+	return &ops
+}
+
+func SetFileOps[T any](fs *FileStore[T], ops *FileOps) {
+	fs.fileOps = ops
+}
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const lockRetryInterval = 10 * time.Millisecond
+
+type LockTimeoutError struct {
+	Path    string
+	Timeout time.Duration
+}
+
+func (e *LockTimeoutError) Error() string {
+	return fmt.Sprintf(
+		"timed out after %s waiting for the lock on `%s`, "+
+			"another process is using the file", e.Timeout, e.Path,
+	)
+}
+
+// FileLock is an advisory lock held on a sidecar file, so that the locked
+// data file itself can still be replaced by an atomic rename.
+type FileLock struct {
+	file *os.File
+}
+
+func LockFile(path string, timeout time.Duration) (*FileLock, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file:\n>%w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err := tryLock(file)
+		if err == nil {
+			return &FileLock{file: file}, nil
+		}
+
+		if !errors.Is(err, errLocked) {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock file:\n>%w", err)
+		}
+
+		if !time.Now().Before(deadline) {
+			file.Close()
+			return nil, &LockTimeoutError{Path: path, Timeout: timeout}
+		}
+
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+func (l *FileLock) Unlock() error {
+	if err := unlock(l.file); err != nil {
+		l.file.Close()
+		return fmt.Errorf("failed to unlock file:\n>%w", err)
+	}
+
+	if err := l.file.Close(); err != nil {
+		return fmt.Errorf("failed to close lock file:\n>%w", err)
+	}
+
+	return nil
+}
+
+var errLocked = errors.New("file is locked")
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package store
+
+import "os"
+
+// Platforms without flock run unlocked; concurrent invocations are then as
+// unsafe as they were before locking existed.
+func tryLock(file *os.File) error {
+	return nil
+}
+
+func unlock(file *os.File) error {
+	return nil
+}
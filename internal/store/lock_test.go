@@ -0,0 +1,69 @@
+package store_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	st "github.com/alnah/task-tracker/internal/store"
+	th "github.com/alnah/task-tracker/test_helpers"
+)
+
+func Test_LockTimeoutError_Error(t *testing.T) {
+	t.Run("returns a string containing the path successfully",
+		func(t *testing.T) {
+			err := &st.LockTimeoutError{Path: "tasks.json.lock", Timeout: time.Second}
+			th.AssertErrorMessage(t, err, err.Error(), err.Path)
+		})
+}
+
+func Test_LockFile_Happy(t *testing.T) {
+	t.Run("locks and unlocks a file successfully", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "tasks.json.lock")
+
+		lock, err := st.LockFile(path, time.Second)
+		th.AssertNoError(t, err)
+		th.AssertNoError(t, lock.Unlock())
+
+		lock, err = st.LockFile(path, 0)
+		th.AssertNoError(t, err)
+		th.AssertNoError(t, lock.Unlock())
+	})
+
+	t.Run("waits for the lock to be released successfully", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "tasks.json.lock")
+
+		lock, err := st.LockFile(path, time.Second)
+		th.AssertNoError(t, err)
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			lock.Unlock()
+		}()
+
+		other, err := st.LockFile(path, 5*time.Second)
+		th.AssertNoError(t, err)
+		th.AssertNoError(t, other.Unlock())
+	})
+}
+
+func Test_LockFile_Sad(t *testing.T) {
+	t.Run("returns a LockTimeoutError while the lock is held", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "tasks.json.lock")
+
+		lock, err := st.LockFile(path, time.Second)
+		th.AssertNoError(t, err)
+		defer lock.Unlock()
+
+		_, err = st.LockFile(path, 30*time.Millisecond)
+		th.AssertError(t, err, &st.LockTimeoutError{})
+	})
+
+	t.Run("returns an os.PathError when the lock file can't be created",
+		func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "missing", "tasks.json.lock")
+			_, err := st.LockFile(path, 0)
+			th.AssertError(t, err, &os.PathError{})
+		})
+}
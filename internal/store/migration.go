@@ -0,0 +1,185 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+const (
+	schemaVersionKey = "schemaVersion"
+	dataKey          = "data"
+
+	// LegacySchemaVersion is reported for files written before the envelope
+	// existed: their whole content is the version 1 payload.
+	LegacySchemaVersion = 0
+)
+
+// Migration upgrades a payload by one schema version. The payload is passed as
+// decoded JSON whatever the file format (map[string]any, []any, string, bool,
+// nil and json.Number), and the migration returns the upgraded payload.
+type Migration struct {
+	Description string
+	Migrate     func(data any) (any, error)
+}
+
+type SchemaVersionError struct {
+	Version   int
+	Supported int
+}
+
+func (e *SchemaVersionError) Error() string {
+	return fmt.Sprintf(
+		"expected a schema version up to %d, but got %d, "+
+			"the file was written by a newer version", e.Supported, e.Version,
+	)
+}
+
+type envelope struct {
+	SchemaVersion int `json:"schemaVersion"`
+	Data          any `json:"data"`
+}
+
+// MigrateData decodes the content of a file at any known schema version,
+// legacy unversioned files included, runs the migrations it needs and returns
+// the content of the up-to-date file.
+func MigrateData(
+	content []byte,
+	codec Codec,
+	migrations []Migration,
+) ([]byte, error) {
+	env, err := decodeEnvelope(content, codec)
+	if err != nil {
+		return nil, err
+	}
+
+	env, err = migrate(env, migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeEnvelope(env, codec)
+}
+
+func (fs *FileStore[T]) SchemaVersion() int {
+	return len(fs.Migrations) + 1
+}
+
+func decodeEnvelope(content []byte, codec Codec) (envelope, error) {
+	var data any
+	if err := codec.Unmarshal(content, &data); err != nil {
+		return envelope{}, fmt.Errorf("failed to unmarshal data:\n>%w", err)
+	}
+
+	fields, ok := data.(map[string]any)
+	if !ok {
+		return envelope{SchemaVersion: LegacySchemaVersion, Data: data}, nil
+	}
+
+	rawVersion, ok := fields[schemaVersionKey]
+	if !ok {
+		return envelope{SchemaVersion: LegacySchemaVersion, Data: data}, nil
+	}
+
+	number, _ := rawVersion.(json.Number)
+	version, err := strconv.Atoi(number.String())
+	if err != nil || version < 1 {
+		return envelope{}, fmt.Errorf(
+			"failed to unmarshal data:\n>%w",
+			fmt.Errorf("invalid schema version %v", rawVersion),
+		)
+	}
+
+	payload, ok := fields[dataKey]
+	if !ok {
+		return envelope{}, fmt.Errorf(
+			"failed to unmarshal data:\n>%w",
+			fmt.Errorf("missing %q next to the schema version", dataKey),
+		)
+	}
+
+	return envelope{SchemaVersion: version, Data: payload}, nil
+}
+
+func encodeEnvelope(env envelope, codec Codec) ([]byte, error) {
+	content, err := codec.Marshal(env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal data:\n>%w", err)
+	}
+
+	return content, nil
+}
+
+func migrate(env envelope, migrations []Migration) (envelope, error) {
+	current := len(migrations) + 1
+
+	version := env.SchemaVersion
+	if version == LegacySchemaVersion {
+		version = 1
+	}
+
+	if version > current {
+		return envelope{}, &SchemaVersionError{
+			Version:   version,
+			Supported: current,
+		}
+	}
+
+	data := env.Data
+	for ; version < current; version++ {
+		var err error
+		data, err = migrations[version-1].Migrate(data)
+		if err != nil {
+			return envelope{}, fmt.Errorf(
+				"failed to migrate data from schema version %d to %d:\n>%w",
+				version, version+1, err,
+			)
+		}
+	}
+
+	return envelope{SchemaVersion: current, Data: data}, nil
+}
+
+// upgradeFile keeps the pre-migration content in a backup next to the file,
+// then replaces the file with its migrated content.
+func (fs *FileStore[T]) upgradeFile(
+	path string,
+	codec Codec,
+	original []byte,
+	fromVersion int,
+	env envelope,
+) error {
+	info, err := fs.statFile(path)
+	if err != nil {
+		return err
+	}
+
+	if err := fs.writeMigrationBackup(path, original, fromVersion); err != nil {
+		return err
+	}
+
+	content, err := encodeEnvelope(env, codec)
+	if err != nil {
+		return err
+	}
+
+	return fs.writeFileAtomic(path, content, info.Mode().Perm())
+}
+
+func (fs *FileStore[T]) writeMigrationBackup(
+	path string,
+	original []byte,
+	fromVersion int,
+) error {
+	_, err := fs.writeNewFile(func(n int) string {
+		if n == 0 {
+			return fmt.Sprintf("%s.v%d.bak", path, fromVersion)
+		}
+		return fmt.Sprintf("%s.v%d.%d.bak", path, fromVersion, n)
+	}, original)
+	if err != nil {
+		return fmt.Errorf("failed to write migration backup:\n>%w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,213 @@
+package store_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	st "github.com/alnah/task-tracker/internal/store"
+	th "github.com/alnah/task-tracker/test_helpers"
+)
+
+func Test_SchemaVersionError_Error(t *testing.T) {
+	t.Run("returns a string containing both versions successfully",
+		func(t *testing.T) {
+			err := &st.SchemaVersionError{Version: 4, Supported: 3}
+			th.AssertErrorMessage(t, err, err.Error(), "4")
+			th.AssertErrorMessage(t, err, err.Error(), "3")
+		})
+}
+
+func Test_MigrateData_Happy(t *testing.T) {
+	testCases := []struct {
+		name    string
+		fixture string
+	}{
+		{"migrates a legacy unversioned file", "legacy.json"},
+		{"migrates a version 1 file", "v1.json"},
+		{"migrates a version 2 file", "v2.json"},
+		{"leaves an up-to-date file as is", "v3.json"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := st.MigrateData(readFixture(t, tc.fixture), st.JSONCodec{}, fakeMigrations)
+			th.AssertNoError(t, err)
+			assertJSONEqual(t, got, readFixture(t, "v3.json"))
+		})
+	}
+
+	t.Run("wraps a legacy file without migrations successfully",
+		func(t *testing.T) {
+			got, err := st.MigrateData(readFixture(t, "legacy.json"), st.JSONCodec{}, nil)
+			th.AssertNoError(t, err)
+			assertJSONEqual(t, got, readFixture(t, "v1.json"))
+		})
+}
+
+func Test_MigrateData_Sad(t *testing.T) {
+	t.Run("returns a SchemaVersionError for a newer file", func(t *testing.T) {
+		_, err := st.MigrateData(readFixture(t, "v4.json"), st.JSONCodec{}, fakeMigrations)
+		th.AssertError(t, err, &st.SchemaVersionError{})
+	})
+
+	t.Run("returns the error of a failing migration", func(t *testing.T) {
+		errBroken := errors.New("broken migration")
+		migrations := []st.Migration{
+			{
+				Description: "always fails",
+				Migrate:     func(any) (any, error) { return nil, errBroken },
+			},
+		}
+
+		_, err := st.MigrateData(readFixture(t, "v1.json"), st.JSONCodec{}, migrations)
+		if !errors.Is(err, errBroken) {
+			t.Errorf("got %v, want %v", err, errBroken)
+		}
+	})
+
+	t.Run("returns a json.SyntaxError for invalid JSON", func(t *testing.T) {
+		_, err := st.MigrateData([]byte(`{"schemaVersion": 1, `), st.JSONCodec{}, fakeMigrations)
+		th.AssertError(t, err, &json.SyntaxError{})
+	})
+}
+
+func Test_FileStore_LoadData_Migrations(t *testing.T) {
+	t.Run("upgrades the file and keeps a backup of the original successfully",
+		func(t *testing.T) {
+			fs, path := setupFileStore(t, "tasks.json")
+			fs.Migrations = fakeMigrations
+
+			original := readFixture(t, "legacy.json")
+			err := os.WriteFile(path, original, 0644)
+			th.AssertNoError(t, err)
+
+			got, err := fs.LoadData(path)
+			th.AssertNoError(t, err)
+
+			var want any
+			err = json.Unmarshal(readEnvelopeData(t, path, 3), &want)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got, want)
+
+			backup, err := os.ReadFile(path + ".v0.bak")
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, string(backup), string(original))
+		})
+
+	t.Run("keeps earlier backups of the same version successfully",
+		func(t *testing.T) {
+			fs, path := setupFileStore(t, "tasks.json")
+			fs.Migrations = fakeMigrations
+
+			err := os.WriteFile(path+".v1.bak", []byte("earlier"), 0644)
+			th.AssertNoError(t, err)
+			err = os.WriteFile(path, readFixture(t, "v1.json"), 0644)
+			th.AssertNoError(t, err)
+
+			_, err = fs.LoadData(path)
+			th.AssertNoError(t, err)
+
+			earlier, err := os.ReadFile(path + ".v1.bak")
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, string(earlier), "earlier")
+
+			later, err := os.ReadFile(path + ".v1.1.bak")
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, string(later), string(readFixture(t, "v1.json")))
+		})
+
+	t.Run("doesn't rewrite an up-to-date file successfully", func(t *testing.T) {
+		fs, path := setupFileStore(t, "tasks.json")
+		fs.Migrations = fakeMigrations
+
+		original := readFixture(t, "v3.json")
+		err := os.WriteFile(path, original, 0644)
+		th.AssertNoError(t, err)
+
+		_, err = fs.LoadData(path)
+		th.AssertNoError(t, err)
+
+		got, err := os.ReadFile(path)
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, string(got), string(original))
+
+		matches, err := filepath.Glob(path + ".v*.bak")
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, len(matches), 0)
+	})
+
+	t.Run("returns a SchemaVersionError and leaves a newer file untouched",
+		func(t *testing.T) {
+			fs, path := setupFileStore(t, "tasks.json")
+			fs.Migrations = fakeMigrations
+
+			original := readFixture(t, "v4.json")
+			err := os.WriteFile(path, original, 0644)
+			th.AssertNoError(t, err)
+
+			_, err = fs.LoadData(path)
+			th.AssertError(t, err, &st.SchemaVersionError{})
+
+			_, _, err = fs.InitFile()
+			th.AssertError(t, err, &st.SchemaVersionError{})
+
+			got, err := os.ReadFile(path)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, string(got), string(original))
+		})
+
+	t.Run("saves data with the current schema version successfully",
+		func(t *testing.T) {
+			fs, path := setupFileStore(t, "tasks.json")
+			fs.Migrations = fakeMigrations
+
+			_, _, err := fs.InitFile()
+			th.AssertNoError(t, err)
+			readEnvelopeData(t, path, 3)
+
+			err = fs.SaveData(map[string]any{"count": 0}, path)
+			th.AssertNoError(t, err)
+			readEnvelopeData(t, path, 3)
+		})
+}
+
+// fakeMigrations moves items under an "items" key, then counts them.
+var fakeMigrations = []st.Migration{
+	{
+		Description: "nest items",
+		Migrate: func(data any) (any, error) {
+			return map[string]any{"items": data}, nil
+		},
+	},
+	{
+		Description: "count items",
+		Migrate: func(data any) (any, error) {
+			object, ok := data.(map[string]any)
+			if !ok {
+				return nil, errors.New("expected an object")
+			}
+			items, _ := object["items"].(map[string]any)
+			object["count"] = len(items)
+			return object, nil
+		},
+	},
+}
+
+func readFixture(t testing.TB, name string) []byte {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join("testdata", "migrations", name))
+	th.AssertNoError(t, err)
+	return content
+}
+
+func assertJSONEqual(t testing.TB, got, want []byte) {
+	t.Helper()
+	var gotValue, wantValue any
+	th.AssertNoError(t, json.Unmarshal(got, &gotValue))
+	th.AssertNoError(t, json.Unmarshal(want, &wantValue))
+	th.AssertDeepEqual(t, gotValue, wantValue)
+}
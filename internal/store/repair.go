@@ -0,0 +1,281 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+type CorruptFileError struct {
+	Filename string
+	Err      error
+}
+
+func (e *CorruptFileError) Error() string {
+	return fmt.Sprintf("corrupt file `%s`: %s", e.Filename, e.Err)
+}
+
+func (e *CorruptFileError) Unwrap() error {
+	return e.Err
+}
+
+// RepairReport tells how a corrupt file was repaired: from a backup, or by
+// salvaging what could still be read from it, in which case Lost lists the
+// paths of the values that couldn't, such as `data.7`, and TruncatedPath if
+// the file ends early. When the newest valid backup still holds records the
+// salvage lost, Offer names it and Recoverable lists their paths. The corrupt
+// content is kept in Quarantine.
+type RepairReport struct {
+	Repaired    bool
+	Backup      string
+	Lost        []string
+	Offer       string
+	Recoverable []string
+	Quarantine  string
+}
+
+type RepairStore interface {
+	Repair(string) (RepairReport, error)
+}
+
+// Repair leaves a loadable file alone. Otherwise it salvages every record
+// still readable from the file, which is newer than any backup, and falls
+// back to the newest valid backup when no record can be salvaged.
+func (fs *FileStore[T]) Repair(path string) (RepairReport, error) {
+	info, err := fs.statFile(path)
+	if err != nil {
+		return RepairReport{}, err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return RepairReport{}, fmt.Errorf("failed to read file content:\n>%w", err)
+	}
+
+	err = fs.validateContent(path, content)
+	var corruptErr *CorruptFileError
+	if !errors.As(err, &corruptErr) {
+		return RepairReport{}, err // healthy, or not something to repair
+	}
+
+	report := RepairReport{Repaired: true}
+
+	backup, backupContent, err := fs.newestValidBackup(path)
+	if err != nil {
+		return RepairReport{}, err
+	}
+
+	useBackup := true
+	repaired, lost, err := fs.salvage(path, content)
+	if err == nil {
+		report.Lost = lost
+		useBackup, err = fs.compareBackup(path, &report, repaired, backup,
+			backupContent)
+		if err != nil {
+			return RepairReport{}, err
+		}
+	}
+	if useBackup {
+		if backup == "" {
+			return RepairReport{}, &FileContentError{
+				Filename: path,
+				Message:  "nothing can be salvaged and no valid backup exists",
+			}
+		}
+		report = RepairReport{Repaired: true, Backup: backup}
+		repaired = backupContent
+	}
+
+	report.Quarantine, err = fs.writeNewFile(func(n int) string {
+		if n == 0 {
+			return path + ".corrupt"
+		}
+		return fmt.Sprintf("%s.%d.corrupt", path, n)
+	}, content)
+	if err != nil {
+		return RepairReport{}, fmt.Errorf("failed to keep corrupt file:\n>%w", err)
+	}
+
+	if err := fs.writeFileAtomic(path, repaired, info.Mode().Perm()); err != nil {
+		return RepairReport{}, err
+	}
+
+	return report, nil
+}
+
+// salvage returns the content of the file rebuilt from its readable parts, at
+// the current schema version.
+func (fs *FileStore[T]) salvage(
+	path string,
+	content []byte,
+) ([]byte, []string, error) {
+	codec, err := fs.codecFor(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !contains(codec.Extensions(), ".json") {
+		return nil, nil, errSalvage
+	}
+
+	tree, lost, err := salvageJSON(content)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rebuilt, err := JSONCodec{}.Marshal(tree)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	env, err := decodeEnvelope(rebuilt, JSONCodec{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	env, err = migrate(env, fs.Migrations)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := fs.unmarshall(env.Data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	repaired, err := encodeEnvelope(envelope{
+		SchemaVersion: env.SchemaVersion,
+		Data:          data,
+	}, codec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return repaired, lost, nil
+}
+
+// compareBackup offers the backup in the report when it holds records the
+// salvaged content lacks, and tells to use the backup instead when the
+// salvage kept no record at all.
+func (fs *FileStore[T]) compareBackup(
+	path string,
+	report *RepairReport,
+	salvaged []byte,
+	backup string,
+	backupContent []byte,
+) (bool, error) {
+	if backup == "" {
+		return false, nil
+	}
+
+	held, err := fs.normalize(path, backupContent)
+	if err != nil {
+		return false, err
+	}
+	kept, err := fs.normalize(path, salvaged)
+	if err != nil {
+		return false, err
+	}
+
+	keptPaths := recordPaths(kept)
+	for _, record := range recordPaths(held) {
+		if !contains(keptPaths, record) {
+			report.Recoverable = append(report.Recoverable, record)
+		}
+	}
+	if len(report.Recoverable) == 0 {
+		return false, nil
+	}
+	report.Offer = backup
+	return len(keptPaths) == 0, nil
+}
+
+// normalize returns valid content as JSON at the current schema version, so
+// content of any version and format compares.
+func (fs *FileStore[T]) normalize(path string, content []byte) ([]byte, error) {
+	codec, err := fs.codecFor(path)
+	if err != nil {
+		return nil, err
+	}
+
+	env, err := decodeEnvelope(content, codec)
+	if err != nil {
+		return nil, err
+	}
+
+	env, err = migrate(env, fs.Migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := fs.unmarshall(env.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeEnvelope(envelope{
+		SchemaVersion: env.SchemaVersion,
+		Data:          data,
+	}, JSONCodec{})
+}
+
+// recordPaths returns the sorted paths of the records of JSON content, the
+// members keyed by an ID, such as data.Tasks.7.
+func recordPaths(content []byte) []string {
+	var tree any
+	if err := json.Unmarshal(content, &tree); err != nil {
+		return nil
+	}
+
+	var paths []string
+	var walk func(path string, value any)
+	walk = func(path string, value any) {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return
+		}
+		for key, member := range object {
+			if isID(key) {
+				paths = append(paths, joinKey(path, key))
+			} else {
+				walk(joinKey(path, key), member)
+			}
+		}
+	}
+	walk("", tree)
+
+	sort.Slice(paths, func(i, j int) bool { return lessPath(paths[i], paths[j]) })
+	return paths
+}
+
+// lessPath orders paths as their parts, IDs numerically.
+func lessPath(a, b string) bool {
+	partsA, partsB := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(partsA) && i < len(partsB); i++ {
+		if partsA[i] != partsB[i] {
+			return lessID(partsA[i], partsB[i])
+		}
+	}
+	return len(partsA) < len(partsB)
+}
+
+func (fs *FileStore[T]) newestValidBackup(path string) (string, []byte, error) {
+	backups, err := fs.Backups(path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	for _, backup := range backups {
+		content, err := os.ReadFile(backup.Path)
+		if err != nil {
+			continue
+		}
+		if fs.validateContent(backup.Path, content) == nil {
+			return backup.Name, content, nil
+		}
+	}
+
+	return "", nil, nil
+}
@@ -0,0 +1,245 @@
+package store_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	st "github.com/alnah/task-tracker/internal/store"
+	th "github.com/alnah/task-tracker/test_helpers"
+)
+
+func Test_CorruptFileError_Error(t *testing.T) {
+	t.Run("returns a string containing the filename successfully",
+		func(t *testing.T) {
+			err := &st.CorruptFileError{Filename: "tasks.json", Err: os.ErrClosed}
+			th.AssertErrorMessage(t, err, err.Error(), err.Filename)
+			th.AssertErrorMessage(t, err, err.Error(), os.ErrClosed.Error())
+		})
+}
+
+func Test_FileStore_Repair_Salvage(t *testing.T) {
+	canonical := "{\n" +
+		"  \"schemaVersion\": 1,\n" +
+		"  \"data\": {\n" +
+		"    \"1\": {\"id\":1,\"name\":\"first\"},\n" +
+		"    \"2\": {\"id\":2,\"name\":\"second\"},\n" +
+		"    \"3\": {\"id\":3,\"name\":\"third\"}\n" +
+		"  }\n" +
+		"}\n"
+	compact := `{"schemaVersion":1,"data":{"1":{"id":1,"name":"first"},` +
+		`"2":{"id":2,"name":"second"},"3":{"id":3,"name":"third"}}}`
+
+	testCases := []struct {
+		name     string
+		content  string
+		wantIDs  []uint
+		wantLost []string
+	}{
+		{
+			"keeps the complete records of a truncated file",
+			canonical[:strings.Index(canonical, `"third"`)],
+			[]uint{1, 2},
+			[]string{"data.3", st.TruncatedPath},
+		},
+		{
+			"reports a file truncated between records",
+			canonical[:strings.Index(canonical, `    "3"`)],
+			[]uint{1, 2},
+			[]string{st.TruncatedPath},
+		},
+		{
+			"keeps the complete records of a truncated compact file",
+			compact[:strings.Index(compact, `"second"`)+3],
+			[]uint{1},
+			[]string{"data.2", st.TruncatedPath},
+		},
+		{
+			"reports a file truncated after its last record",
+			canonical[:strings.Index(canonical, "\n  }\n")],
+			[]uint{1, 2, 3},
+			[]string{st.TruncatedPath},
+		},
+		{
+			"drops a damaged record and keeps the following ones",
+			strings.Replace(canonical, `{"id":2,"name":"second"}`,
+				`{"id":2,"na\x00@@`, 1),
+			[]uint{1, 3},
+			[]string{"data.2"},
+		},
+		{
+			"drops a damaged record of a compact file",
+			strings.Replace(compact, `"name":"second"`, `"name":"sec`, 1),
+			[]uint{1, 3},
+			[]string{"data.2"},
+		},
+		{
+			"reports trailing garbage",
+			canonical + "garbage",
+			[]uint{1, 2, 3},
+			[]string{"(trailing content)"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			fs, path := setupRepairStore(t, "tasks.json", tc.content)
+
+			_, err := fs.LoadData(path)
+			th.AssertError(t, err, &st.CorruptFileError{})
+
+			report, err := fs.Repair(path)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, report.Repaired, true)
+			th.AssertDeepEqual(t, report.Backup, "")
+			th.AssertDeepEqual(t, report.Lost, tc.wantLost)
+
+			got, err := fs.LoadData(path)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, sortedKeys(got), tc.wantIDs)
+			for _, id := range tc.wantIDs {
+				th.AssertDeepEqual(t, got[id].ID, id)
+			}
+
+			quarantined, err := os.ReadFile(report.Quarantine)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, string(quarantined), tc.content)
+		})
+	}
+}
+
+func Test_FileStore_Repair_Backup(t *testing.T) {
+	t.Run("falls back to the newest valid backup successfully",
+		func(t *testing.T) {
+			fs, path := setupRepairStore(t, "tasks.json",
+				`{"schemaVersion":1,"da`)
+			writeBackup(t, path, "tasks-20060102T150405.000Z.json",
+				`{"schemaVersion":1,"data":{"1":{"id":1,"name":"first"}}}`)
+			writeBackup(t, path, "tasks-20060102T150505.000Z.json", `{"schem`)
+
+			report, err := fs.Repair(path)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, report.Backup, "tasks-20060102T150405.000Z.json")
+
+			got, err := fs.LoadData(path)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got[1].Name, "first")
+		})
+
+	t.Run("offers a backup holding records the salvage lost successfully",
+		func(t *testing.T) {
+			content := "{\n" +
+				"  \"schemaVersion\": 1,\n" +
+				"  \"data\": {\n" +
+				"    \"1\": {\"id\":1,\"name\":\"first, edited\"},\n"
+			fs, path := setupRepairStore(t, "tasks.json", content)
+			writeBackup(t, path, "tasks-20060102T150405.000Z.json",
+				`{"schemaVersion":1,"data":{"1":{"id":1,"name":"first"},`+
+					`"2":{"id":2,"name":"second"},"3":{"id":3,"name":"third"}}}`)
+
+			report, err := fs.Repair(path)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, report.Backup, "")
+			th.AssertDeepEqual(t, report.Lost, []string{st.TruncatedPath})
+			th.AssertDeepEqual(t, report.Offer,
+				"tasks-20060102T150405.000Z.json")
+			th.AssertDeepEqual(t, report.Recoverable,
+				[]string{"data.2", "data.3"})
+
+			got, err := fs.LoadData(path)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got[1].Name, "first, edited")
+		})
+
+	t.Run("falls back to a backup when no record is salvaged",
+		func(t *testing.T) {
+			fs, path := setupRepairStore(t, "tasks.json",
+				`{"schemaVersion":1,"data":{"1":{"id":1,"na`)
+			writeBackup(t, path, "tasks-20060102T150405.000Z.json",
+				`{"schemaVersion":1,"data":{"1":{"id":1,"name":"first"}}}`)
+
+			report, err := fs.Repair(path)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, report.Backup,
+				"tasks-20060102T150405.000Z.json")
+			th.AssertDeepEqual(t, len(report.Lost), 0)
+
+			got, err := fs.LoadData(path)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got[1].Name, "first")
+		})
+
+	t.Run("falls back to a backup for a corrupt YAML file", func(t *testing.T) {
+		fs, path := setupRepairStore(t, "tasks.yaml", "data: {1: [\n")
+		writeBackup(t, path, "tasks-20060102T150405.000Z.yaml",
+			"schemaVersion: 1\ndata:\n  \"1\":\n    id: 1\n    name: first\n")
+
+		report, err := fs.Repair(path)
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, report.Backup, "tasks-20060102T150405.000Z.yaml")
+	})
+
+	t.Run("returns a FileContentError with nothing to recover from",
+		func(t *testing.T) {
+			fs, path := setupRepairStore(t, "tasks.json", "\x00\x00")
+
+			_, err := fs.Repair(path)
+			th.AssertError(t, err, &st.FileContentError{})
+
+			got, err := os.ReadFile(path)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, string(got), "\x00\x00")
+		})
+
+	t.Run("leaves a healthy file untouched", func(t *testing.T) {
+		healthy := `{"schemaVersion":1,"data":{"1":{"id":1,"name":"first"}}}`
+		fs, path := setupRepairStore(t, "tasks.json", healthy)
+
+		report, err := fs.Repair(path)
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, report.Repaired, false)
+
+		got, err := os.ReadFile(path)
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, string(got), healthy)
+	})
+}
+
+func setupRepairStore(
+	t *testing.T,
+	filename string,
+	content string,
+) (*st.FileStore[records], string) {
+	fs := &st.FileStore[records]{
+		DestDir:  t.TempDir(),
+		Filename: filename,
+		InitData: st.EmptyObject,
+	}
+	path := filepath.Join(fs.DestDir, fs.Filename)
+
+	err := os.WriteFile(path, []byte(content), 0644)
+	th.AssertNoError(t, err)
+
+	return fs, path
+}
+
+func writeBackup(t testing.TB, path, name, content string) {
+	t.Helper()
+	dir := filepath.Join(filepath.Dir(path), st.DefaultBackupDir)
+	err := os.MkdirAll(dir, 0755)
+	th.AssertNoError(t, err)
+	err = os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	th.AssertNoError(t, err)
+}
+
+func sortedKeys(data records) []uint {
+	var ids []uint
+	for id := uint(1); len(ids) < len(data); id++ {
+		if _, ok := data[id]; ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,274 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+var errSalvage = errors.New("unreadable content")
+
+// TruncatedPath is the lost path a salvage reports when the content ends
+// before the document does: whatever followed is lost, unseen.
+const TruncatedPath = "(truncated)"
+
+// salvager reads as much of a damaged JSON document as it can. Members keyed
+// by an ID are records, kept whole or dropped whole: a record that doesn't
+// parse is reported as lost, and reading resumes at the next record. Anything
+// else is read leniently, so a truncated document keeps every complete
+// record it holds.
+type salvager struct {
+	data      []byte
+	pos       int
+	lost      []string
+	truncated bool
+}
+
+func salvageJSON(content []byte) (any, []string, error) {
+	s := &salvager{data: content}
+
+	s.skipSpace()
+	if s.eof() || (s.peek() != '{' && s.peek() != '[') {
+		return nil, nil, errSalvage
+	}
+
+	value, err := s.lenientValue("")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.skipSpace()
+	if !s.eof() {
+		s.lost = append(s.lost, "(trailing content)")
+	}
+	if s.truncated {
+		s.lost = append(s.lost, TruncatedPath)
+	}
+
+	return value, s.lost, nil
+}
+
+func (s *salvager) lenientValue(path string) (any, error) {
+	s.skipSpace()
+	if s.eof() {
+		return nil, errSalvage
+	}
+
+	switch s.peek() {
+	case '{':
+		return s.lenientObject(path), nil
+	case '[':
+		return s.lenientArray(path), nil
+	default:
+		return s.strictValue()
+	}
+}
+
+func (s *salvager) lenientObject(path string) map[string]any {
+	s.pos++ // {
+	object := map[string]any{}
+
+	for {
+		s.skipSpace()
+		if s.eof() {
+			s.truncated = true
+			return object // close it
+		}
+
+		switch s.peek() {
+		case '}':
+			s.pos++
+			return object
+		case ',':
+			s.pos++
+			continue
+		}
+
+		start := s.pos
+		key, err := s.stringValue()
+		if err != nil {
+			s.lose(joinKey(path, "(unreadable member)"))
+			s.resync(start, false)
+			continue
+		}
+
+		memberPath := joinKey(path, key)
+		isRecord := isID(key)
+
+		value, err := s.member(memberPath, isRecord)
+		if err != nil {
+			s.lose(memberPath)
+			s.resync(start, isRecord)
+			continue
+		}
+
+		object[key] = value
+	}
+}
+
+func (s *salvager) member(path string, isRecord bool) (any, error) {
+	s.skipSpace()
+	if s.eof() || s.peek() != ':' {
+		return nil, errSalvage
+	}
+	s.pos++
+
+	if !isRecord {
+		return s.lenientValue(path)
+	}
+
+	value, err := s.strictValue()
+	if err != nil {
+		return nil, err
+	}
+
+	// objects and arrays end with their own delimiter, but a truncated
+	// number still parses, so a scalar is whole only once a delimiter follows
+	s.skipSpace()
+	switch value.(type) {
+	case map[string]any, []any:
+		if s.eof() {
+			return value, nil
+		}
+	}
+	if s.eof() || (s.peek() != ',' && s.peek() != '}') {
+		return nil, errSalvage
+	}
+
+	return value, nil
+}
+
+func (s *salvager) lenientArray(path string) []any {
+	s.pos++ // [
+	array := []any{}
+
+	for {
+		s.skipSpace()
+		if s.eof() {
+			s.truncated = true
+			return array
+		}
+
+		switch s.peek() {
+		case ']':
+			s.pos++
+			return array
+		case ',':
+			s.pos++
+			continue
+		}
+
+		start := s.pos
+		itemPath := joinKey(path, strconv.Itoa(len(array)))
+		value, err := s.lenientValue(itemPath)
+		if err != nil {
+			s.lose(itemPath)
+			s.resync(start, false)
+			continue
+		}
+
+		array = append(array, value)
+	}
+}
+
+// strictValue reads a complete JSON value, or fails.
+func (s *salvager) strictValue() (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(s.data[s.pos:]))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, errSalvage
+	}
+
+	s.pos += int(decoder.InputOffset())
+	return value, nil
+}
+
+func (s *salvager) stringValue() (string, error) {
+	if s.eof() || s.peek() != '"' {
+		return "", errSalvage
+	}
+
+	value, err := s.strictValue()
+	if err != nil {
+		return "", err
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return "", errSalvage
+	}
+	return str, nil
+}
+
+// resync moves past damaged content: to the next record when a record was
+// damaged, since records may share a line in compact files, and to the next
+// line otherwise.
+func (s *salvager) resync(start int, toRecord bool) {
+	if toRecord {
+		for i := start + 1; i < len(s.data); i++ {
+			if s.data[i] == '"' && s.recordStartsAt(i) {
+				s.pos = i
+				return
+			}
+		}
+		s.pos = len(s.data)
+		return
+	}
+
+	newline := bytes.IndexByte(s.data[start:], '\n')
+	if newline < 0 {
+		s.pos = len(s.data)
+		return
+	}
+	s.pos = start + newline + 1
+}
+
+func (s *salvager) recordStartsAt(i int) bool {
+	probe := &salvager{data: s.data, pos: i}
+	key, err := probe.stringValue()
+	if err != nil || !isID(key) {
+		return false
+	}
+
+	probe.skipSpace()
+	return !probe.eof() && probe.peek() == ':'
+}
+
+func (s *salvager) lose(path string) {
+	s.lost = append(s.lost, path)
+}
+
+func (s *salvager) skipSpace() {
+	for !s.eof() {
+		switch s.peek() {
+		case ' ', '\t', '\n', '\r':
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (s *salvager) peek() byte {
+	return s.data[s.pos]
+}
+
+func (s *salvager) eof() bool {
+	return s.pos >= len(s.data)
+}
+
+func isID(key string) bool {
+	_, err := strconv.ParseUint(key, 10, 64)
+	return err == nil
+}
+
+func joinKey(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return fmt.Sprintf("%s.%s", path, key)
+}
@@ -2,14 +2,18 @@ package store
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
 )
 
 type Store[T any] interface {
-	InitFile() (*os.File, error)
+	InitFile() (string, bool, error)
 	LoadData(string) (T, error)
 	SaveData(T, string) error
 }
@@ -21,10 +25,36 @@ const (
 	EmptyObject JSONInitData = "{}"
 )
 
-type JSONFileStore[T any] struct {
-	DestDir  string
-	Filename string
-	InitData JSONInitData
+// FileStore keeps data in a file whose format follows its extension, unless
+// Codec forces one. With a Backup policy, each save first copies the file
+// into a backups directory.
+type FileStore[T any] struct {
+	DestDir    string
+	Filename   string
+	InitData   JSONInitData
+	Codec      Codec
+	Migrations []Migration
+	Backup     *BackupPolicy
+	Clock      Clock
+	fileOps    *fileOps
+}
+
+// fileOps holds the system calls used by atomic writes, so tests can inject
+// faults between the steps of a save.
+type fileOps struct {
+	write   func(*os.File, []byte) (int, error)
+	sync    func(*os.File) error
+	rename  func(string, string) error
+	link    func(string, string) error
+	syncDir func(string) error
+}
+
+var defaultFileOps = fileOps{
+	write:   (*os.File).Write,
+	sync:    (*os.File).Sync,
+	rename:  os.Rename,
+	link:    os.Link,
+	syncDir: syncDir,
 }
 
 type InitDataError struct {
@@ -40,35 +70,64 @@ func (e *InitDataError) Error() string {
 
 type FilenameExtError struct {
 	Filename string
+	Accepted []string
 }
 
 func (e *FilenameExtError) Error() string {
-	return fmt.Sprintf("expected a `.json` file, but got `%s`", e.Filename)
+	if len(e.Accepted) == 0 {
+		return fmt.Sprintf(
+			"expected a supported file extension, but got `%s`", e.Filename,
+		)
+	}
+
+	return fmt.Sprintf(
+		"expected a file ending in %s, but got `%s`",
+		strings.Join(e.Accepted, ", "), e.Filename,
+	)
+}
+
+type FileContentError struct {
+	Filename string
+	Message  string
+}
+
+func (e *FileContentError) Error() string {
+	return fmt.Sprintf("unusable file `%s`: %s", e.Filename, e.Message)
 }
 
-func (fs *JSONFileStore[T]) InitFile() (*os.File, error) {
+// InitFile creates the file with the initial data, or checks that an existing
+// one is usable and leaves it untouched. It returns the file path, and whether
+// the file was created.
+func (fs *FileStore[T]) InitFile() (string, bool, error) {
 	if err := fs.validateDataStructure(); err != nil {
-		return nil, err
+		return "", false, err
 	}
 
-	if err := fs.validateFilenameExt(); err != nil {
-		return nil, err
+	codec, err := fs.codecFor(fs.Filename)
+	if err != nil {
+		return "", false, err
 	}
 
 	if err := fs.createDestDir(); err != nil {
-		return nil, err
+		return "", false, err
 	}
 
-	file, err := fs.createFile()
+	path := filepath.Join(fs.DestDir, fs.Filename)
+	created, err := fs.createFile(path, codec)
 	if err != nil {
-		return nil, err
+		return "", false, err
 	}
-	defer fs.closeFile(file)
 
-	return file, nil
+	if !created {
+		if err := fs.validateExistingFile(path, codec); err != nil {
+			return "", false, err
+		}
+	}
+
+	return path, created, nil
 }
 
-func (fs *JSONFileStore[T]) LoadData(filepath string) (T, error) {
+func (fs *FileStore[T]) LoadData(filepath string) (T, error) {
 	var zero T
 
 	file, err := fs.openFile(filepath, os.O_RDONLY)
@@ -82,34 +141,69 @@ func (fs *JSONFileStore[T]) LoadData(filepath string) (T, error) {
 		return zero, err
 	}
 
-	data, err := fs.unmarshall(bytes)
+	codec, err := fs.codecFor(filepath)
 	if err != nil {
 		return zero, err
 	}
 
+	env, err := decodeEnvelope(bytes, codec)
+	if err != nil {
+		return zero, &CorruptFileError{Filename: filepath, Err: errors.Unwrap(err)}
+	}
+
+	migrated, err := migrate(env, fs.Migrations)
+	if err != nil {
+		return zero, err
+	}
+
+	data, err := fs.unmarshall(migrated.Data)
+	if err != nil {
+		return zero, &CorruptFileError{Filename: filepath, Err: errors.Unwrap(err)}
+	}
+
+	if migrated.SchemaVersion != env.SchemaVersion {
+		// written from the typed data, like any save
+		upgraded := envelope{SchemaVersion: migrated.SchemaVersion, Data: data}
+		err := fs.upgradeFile(filepath, codec, bytes, env.SchemaVersion, upgraded)
+		if err != nil {
+			return zero, fmt.Errorf("failed to upgrade file:\n>%w", err)
+		}
+	}
+
 	return data, nil
 }
 
-func (fs *JSONFileStore[T]) SaveData(data T, filepath string) error {
-	file, err := fs.openFile(filepath, os.O_WRONLY|os.O_TRUNC)
+func (fs *FileStore[T]) SaveData(data T, filepath string) error {
+	info, err := fs.statFile(filepath)
+	if err != nil {
+		return err
+	}
+
+	codec, err := fs.codecFor(filepath)
 	if err != nil {
 		return err
 	}
-	defer fs.closeFile(file)
 
-	bytes, err := fs.marshall(data)
+	bytes, err := encodeEnvelope(envelope{
+		SchemaVersion: fs.SchemaVersion(),
+		Data:          data,
+	}, codec)
 	if err != nil {
 		return err
 	}
 
-	if err := fs.writeFileContent(file, bytes); err != nil {
+	if err := fs.backupFile(filepath, bytes); err != nil {
+		return err
+	}
+
+	if err := fs.writeFileAtomic(filepath, bytes, info.Mode().Perm()); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (fs *JSONFileStore[T]) validateDataStructure() error {
+func (fs *FileStore[T]) validateDataStructure() error {
 	if fs.InitData != EmptyArray && fs.InitData != EmptyObject {
 		return &InitDataError{InitData: fs.InitData}
 	}
@@ -117,36 +211,127 @@ func (fs *JSONFileStore[T]) validateDataStructure() error {
 	return nil
 }
 
-func (fs *JSONFileStore[T]) validateFilenameExt() error {
-	if filepath.Ext(fs.Filename) != ".json" {
-		return &FilenameExtError{Filename: fs.Filename}
+func (fs *FileStore[T]) codecFor(filename string) (Codec, error) {
+	if fs.Codec == nil {
+		return CodecFor(filename, DefaultCodecs)
 	}
 
-	return nil
+	return CodecFor(filename, []Codec{fs.Codec})
 }
 
-func (fs *JSONFileStore[T]) createDestDir() error {
-	if err := os.MkdirAll(fs.DestDir, 0644); err != nil {
+func (fs *FileStore[T]) createDestDir() error {
+	if err := os.MkdirAll(fs.DestDir, 0755); err != nil {
 		return fmt.Errorf("failed to create destination directory:\n>%w", err)
 	}
 
 	return nil
 }
 
-func (fs *JSONFileStore[T]) createFile() (*os.File, error) {
-	filepath := filepath.Join(fs.DestDir, fs.Filename)
+// createFile links a fully written temporary file into place, so the file
+// either doesn't exist or holds the initial data, and an existing file is
+// never overwritten. A zero-byte file holds nothing to lose, so it's
+// initialized too.
+func (fs *FileStore[T]) createFile(path string, codec Codec) (bool, error) {
+	var initData any
+	if err := json.Unmarshal([]byte(fs.InitData), &initData); err != nil {
+		return false, &InitDataError{InitData: fs.InitData}
+	}
 
-	file, err := os.OpenFile(filepath, os.O_WRONLY|os.O_CREATE, 0644)
+	content, err := encodeEnvelope(envelope{
+		SchemaVersion: fs.SchemaVersion(),
+		Data:          initData,
+	}, codec)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create file:\n>%w", err)
+		return false, err
 	}
 
-	file.WriteString(string(fs.InitData))
+	info, err := os.Stat(path)
+	switch {
+	case err == nil && info.Size() == 0 && info.Mode().IsRegular():
+		if err := fs.writeFileAtomic(path, content, 0644); err != nil {
+			return false, fmt.Errorf("failed to create file:\n>%w", err)
+		}
+		return true, nil
+	case err == nil:
+		return false, nil
+	case !errors.Is(err, os.ErrNotExist):
+		return false, fmt.Errorf("failed to create file:\n>%w", err)
+	}
 
-	return file, nil
+	tmp, err := fs.writeTempFile(path, content, 0644)
+	if err != nil {
+		return false, fmt.Errorf("failed to create file:\n>%w", err)
+	}
+	defer os.Remove(tmp)
+
+	ops := fs.ops()
+	if err := ops.link(tmp, path); err != nil {
+		if errors.Is(err, os.ErrExist) { // another process created it first
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to create file:\n>%w", err)
+	}
+
+	if err := ops.syncDir(filepath.Dir(path)); err != nil {
+		return false, fmt.Errorf("failed to sync directory:\n>%w", err)
+	}
+
+	return true, nil
+}
+
+func (fs *FileStore[T]) validateExistingFile(path string, codec Codec) error {
+	info, err := fs.statFile(path)
+	if err != nil {
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		return &FileContentError{Filename: path, Message: "not a regular file"}
+	}
+
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file content:\n>%w", err)
+	}
+
+	env, err := decodeEnvelope(bytes, codec)
+	if err != nil {
+		return &CorruptFileError{Filename: path, Err: errors.Unwrap(err)}
+	}
+
+	if env.SchemaVersion > fs.SchemaVersion() {
+		return &SchemaVersionError{
+			Version:   env.SchemaVersion,
+			Supported: fs.SchemaVersion(),
+		}
+	}
+
+	switch env.Data.(type) {
+	case map[string]any:
+		if fs.InitData != EmptyObject {
+			return &FileContentError{
+				Filename: path,
+				Message:  "expected an array, but got an object",
+			}
+		}
+	case []any:
+		if fs.InitData != EmptyArray {
+			return &FileContentError{
+				Filename: path,
+				Message:  "expected an object, but got an array",
+			}
+		}
+	default:
+		return &FileContentError{
+			Filename: path,
+			Message:  "expected an object or an array",
+		}
+	}
+
+	return nil
 }
 
-func (fs *JSONFileStore[T]) openFile(
+func (fs *FileStore[T]) openFile(
 	filepath string,
 	mode int,
 ) (*os.File, error) {
@@ -158,26 +343,142 @@ func (fs *JSONFileStore[T]) openFile(
 	return file, nil
 }
 
-func (fs *JSONFileStore[T]) closeFile(file *os.File) error {
-	if err := file.Close(); err != nil {
-		return fmt.Errorf("failed to close file:\n>%w", err)
+func (fs *FileStore[T]) statFile(filepath string) (os.FileInfo, error) {
+	info, err := os.Stat(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file:\n>%w", err)
+	}
+
+	if info.IsDir() {
+		return nil, fmt.Errorf("failed to open file:\n>%w", &os.PathError{
+			Op:   "open",
+			Path: filepath,
+			Err:  syscall.EISDIR,
+		})
+	}
+
+	return info, nil
+}
+
+// writeFileAtomic never touches the live file until the new content is fully
+// on disk: it writes a temporary sibling, syncs it, renames it over the
+// original and syncs the directory so the rename itself survives a crash.
+func (fs *FileStore[T]) writeFileAtomic(
+	path string,
+	bytes []byte,
+	perm os.FileMode,
+) error {
+	ops := fs.ops()
+
+	tmp, err := fs.writeTempFile(path, bytes, perm)
+	if err != nil {
+		return err
+	}
+
+	if err := ops.rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace file:\n>%w", err)
+	}
+
+	if err := ops.syncDir(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("failed to sync directory:\n>%w", err)
 	}
 
 	return nil
 }
 
-func (fs *JSONFileStore[T]) marshall(data T) ([]byte, error) {
-	bytes, err := json.Marshal(data)
+func (fs *FileStore[T]) writeTempFile(
+	path string,
+	bytes []byte,
+	perm os.FileMode,
+) (name string, err error) {
+	ops := fs.ops()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal data:\n>%w", err)
+		return "", fmt.Errorf("failed to create temporary file:\n>%w", err)
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err := ops.write(tmp, bytes); err != nil {
+		return "", fmt.Errorf("failed to write file content:\n>%w", err)
 	}
 
-	return bytes, nil
+	if err := ops.sync(tmp); err != nil {
+		return "", fmt.Errorf("failed to sync file:\n>%w", err)
+	}
+
+	if err := tmp.Chmod(perm); err != nil {
+		return "", fmt.Errorf("failed to set file permissions:\n>%w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to close file:\n>%w", err)
+	}
+
+	return tmp.Name(), nil
+}
+
+// writeNewFile writes content to the first name that doesn't exist yet, trying
+// nameFor(0), nameFor(1) and so on, and never replaces an existing file.
+func (fs *FileStore[T]) writeNewFile(
+	nameFor func(n int) string,
+	content []byte,
+) (string, error) {
+	for n := 0; ; n++ {
+		name := nameFor(n)
+
+		file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to create file:\n>%w", err)
+		}
+
+		if _, err := file.Write(content); err != nil {
+			file.Close()
+			return "", fmt.Errorf("failed to write file content:\n>%w", err)
+		}
+
+		if err := file.Sync(); err != nil {
+			file.Close()
+			return "", fmt.Errorf("failed to sync file:\n>%w", err)
+		}
+
+		return name, fs.closeFile(file)
+	}
 }
 
-func (fs *JSONFileStore[T]) unmarshall(bytes []byte) (T, error) {
+func (fs *FileStore[T]) ops() *fileOps {
+	if fs.fileOps != nil {
+		return fs.fileOps
+	}
+	return &defaultFileOps
+}
+
+func (fs *FileStore[T]) closeFile(file *os.File) error {
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close file:\n>%w", err)
+	}
+
+	return nil
+}
+
+func (fs *FileStore[T]) unmarshall(decoded any) (T, error) {
 	var data T
 
+	bytes, err := json.Marshal(decoded)
+	if err != nil {
+		var zero T
+		return zero, fmt.Errorf("failed to unmarshal data:\n>%w", err)
+	}
+
 	if err := json.Unmarshal(bytes, &data); err != nil {
 		var zero T
 		return zero, fmt.Errorf("failed to unmarshal data:\n>%w", err)
@@ -186,7 +487,7 @@ func (fs *JSONFileStore[T]) unmarshall(bytes []byte) (T, error) {
 	return data, nil
 }
 
-func (fs *JSONFileStore[T]) readFileContent(file *os.File) ([]byte, error) {
+func (fs *FileStore[T]) readFileContent(file *os.File) ([]byte, error) {
 	bytes, err := io.ReadAll(file)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file content:\n>%w", err)
@@ -195,12 +496,23 @@ func (fs *JSONFileStore[T]) readFileContent(file *os.File) ([]byte, error) {
 	return bytes, nil
 }
 
-func (fs *JSONFileStore[T]) writeFileContent(file *os.File, bytes []byte) error {
-	if _, err := file.Write(bytes); err != nil {
-		return fmt.Errorf("failed to write file content:\n>%w", err)
+func syncDir(dir string) error {
+	// Windows can't open a directory for syncing, and NTFS journals renames.
+	if runtime.GOOS == "windows" {
+		return nil
 	}
 
-	return nil
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return file.Sync()
 }
 
-var _ Store[any] = (*JSONFileStore[any])(nil)
+var (
+	_ Store[any]  = (*FileStore[any])(nil)
+	_ BackupStore = (*FileStore[any])(nil)
+	_ RepairStore = (*FileStore[any])(nil)
+)
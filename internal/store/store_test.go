@@ -2,6 +2,7 @@ package store_test
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -31,7 +32,16 @@ func Test_FilenameExtError_Error(t *testing.T) {
 		})
 }
 
-func Test_JSONFileStore_InitFile_Happy(t *testing.T) {
+func Test_FileContentError_Error(t *testing.T) {
+	t.Run("returns a string containing the filename and message successfully",
+		func(t *testing.T) {
+			err := &st.FileContentError{Filename: "tasks.json", Message: "invalid"}
+			th.AssertErrorMessage(t, err, err.Error(), err.Filename)
+			th.AssertErrorMessage(t, err, err.Error(), err.Message)
+		})
+}
+
+func Test_FileStore_InitFile_Happy(t *testing.T) {
 	testCases := []struct {
 		name     string
 		initData st.JSONInitData
@@ -43,7 +53,7 @@ func Test_JSONFileStore_InitFile_Happy(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
-			fs := st.JSONFileStore[any]{
+			fs := st.FileStore[any]{
 				DestDir:  t.TempDir(),
 				Filename: fmt.Sprintf("test_%s.json", tc.name),
 				InitData: tc.initData,
@@ -51,15 +61,16 @@ func Test_JSONFileStore_InitFile_Happy(t *testing.T) {
 			filepath := filepath.Join(fs.DestDir, fs.Filename)
 			t.Cleanup(func() { os.Remove(filepath) })
 
-			_, err := fs.InitFile()
+			path, created, err := fs.InitFile()
 			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, path, filepath)
+			th.AssertDeepEqual(t, created, true)
 
 			if _, err := os.Stat(filepath); os.IsNotExist(err) {
 				t.Errorf("got nothing, but want a file")
 			}
 
-			content, err := os.ReadFile(filepath)
-			th.AssertNoError(t, err)
+			content := readEnvelopeData(t, filepath, 1)
 
 			var got any
 			switch tc.initData {
@@ -83,15 +94,15 @@ func Test_JSONFileStore_InitFile_Happy(t *testing.T) {
 	}
 }
 
-func Test_JSONFileStore_InitFile_Sad_Edge(t *testing.T) {
+func Test_FileStore_InitFile_Sad_Edge(t *testing.T) {
 	testCases := []struct {
 		name    string
-		fs      st.JSONFileStore[any]
+		fs      st.FileStore[any]
 		errType error
 	}{
 		{
 			"returns an InitDataError for a bad initial data structure",
-			st.JSONFileStore[any]{
+			st.FileStore[any]{
 				DestDir:  t.TempDir(),
 				Filename: "bad_init_data_struct.json",
 				InitData: "incorrect",
@@ -100,7 +111,7 @@ func Test_JSONFileStore_InitFile_Sad_Edge(t *testing.T) {
 		},
 		{
 			"returns a FilenameExtError for a bad filename extension",
-			st.JSONFileStore[any]{
+			st.FileStore[any]{
 				DestDir:  t.TempDir(),
 				Filename: "bad_filename.incorrect",
 				InitData: "{}",
@@ -110,7 +121,7 @@ func Test_JSONFileStore_InitFile_Sad_Edge(t *testing.T) {
 		{
 			"returns an os.PathError when destination directory creation fails " +
 				"successfully",
-			st.JSONFileStore[any]{
+			st.FileStore[any]{
 				DestDir:  strings.Repeat("a", 1000), // too long
 				Filename: "dest_dir_creation_fails.json",
 				InitData: "{}",
@@ -119,7 +130,7 @@ func Test_JSONFileStore_InitFile_Sad_Edge(t *testing.T) {
 		},
 		{
 			"returns an os.PathError when file creation fails successfully",
-			st.JSONFileStore[any]{
+			st.FileStore[any]{
 				DestDir:  t.TempDir(),
 				Filename: fmt.Sprintf("%s.json", strings.Repeat("a", 1000)), // too long
 				InitData: "{}",
@@ -128,7 +139,7 @@ func Test_JSONFileStore_InitFile_Sad_Edge(t *testing.T) {
 		},
 		{
 			"returns an os.PathError for an empty destination directory successfully",
-			st.JSONFileStore[any]{
+			st.FileStore[any]{
 				DestDir:  "",
 				Filename: "empty_dir.json",
 				InitData: "{}",
@@ -137,7 +148,7 @@ func Test_JSONFileStore_InitFile_Sad_Edge(t *testing.T) {
 		},
 		{
 			"returns a FilenameExtError for an empty filename successfully",
-			st.JSONFileStore[any]{
+			st.FileStore[any]{
 				DestDir:  t.TempDir(),
 				Filename: "",
 				InitData: "{}",
@@ -146,7 +157,7 @@ func Test_JSONFileStore_InitFile_Sad_Edge(t *testing.T) {
 		},
 		{
 			"returns an InitDataError for an empty initial data structure successfully",
-			st.JSONFileStore[any]{
+			st.FileStore[any]{
 				DestDir:  t.TempDir(),
 				Filename: "empty_init_data.json",
 				InitData: "",
@@ -161,13 +172,118 @@ func Test_JSONFileStore_InitFile_Sad_Edge(t *testing.T) {
 			filepath := filepath.Join(tc.fs.DestDir, tc.fs.Filename)
 			t.Cleanup(func() { os.Remove(filepath) })
 
-			_, err := tc.fs.InitFile()
+			_, _, err := tc.fs.InitFile()
 			th.AssertError(t, err, tc.errType)
 		})
 	}
 }
 
-func Test_JSONFileStore_SaveData_Happy(t *testing.T) {
+func Test_FileStore_InitFile_Existing(t *testing.T) {
+	t.Run("leaves valid existing data untouched successfully", func(t *testing.T) {
+		testCases := []struct {
+			name     string
+			initData st.JSONInitData
+			content  string
+		}{
+			{"reuses a JSON object", st.EmptyObject, FakeJSONObject},
+			{"reuses a JSON array", st.EmptyArray, FakeJSONArray},
+			{"reuses a single character object", st.EmptyObject, "{}"},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+				fs, filepath := setupFileStore(t, "existing.json")
+				fs.InitData = tc.initData
+
+				err := os.WriteFile(filepath, []byte(tc.content), 0644)
+				th.AssertNoError(t, err)
+
+				path, created, err := fs.InitFile()
+				th.AssertNoError(t, err)
+				th.AssertDeepEqual(t, path, filepath)
+				th.AssertDeepEqual(t, created, false)
+
+				got, err := os.ReadFile(filepath)
+				th.AssertNoError(t, err)
+				th.AssertDeepEqual(t, string(got), tc.content)
+			})
+		}
+	})
+
+	t.Run("is idempotent successfully", func(t *testing.T) {
+		fs, filepath := setupFileStore(t, "idempotent.json")
+
+		_, created, err := fs.InitFile()
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, created, true)
+
+		err = fs.SaveData(map[string]any{"1": "task"}, filepath)
+		th.AssertNoError(t, err)
+
+		_, created, err = fs.InitFile()
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, created, false)
+
+		got, err := fs.LoadData(filepath)
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, got, any(map[string]any{"1": "task"}))
+		assertNoTempFiles(t, fs.DestDir)
+	})
+
+	t.Run("initializes an existing empty file successfully", func(t *testing.T) {
+		fs, filepath := setupFileStore(t, "empty.json")
+
+		err := os.WriteFile(filepath, nil, 0644)
+		th.AssertNoError(t, err)
+
+		_, created, err := fs.InitFile()
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, created, true)
+
+		got := readEnvelopeData(t, filepath, 1)
+		th.AssertDeepEqual(t, string(got), "{}")
+	})
+
+	t.Run("returns an error for unusable existing data",
+		func(t *testing.T) {
+			testCases := []struct {
+				name     string
+				initData st.JSONInitData
+				content  string
+				wantErr  error
+			}{
+				{"rejects invalid JSON", st.EmptyObject, `{"key": "value"`,
+					&st.CorruptFileError{}},
+				{"rejects an array for an object", st.EmptyObject, FakeJSONArray,
+					&st.FileContentError{}},
+				{"rejects an object for an array", st.EmptyArray, FakeJSONObject,
+					&st.FileContentError{}},
+				{"rejects a scalar", st.EmptyObject, `"tasks"`,
+					&st.FileContentError{}},
+			}
+
+			for _, tc := range testCases {
+				t.Run(tc.name, func(t *testing.T) {
+					t.Parallel()
+					fs, filepath := setupFileStore(t, "unusable.json")
+					fs.InitData = tc.initData
+
+					err := os.WriteFile(filepath, []byte(tc.content), 0644)
+					th.AssertNoError(t, err)
+
+					_, _, err = fs.InitFile()
+					th.AssertError(t, err, tc.wantErr)
+
+					got, err := os.ReadFile(filepath)
+					th.AssertNoError(t, err)
+					th.AssertDeepEqual(t, string(got), tc.content)
+				})
+			}
+		})
+}
+
+func Test_FileStore_SaveData_Happy(t *testing.T) {
 	testCases := []struct {
 		name     string
 		jsonData string
@@ -182,8 +298,8 @@ func Test_JSONFileStore_SaveData_Happy(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
-			filename := strings.ReplaceAll(tc.name, " ", "_")
-			fs, filepath := setupJSONFileStore(t, filename)
+			filename := strings.ReplaceAll(tc.name, " ", "_") + ".json"
+			fs, filepath := setupFileStore(t, filename)
 
 			flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
 			writeFile, err := os.OpenFile(filepath, flags, 0644)
@@ -210,16 +326,20 @@ func Test_JSONFileStore_SaveData_Happy(t *testing.T) {
 			bytes, err := io.ReadAll(readFile)
 			th.AssertNoError(t, err)
 
-			var got any
+			var got struct {
+				SchemaVersion int `json:"schemaVersion"`
+				Data          any `json:"data"`
+			}
 			err = json.Unmarshal(bytes, &got)
 			th.AssertNoError(t, err)
 
-			th.AssertDeepEqual(t, got, want)
+			th.AssertDeepEqual(t, got.SchemaVersion, 1)
+			th.AssertDeepEqual(t, got.Data, want)
 		})
 	}
 }
 
-func Test_JSONFileStore_SaveData_Sad_Edge(t *testing.T) {
+func Test_FileStore_SaveData_Sad_Edge(t *testing.T) {
 	testCases := []struct {
 		name     string
 		filename string
@@ -248,14 +368,113 @@ func Test_JSONFileStore_SaveData_Sad_Edge(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			fs, filepath := setupJSONFileStore(t, tc.filename)
+			fs, filepath := setupFileStore(t, tc.filename)
 			err := fs.SaveData(tc.data, filepath)
 			th.AssertError(t, err, tc.errType)
 		})
 	}
 }
 
-func Test_JSONFileStore_LoadData_Happy(t *testing.T) {
+func Test_FileStore_SaveData_Atomic(t *testing.T) {
+	errDiskFull := errors.New("no space left on device")
+
+	testCases := []struct {
+		name string
+		ops  *st.FileOps
+	}{
+		{
+			name: "keeps the old data when the write is interrupted",
+			ops: st.NewFileOps(
+				func(f *os.File, b []byte) (int, error) {
+					n, _ := f.Write(b[:len(b)/2])
+					return n, errDiskFull
+				},
+				nil,
+				nil,
+			),
+		},
+		{
+			name: "keeps the old data when the sync fails",
+			ops: st.NewFileOps(
+				nil,
+				func(*os.File) error { return errDiskFull },
+				nil,
+			),
+		},
+		{
+			name: "keeps the old data when the rename fails",
+			ops: st.NewFileOps(
+				nil,
+				nil,
+				func(string, string) error { return errDiskFull },
+			),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			fs, filepath := setupFileStore(t, "atomic.json")
+
+			err := os.WriteFile(filepath, []byte(FakeJSONObject), 0644)
+			th.AssertNoError(t, err)
+
+			st.SetFileOps(fs, tc.ops)
+
+			var data any
+			err = json.Unmarshal([]byte(FakeNestedMixedData), &data)
+			th.AssertNoError(t, err)
+
+			err = fs.SaveData(data, filepath)
+			if !errors.Is(err, errDiskFull) {
+				t.Fatalf("got %v, want %v", err, errDiskFull)
+			}
+
+			got, err := os.ReadFile(filepath)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, string(got), FakeJSONObject)
+
+			assertNoTempFiles(t, fs.DestDir)
+		})
+	}
+
+	t.Run("ignores a temporary file left behind by a crashed save",
+		func(t *testing.T) {
+			fs, filepath := setupFileStore(t, "crashed.json")
+
+			err := os.WriteFile(filepath, []byte(FakeJSONObject), 0644)
+			th.AssertNoError(t, err)
+
+			stray := filepath + ".tmp-crashed"
+			err = os.WriteFile(stray, []byte(`{"items": [`), 0644)
+			th.AssertNoError(t, err)
+
+			got, err := fs.LoadData(filepath)
+			th.AssertNoError(t, err)
+
+			var want any
+			err = json.Unmarshal([]byte(FakeJSONObject), &want)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got, want)
+		})
+
+	t.Run("preserves the file permissions successfully", func(t *testing.T) {
+		fs, filepath := setupFileStore(t, "permissions.json")
+
+		err := os.WriteFile(filepath, []byte("{}"), 0600)
+		th.AssertNoError(t, err)
+
+		err = fs.SaveData(map[string]any{"key": "value"}, filepath)
+		th.AssertNoError(t, err)
+
+		info, err := os.Stat(filepath)
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, info.Mode().Perm(), os.FileMode(0600))
+		assertNoTempFiles(t, fs.DestDir)
+	})
+}
+
+func Test_FileStore_LoadData_Happy(t *testing.T) {
 	testCases := []struct {
 		name     string
 		jsonData string
@@ -271,7 +490,7 @@ func Test_JSONFileStore_LoadData_Happy(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 			filename := strings.ReplaceAll(tc.name, " ", "_") + ".json"
-			fs, filepath := setupJSONFileStore(t, filename)
+			fs, filepath := setupFileStore(t, filename)
 
 			err := os.WriteFile(filepath, []byte(tc.jsonData), 0644)
 			th.AssertNoError(t, err)
@@ -288,10 +507,10 @@ func Test_JSONFileStore_LoadData_Happy(t *testing.T) {
 	}
 }
 
-func Test_JSONFileStore_LoadData_Sad(t *testing.T) {
+func Test_FileStore_LoadData_Sad(t *testing.T) {
 	t.Run("returns an os.PathError if the file doesn't exist successfully",
 		func(t *testing.T) {
-			fs, filepath := setupJSONFileStore(t, "test_not_exist.json")
+			fs, filepath := setupFileStore(t, "test_not_exist.json")
 			got, err := fs.LoadData(filepath)
 			th.AssertError(t, err, &os.PathError{})
 			th.AssertNil(t, got)
@@ -299,7 +518,7 @@ func Test_JSONFileStore_LoadData_Sad(t *testing.T) {
 
 	t.Run("returns a json.SyntaxError if EOF is reached successfully",
 		func(t *testing.T) {
-			fs, filepath := setupJSONFileStore(t, "test_eof.json")
+			fs, filepath := setupFileStore(t, "test_eof.json")
 
 			err := os.WriteFile(filepath, []byte("{\"key\": \"value\""), 0644)
 			th.AssertNoError(t, err)
@@ -310,10 +529,10 @@ func Test_JSONFileStore_LoadData_Sad(t *testing.T) {
 		})
 }
 
-func Test_JSONFileStore_LoadData_Edge(t *testing.T) {
+func Test_FileStore_LoadData_Edge(t *testing.T) {
 	t.Run("returns an os.PathError if filepath is an empty string successfully",
 		func(t *testing.T) {
-			fs, emptyFilepath := setupJSONFileStore(t, "")
+			fs, emptyFilepath := setupFileStore(t, "")
 			got, err := fs.LoadData(emptyFilepath)
 			th.AssertError(t, err, &os.PathError{})
 			th.AssertNil(t, got)
@@ -387,8 +606,8 @@ const (
 	}`
 )
 
-func setupJSONFileStore(t *testing.T, f string) (*st.JSONFileStore[any], string) {
-	fs := &st.JSONFileStore[any]{
+func setupFileStore(t *testing.T, f string) (*st.FileStore[any], string) {
+	fs := &st.FileStore[any]{
 		DestDir:  t.TempDir(),
 		Filename: f,
 		InitData: "{}",
@@ -397,3 +616,30 @@ func setupJSONFileStore(t *testing.T, f string) (*st.JSONFileStore[any], string)
 	t.Cleanup(func() { os.Remove(filepath) })
 	return fs, filepath
 }
+
+func assertNoTempFiles(t testing.TB, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	th.AssertNoError(t, err)
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp-") {
+			t.Errorf("got leftover temporary file %s, want none", entry.Name())
+		}
+	}
+}
+
+func readEnvelopeData(t testing.TB, path string, wantVersion int) []byte {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	th.AssertNoError(t, err)
+
+	var env struct {
+		SchemaVersion int             `json:"schemaVersion"`
+		Data          json.RawMessage `json:"data"`
+	}
+	err = json.Unmarshal(content, &env)
+	th.AssertNoError(t, err)
+	th.AssertDeepEqual(t, env.SchemaVersion, wantVersion)
+
+	return env.Data
+}
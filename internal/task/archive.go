@@ -0,0 +1,262 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	st "github.com/alnah/task-tracker/internal/store"
+)
+
+// Archive is a second tasks file holding archived done tasks, so the tasks
+// file stays small. Its tasks keep their IDs, which are never given again.
+type Archive struct {
+	Store    st.Store[TaskList]
+	Filepath string
+}
+
+// ArchivePolicy archives done tasks automatically on save, once they've been
+// done for After.
+type ArchivePolicy struct {
+	After time.Duration
+}
+
+// TaskConflictError reports a task found both in the tasks and In, the
+// archive or the trash, which it can't be moved back from.
+type TaskConflictError struct {
+	ID uint
+	In string
+}
+
+func (e *TaskConflictError) Error() string {
+	return fmt.Sprintf("task with ID %d exists in both the tasks and the %s",
+		e.ID, e.In)
+}
+
+// ArchiveTasks moves to the archive the tasks done for olderThan, and returns
+// them.
+func (tr *FileTaskRepository) ArchiveTasks(
+	olderThan time.Duration,
+) (Tasks, error) {
+	lock, err := tr.lock()
+	if err != nil {
+		return Tasks{}, err
+	}
+	defer lock.Unlock()
+
+	list, err := tr.load()
+	if err != nil {
+		return Tasks{}, err
+	}
+
+	archived, err := tr.archiveDone(list, olderThan)
+	if err != nil {
+		return Tasks{}, err
+	}
+	if len(archived) == 0 {
+		return archived, nil
+	}
+
+	if err := tr.writeTasks(list); err != nil {
+		return Tasks{}, err
+	}
+	return archived, nil
+}
+
+func (tr *FileTaskRepository) ReadArchive() (Tasks, error) {
+	lock, err := tr.lock()
+	if err != nil {
+		return Tasks{}, err
+	}
+	defer lock.Unlock()
+
+	archive, err := tr.loadArchive()
+	if err != nil {
+		return Tasks{}, err
+	}
+	return archive.Tasks, nil
+}
+
+// UnarchiveTask moves a task back from the archive.
+func (tr *FileTaskRepository) UnarchiveTask(id uint) (Task, error) {
+	lock, err := tr.lock()
+	if err != nil {
+		return Task{}, err
+	}
+	defer lock.Unlock()
+
+	list, err := tr.load()
+	if err != nil {
+		return Task{}, err
+	}
+
+	archive, err := tr.loadArchive()
+	if err != nil {
+		return Task{}, err
+	}
+
+	task, err := tr.findByID(archive.Tasks, id)
+	if err != nil {
+		return Task{}, err
+	}
+	if _, ok := list.Tasks[id]; ok {
+		return Task{}, &TaskConflictError{ID: id, In: "archive"}
+	}
+
+	task.ArchivedAt = nil
+	task.UpdatedAt = tr.TimeProvider.Now()
+	list.Tasks[id] = task
+	delete(archive.Tasks, id)
+
+	// save the tasks first: a failure in between leaves the task in both
+	// files, never in none. Skip auto archiving, which would take it back.
+	if err := tr.writeTasks(list); err != nil {
+		return Task{}, err
+	}
+	if err := tr.saveArchive(archive); err != nil {
+		return Task{}, err
+	}
+
+	return task, nil
+}
+
+// archiveDone moves the tasks completed olderThan ago, in a final status of
+// the workflow, from list to the archive file, which it saves, counting from
+// their last update when they have no CompletedAt. The caller saves list.
+func (tr *FileTaskRepository) archiveDone(
+	list TaskList,
+	olderThan time.Duration,
+) (Tasks, error) {
+	now := tr.TimeProvider.Now()
+	cutoff := now.Add(-olderThan)
+
+	archived := Tasks{}
+	for id, task := range list.Tasks {
+		if !tr.Workflow.Final(task.Status) {
+			continue
+		}
+		completedAt := task.UpdatedAt
+		if task.CompletedAt != nil {
+			completedAt = *task.CompletedAt
+		}
+		if completedAt.After(cutoff) {
+			continue
+		}
+		task.ArchivedAt = &now
+		archived[id] = task
+	}
+	if len(archived) == 0 {
+		return archived, nil
+	}
+
+	archive, err := tr.openArchive()
+	if err != nil {
+		return Tasks{}, err
+	}
+
+	// a task already archived is a leftover of an interrupted unarchive, and
+	// the copy in the tasks file is the newer one
+	for id, task := range archived {
+		archive.Tasks[id] = task
+	}
+	archive.LastID = max(archive.LastID, list.LastID)
+
+	// save the archive first: a failure in between leaves the tasks in both
+	// files, never in none
+	if err := tr.saveArchive(archive); err != nil {
+		return Tasks{}, err
+	}
+
+	for id := range archived {
+		delete(list.Tasks, id)
+	}
+	return archived, nil
+}
+
+// RepairArchive repairs a corrupt archive file as Repair does the tasks file,
+// and leaves a missing one alone.
+func (tr *FileTaskRepository) RepairArchive() (st.RepairReport, error) {
+	if tr.Archive == nil {
+		return st.RepairReport{}, nil
+	}
+	store, ok := tr.Archive.Store.(st.RepairStore)
+	if !ok {
+		return st.RepairReport{}, errors.New("the archive store can't be repaired")
+	}
+
+	lock, err := tr.lock()
+	if err != nil {
+		return st.RepairReport{}, err
+	}
+	defer lock.Unlock()
+
+	exists, err := tr.archiveExists()
+	if err != nil || !exists {
+		return st.RepairReport{}, err
+	}
+
+	report, err := store.Repair(tr.Archive.Filepath)
+	if err != nil {
+		return st.RepairReport{}, fmt.Errorf("failed to repair archive data:\n>%w", err)
+	}
+	return report, nil
+}
+
+// archiveExists reports whether the archive file exists, which it only does
+// once a task has been archived.
+func (tr *FileTaskRepository) archiveExists() (bool, error) {
+	_, err := os.Stat(tr.Archive.Filepath)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check archive:\n>%w", err)
+	}
+	return true, nil
+}
+
+// openArchive creates the archive file unless it exists, then loads it, for
+// the changes that save it.
+func (tr *FileTaskRepository) openArchive() (TaskList, error) {
+	if tr.Archive == nil {
+		return TaskList{}, errors.New("no archive is set up for the tasks")
+	}
+
+	if _, _, err := tr.Archive.Store.InitFile(); err != nil {
+		return TaskList{}, fmt.Errorf("failed to initialize archive:\n>%w", err)
+	}
+	return tr.loadArchive()
+}
+
+// loadArchive loads the archive, empty when its file doesn't exist yet.
+func (tr *FileTaskRepository) loadArchive() (TaskList, error) {
+	if tr.Archive == nil {
+		return TaskList{}, errors.New("no archive is set up for the tasks")
+	}
+
+	exists, err := tr.archiveExists()
+	if err != nil {
+		return TaskList{}, err
+	}
+	if !exists {
+		return TaskList{Tasks: Tasks{}}, nil
+	}
+
+	archive, err := tr.Archive.Store.LoadData(tr.Archive.Filepath)
+	if err != nil {
+		return TaskList{}, fmt.Errorf("failed to load archive data:\n>%w", err)
+	}
+	if archive.Tasks == nil {
+		archive.Tasks = Tasks{}
+	}
+	return archive, nil
+}
+
+func (tr *FileTaskRepository) saveArchive(archive TaskList) error {
+	err := tr.Archive.Store.SaveData(archive, tr.Archive.Filepath)
+	if err != nil {
+		return fmt.Errorf("failed to save archive data:\n>%w", err)
+	}
+	return nil
+}
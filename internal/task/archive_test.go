@@ -0,0 +1,281 @@
+package task_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	tk "github.com/alnah/task-tracker/internal/task"
+	th "github.com/alnah/task-tracker/test_helpers"
+)
+
+func Test_TaskConflictError_Error(t *testing.T) {
+	t.Run("returns a string containing the ID", func(t *testing.T) {
+		err := &tk.TaskConflictError{ID: 4, In: "trash"}
+		th.AssertErrorMessage(t, err, err.Error(), "4")
+		th.AssertErrorMessage(t, err, err.Error(), "the tasks and the trash")
+	})
+}
+
+func Test_FileTaskRepository_ArchiveTasks(t *testing.T) {
+	at := func(days int) time.Time {
+		return th.FixedTime.Add(time.Duration(days) * 24 * time.Hour)
+	}
+
+	testCases := []struct {
+		name      string
+		olderThan time.Duration
+		want      []uint
+	}{
+		{"archives every done task", 0, []uint{2, 3}},
+		{"archives the done tasks untouched for the age", 15 * 24 * time.Hour,
+			[]uint{2}},
+		{"archives nothing newer than the age", 60 * 24 * time.Hour, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			mockFs, mockArchive, taskRepo := setupArchiveUnitTest(t)
+			mockFs.Tasks = tk.Tasks{
+				1: th.NewTestTask(1, "test_task_1", tk.Todo),
+				2: th.NewTestTask(2, "test_task_2", tk.Done),
+				3: th.NewTestTask(3, "test_task_3", tk.Done),
+			}
+			task := mockFs.Tasks[3]
+			task.UpdatedAt = at(20)
+			mockFs.Tasks[3] = task
+			taskRepo.TimeProvider = &th.StubTimeProvider{FixedTime: at(30)}
+
+			got, err := taskRepo.ArchiveTasks(tc.olderThan)
+			th.AssertNoError(t, err)
+
+			var gotIDs []uint
+			for _, id := range []uint{1, 2, 3} {
+				if task, ok := got[id]; ok {
+					gotIDs = append(gotIDs, id)
+					th.AssertDeepEqual(t, task.ArchivedAt, ptr(at(30)))
+					th.AssertDeepEqual(t, mockArchive.Tasks[id], task)
+					if _, ok := mockFs.Tasks[id]; ok {
+						t.Errorf("got task %d in the tasks, want it archived", id)
+					}
+				}
+			}
+			th.AssertDeepEqual(t, gotIDs, tc.want)
+
+			wantCalls := Calls{LoadData}
+			if len(tc.want) > 0 {
+				wantCalls = append(wantCalls, SaveData)
+				th.AssertDeepEqual(t, mockArchive.Calls,
+					Calls{InitFile, LoadData, SaveData})
+			}
+			th.AssertDeepEqual(t, mockFs.Calls, wantCalls)
+		})
+	}
+
+	t.Run("counts the age from the completion successfully", func(t *testing.T) {
+		mockFs, _, taskRepo := setupArchiveUnitTest(t)
+		for id, updated := range map[uint]int{2: 29, 3: 20} {
+			task := mockFs.Tasks[id]
+			task.UpdatedAt = at(updated)
+			mockFs.Tasks[id] = task
+		}
+		task := mockFs.Tasks[2]
+		task.CompletedAt = ptr(at(0))
+		mockFs.Tasks[2] = task
+		taskRepo.TimeProvider = &th.StubTimeProvider{FixedTime: at(30)}
+
+		got, err := taskRepo.ArchiveTasks(15 * 24 * time.Hour)
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, len(got), 1)
+		th.AssertDeepEqual(t, got[2].ID, uint(2))
+	})
+
+	t.Run("archives the tasks in a final custom status successfully",
+		func(t *testing.T) {
+			mockFs, mockArchive, taskRepo := setupArchiveUnitTest(t)
+			taskRepo.Workflow = &tk.Workflow{
+				Statuses: []tk.Status{"open", "closed"},
+				Transitions: []tk.Transition{
+					{Name: "close", From: []tk.Status{"open"}, To: "closed"},
+				},
+			}
+			mockFs.Tasks = tk.Tasks{
+				1: th.NewTestTask(1, "test_task_1", "open"),
+				2: th.NewTestTask(2, "test_task_2", "closed"),
+			}
+
+			got, err := taskRepo.ArchiveTasks(0)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, len(got), 1)
+			th.AssertDeepEqual(t, mockArchive.Tasks[2].Status, tk.Status("closed"))
+			if _, ok := mockFs.Tasks[1]; !ok {
+				t.Errorf("got task 1 archived, want it in the tasks")
+			}
+		})
+
+	t.Run("returns an error without an archive", func(t *testing.T) {
+		mockFs, taskRepo := setupTaskUnitTest(t)
+		mockFs.Tasks[1] = th.NewTestTask(1, "test_task_1", tk.Done)
+
+		_, err := taskRepo.ArchiveTasks(0)
+		th.AssertNotNil(t, err)
+		th.AssertDeepEqual(t, mockFs.Calls, Calls{LoadData})
+	})
+}
+
+func Test_FileTaskRepository_UnarchiveTask(t *testing.T) {
+	t.Run("moves a task back from the archive successfully",
+		func(t *testing.T) {
+			mockFs, mockArchive, taskRepo := setupArchiveUnitTest(t)
+			want := mockFs.Tasks[2]
+			_, err := taskRepo.ArchiveTasks(0)
+			th.AssertNoError(t, err)
+
+			later := th.FixedTime.Add(time.Hour)
+			taskRepo.TimeProvider = &th.StubTimeProvider{FixedTime: later}
+			want.UpdatedAt = later
+
+			got, err := taskRepo.UnarchiveTask(2)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got, want)
+			th.AssertDeepEqual(t, mockFs.Tasks[2], want)
+			if _, ok := mockArchive.Tasks[2]; ok {
+				t.Errorf("got task 2 in the archive, want it unarchived")
+			}
+		})
+
+	t.Run("keeps an unarchived task out of auto archiving successfully",
+		func(t *testing.T) {
+			mockFs, mockArchive, taskRepo := setupArchiveUnitTest(t)
+			taskRepo.AutoArchive = &tk.ArchivePolicy{After: time.Hour}
+			mockArchive.Tasks = tk.Tasks{
+				2: mockFs.Tasks[2],
+			}
+			delete(mockFs.Tasks, 2)
+
+			_, err := taskRepo.UnarchiveTask(2)
+			th.AssertNoError(t, err)
+			_, err = taskRepo.CreateTask(tk.CreateTaskParams{
+				Description: "test_task_9",
+			})
+			th.AssertNoError(t, err)
+
+			th.AssertDeepEqual(t, mockFs.Tasks[2].Status, tk.Done)
+		})
+
+	t.Run("never gives an archived ID to a new task successfully",
+		func(t *testing.T) {
+			mockFs, mockArchive, taskRepo := setupArchiveUnitTest(t)
+			mockArchive.LastID = 5
+			mockArchive.Tasks = tk.Tasks{4: th.NewTestTask(4, "test_task_4", tk.Done)}
+			mockFs.LastID = 3
+
+			got, err := taskRepo.CreateTask(tk.CreateTaskParams{
+				Description: "test_task_6",
+			})
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got.ID, uint(6))
+			th.AssertDeepEqual(t, mockFs.LastID, uint(6))
+
+			_, err = taskRepo.UnarchiveTask(4)
+			th.AssertNoError(t, err)
+		})
+
+	t.Run("returns an error successfully", func(t *testing.T) {
+		mockFs, mockArchive, taskRepo := setupArchiveUnitTest(t)
+
+		_, err := taskRepo.UnarchiveTask(2)
+		th.AssertError(t, err, &tk.TaskNotFoundError{})
+
+		mockArchive.Tasks = tk.Tasks{2: mockFs.Tasks[2]}
+		_, err = taskRepo.UnarchiveTask(2)
+		th.AssertError(t, err, &tk.TaskConflictError{})
+	})
+}
+
+func Test_FileTaskRepository_AutoArchive(t *testing.T) {
+	t.Run("archives the done tasks on save successfully", func(t *testing.T) {
+		mockFs, mockArchive, taskRepo := setupArchiveUnitTest(t)
+		taskRepo.AutoArchive = &tk.ArchivePolicy{After: 0}
+
+		status := tk.Done
+		task, err := taskRepo.UpdateTask(tk.UpdateTaskParams{
+			ID:     1,
+			Status: &status,
+		})
+		th.AssertNoError(t, err)
+
+		if _, ok := mockFs.Tasks[1]; ok {
+			t.Errorf("got task 1 in the tasks, want it archived")
+		}
+		th.AssertDeepEqual(t, mockArchive.Tasks[1].CompletedAt, task.CompletedAt)
+
+		got, err := taskRepo.ReadAllTasks(tk.IncludeArchived)
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, got[1].ArchivedAt, &th.FixedTime)
+
+		id, err := taskRepo.ResolveID(th.NewTestUID(1), tk.IncludeArchived)
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, id, uint(1))
+	})
+
+	t.Run("archives a task done long ago but edited since successfully",
+		func(t *testing.T) {
+			mockFs, mockArchive, taskRepo := setupArchiveUnitTest(t)
+			taskRepo.AutoArchive = &tk.ArchivePolicy{After: 24 * time.Hour}
+			task := mockFs.Tasks[2]
+			task.CompletedAt = ptr(th.FixedTime)
+			mockFs.Tasks[2] = task
+			later := th.FixedTime.Add(48 * time.Hour)
+			taskRepo.TimeProvider = &th.StubTimeProvider{FixedTime: later}
+
+			description := "test_task_2_edited"
+			_, err := taskRepo.UpdateTask(tk.UpdateTaskParams{
+				ID:          2,
+				Description: &description,
+			})
+			th.AssertNoError(t, err)
+
+			if _, ok := mockFs.Tasks[2]; ok {
+				t.Errorf("got task 2 in the tasks, want it archived")
+			}
+			th.AssertDeepEqual(t, mockArchive.Tasks[2].Description, description)
+		})
+
+	t.Run("leaves the done tasks alone without a policy", func(t *testing.T) {
+		mockFs, mockArchive, taskRepo := setupArchiveUnitTest(t)
+
+		_, err := taskRepo.DeleteTask(3)
+		th.AssertNoError(t, err)
+
+		th.AssertDeepEqual(t, mockFs.Tasks[2].Status, tk.Done)
+		th.AssertDeepEqual(t, mockArchive.Calls, Calls(nil))
+	})
+}
+
+// setupArchiveUnitTest returns a repository with an empty archive, whose tasks
+// 2 and 3 are done. The archive file exists, so it's read from the mock.
+func setupArchiveUnitTest(t testing.TB) (
+	*MockFileStore[tk.TaskList],
+	*MockFileStore[tk.TaskList],
+	*tk.FileTaskRepository,
+) {
+	t.Helper()
+	mockFs, taskRepo := setupTaskUnitTest(t)
+	mockFs.Tasks = tk.Tasks{
+		1: th.NewTestTask(1, "test_task_1", tk.Todo),
+		2: th.NewTestTask(2, "test_task_2", tk.Done),
+		3: th.NewTestTask(3, "test_task_3", tk.Done),
+	}
+
+	mockArchive := &MockFileStore[tk.TaskList]{Tasks: tk.Tasks{}}
+	taskRepo.Archive = &tk.Archive{
+		Store:    mockArchive,
+		Filepath: taskRepo.Filepath() + ".archive",
+	}
+	err := os.WriteFile(taskRepo.Archive.Filepath, nil, 0644)
+	th.AssertNoError(t, err)
+	t.Cleanup(func() { os.Remove(taskRepo.Archive.Filepath) })
+	return mockFs, mockArchive, taskRepo
+}
@@ -0,0 +1,123 @@
+package task
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type DateError struct {
+	Value string
+}
+
+func (e *DateError) Error() string {
+	return fmt.Sprintf("invalid date %q, expected a date such as 2006-01-02, "+
+		"today, tomorrow, friday, next friday, in 3 days, eow or eom", e.Value)
+}
+
+// ParseDate reads a date relative to the time of clock, in its location. It
+// accepts:
+//
+//   - 2006-01-02, 2006-01-02T15:04 and RFC 3339 times
+//   - today (or eod), tomorrow and yesterday
+//   - a weekday such as friday or fri, the next one from today on, and next
+//     friday, the one after today
+//   - in N hours, days, weeks or months
+//   - eow, eom and eoy, the end of the week (Sunday), month and year
+//
+// A day without a time means the end of that day.
+func ParseDate(s string, clock TimeProvider) (time.Time, error) {
+	now := clock.Now()
+	today := endOfDay(now)
+
+	value := strings.TrimSpace(s)
+	loc := now.Location()
+	if t, err := time.ParseInLocation(time.DateOnly, value, loc); err == nil {
+		return endOfDay(t), nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	phrase := strings.Join(strings.Fields(strings.ToLower(value)), " ")
+
+	switch phrase {
+	case "today", "eod":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "eow":
+		return today.AddDate(0, 0, (7-int(now.Weekday()))%7), nil
+	case "eom":
+		return today.AddDate(0, 1, -today.Day()), nil
+	case "eoy":
+		return endOfDay(time.Date(now.Year(), 12, 31, 0, 0, 0, 0, loc)), nil
+	}
+
+	if name, ok := strings.CutPrefix(phrase, "next "); ok {
+		if weekday, ok := parseWeekday(name); ok {
+			days := (int(weekday)-int(now.Weekday())+6)%7 + 1
+			return today.AddDate(0, 0, days), nil
+		}
+	}
+	if weekday, ok := parseWeekday(phrase); ok {
+		days := (int(weekday) - int(now.Weekday()) + 7) % 7
+		return today.AddDate(0, 0, days), nil
+	}
+
+	if rest, ok := strings.CutPrefix(phrase, "in "); ok {
+		if t, ok := addPeriod(now, today, rest); ok {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, &DateError{Value: s}
+}
+
+// addPeriod adds a period such as "3 days" to now. Periods of days or longer
+// land on the end of a day.
+func addPeriod(now, today time.Time, period string) (time.Time, bool) {
+	count, unit, ok := strings.Cut(period, " ")
+	if !ok {
+		return time.Time{}, false
+	}
+	n, err := strconv.ParseUint(count, 10, 16)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	switch strings.TrimSuffix(unit, "s") {
+	case "hour":
+		return now.Add(time.Duration(n) * time.Hour), true
+	case "day":
+		return today.AddDate(0, 0, int(n)), true
+	case "week":
+		return today.AddDate(0, 0, 7*int(n)), true
+	case "month":
+		return today.AddDate(0, int(n), 0), true
+	}
+	return time.Time{}, false
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	if len(name) < 3 {
+		return 0, false
+	}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		full := strings.ToLower(day.String())
+		if name == full || name == full[:3] {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+func endOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 23, 59, 59, 0, t.Location())
+}
@@ -0,0 +1,91 @@
+package task_test
+
+import (
+	"testing"
+	"time"
+
+	tk "github.com/alnah/task-tracker/internal/task"
+	th "github.com/alnah/task-tracker/test_helpers"
+)
+
+func Test_DateError_Error(t *testing.T) {
+	t.Run("returns a string containing the value", func(t *testing.T) {
+		err := &tk.DateError{Value: "someday"}
+		th.AssertErrorMessage(t, err, err.Error(), "someday")
+	})
+}
+
+func Test_ParseDate(t *testing.T) {
+	// th.FixedTime is Monday 2006-01-02 15:04:05 UTC
+	clock := &th.StubTimeProvider{FixedTime: th.FixedTime}
+	day := func(month time.Month, day int) time.Time {
+		return time.Date(2006, month, day, 23, 59, 59, 0, time.UTC)
+	}
+
+	testCases := []struct {
+		name    string
+		s       string
+		want    time.Time
+		wantErr error
+	}{
+		{"happy: parses a day", "2006-01-10", day(1, 10), nil},
+		{"happy: parses a day and a time", "2006-01-10T09:30",
+			time.Date(2006, 1, 10, 9, 30, 0, 0, time.UTC), nil},
+		{"happy: parses an RFC 3339 time", "2006-01-10T09:30:00+02:00",
+			time.Date(2006, 1, 10, 7, 30, 0, 0, time.UTC), nil},
+		{"happy: parses today", "today", day(1, 2), nil},
+		{"happy: parses eod", "eod", day(1, 2), nil},
+		{"happy: parses tomorrow", "tomorrow", day(1, 3), nil},
+		{"happy: parses yesterday", "yesterday", day(1, 1), nil},
+		{"happy: parses a weekday", "friday", day(1, 6), nil},
+		{"happy: parses a short weekday", "fri", day(1, 6), nil},
+		{"happy: parses next weekday", "next friday", day(1, 6), nil},
+		{"happy: parses days", "in 3 days", day(1, 5), nil},
+		{"happy: parses a week", "in 1 week", day(1, 9), nil},
+		{"happy: parses months", "in 2 months", day(3, 2), nil},
+		{"happy: parses hours", "in 5 hours",
+			th.FixedTime.Add(5 * time.Hour), nil},
+		{"happy: parses eow", "eow", day(1, 8), nil},
+		{"happy: parses eom", "eom", day(1, 31), nil},
+		{"happy: parses eoy", "eoy", day(12, 31), nil},
+		{"sad: rejects an unknown phrase", "someday", time.Time{},
+			&tk.DateError{}},
+		{"sad: rejects a count in words", "in three days", time.Time{},
+			&tk.DateError{}},
+		{"sad: rejects an unknown unit", "in 3 fortnights", time.Time{},
+			&tk.DateError{}},
+		{"sad: rejects an invalid day", "2006-13-01", time.Time{},
+			&tk.DateError{}},
+		{"edge: reads today's weekday as today", "monday", day(1, 2), nil},
+		{"edge: reads next weekday as the one after today", "next monday",
+			day(1, 9), nil},
+		{"edge: ignores case and spaces", "  In 3   DAYS ", day(1, 5), nil},
+		{"edge: rejects a weekday shorter than 3 letters", "mo", time.Time{},
+			&tk.DateError{}},
+		{"edge: rejects an empty string", "", time.Time{}, &tk.DateError{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := tk.ParseDate(tc.s, clock)
+			if tc.wantErr != nil {
+				th.AssertError(t, err, tc.wantErr)
+				return
+			}
+			th.AssertNoError(t, err)
+			if !got.Equal(tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	t.Run("resolves days in the location of the clock", func(t *testing.T) {
+		tokyo := time.FixedZone("JST", 9*60*60)
+		clock := &th.StubTimeProvider{FixedTime: th.FixedTime.In(tokyo)}
+
+		got, err := tk.ParseDate("today", clock)
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, got, time.Date(2006, 1, 3, 23, 59, 59, 0, tokyo))
+	})
+}
@@ -0,0 +1,27 @@
+package task
+
+import "time"
+
+// ReadDueTasks returns the tasks that aren't in a final status of the
+// workflow and are due before within from now. Within 0 returns the overdue
+// tasks.
+func (tr *FileTaskRepository) ReadDueTasks(
+	within time.Duration,
+) (Tasks, error) {
+	tasks, err := tr.ReadAllTasks()
+	if err != nil {
+		return Tasks{}, err
+	}
+
+	deadline := tr.TimeProvider.Now().Add(within)
+	due := Tasks{}
+	for id, task := range tasks {
+		if tr.Workflow.Final(task.Status) || task.Due == nil {
+			continue
+		}
+		if task.Due.Before(deadline) {
+			due[id] = task
+		}
+	}
+	return due, nil
+}
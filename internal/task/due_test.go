@@ -0,0 +1,91 @@
+package task_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	tk "github.com/alnah/task-tracker/internal/task"
+	th "github.com/alnah/task-tracker/test_helpers"
+)
+
+func Test_FileTaskRepository_ReadDueTasks(t *testing.T) {
+	setup := func(t *testing.T) *tk.FileTaskRepository {
+		mockFs, taskRepo := setupTaskUnitTest(t)
+		due := func(id uint, status tk.Status, in time.Duration) {
+			task := th.NewTestTask(id, "test_task", status)
+			task.Due = ptr(th.FixedTime.Add(in))
+			mockFs.Tasks[id] = task
+		}
+		mockFs.Tasks = tk.Tasks{
+			1: th.NewTestTask(1, "test_task_1", tk.Todo),
+		}
+		due(2, tk.Todo, -time.Hour)
+		due(3, tk.InProgress, -48*time.Hour)
+		due(4, tk.Done, -time.Hour)
+		due(5, tk.Todo, 0)
+		due(6, tk.Todo, 24*time.Hour)
+		due(7, tk.Todo, 72*time.Hour)
+		return taskRepo
+	}
+
+	testCases := []struct {
+		name   string
+		within time.Duration
+		want   []uint
+	}{
+		{"happy: returns the overdue tasks", 0, []uint{2, 3}},
+		{"happy: returns the tasks due within 48h", 48 * time.Hour,
+			[]uint{2, 3, 5, 6}},
+		{"edge: leaves out a task due exactly at the deadline", 24 * time.Hour,
+			[]uint{2, 3, 5}},
+		{"edge: never returns done tasks or tasks without due dates",
+			365 * 24 * time.Hour, []uint{2, 3, 5, 6, 7}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			taskRepo := setup(t)
+
+			tasks, err := taskRepo.ReadDueTasks(tc.within)
+			th.AssertNoError(t, err)
+
+			var got []uint
+			for _, id := range []uint{1, 2, 3, 4, 5, 6, 7} {
+				if _, ok := tasks[id]; ok {
+					got = append(got, id)
+				}
+			}
+			th.AssertDeepEqual(t, got, tc.want)
+		})
+	}
+
+	t.Run("leaves out the tasks in a final custom status successfully",
+		func(t *testing.T) {
+			mockFs, taskRepo := setupTaskUnitTest(t)
+			taskRepo.Workflow = &tk.Workflow{
+				Statuses: []tk.Status{"open", "closed"},
+				Transitions: []tk.Transition{
+					{Name: "close", From: []tk.Status{"open"}, To: "closed"},
+				},
+			}
+			for id, status := range map[uint]tk.Status{1: "open", 2: "closed"} {
+				task := th.NewTestTask(id, "test_task", status)
+				task.Due = ptr(th.FixedTime.Add(-time.Hour))
+				mockFs.Tasks[id] = task
+			}
+
+			got, err := taskRepo.ReadDueTasks(0)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got, tk.Tasks{1: mockFs.Tasks[1]})
+		})
+
+	t.Run("sad: returns an error when loading fails", func(t *testing.T) {
+		mockFs, taskRepo := setupTaskUnitTest(t)
+		mockFs.LoadError = &os.PathError{}
+
+		_, err := taskRepo.ReadDueTasks(0)
+		th.AssertError(t, err, &os.PathError{})
+	})
+}
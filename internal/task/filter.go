@@ -0,0 +1,322 @@
+package task
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter selects tasks, see TaskFilter and CompileQuery.
+type Filter interface {
+	Match(Task) bool
+}
+
+// FilterFunc is a function used as a Filter.
+type FilterFunc func(Task) bool
+
+func (f FilterFunc) Match(task Task) bool {
+	return f(task)
+}
+
+// AllOf matches the tasks that every filter matches.
+func AllOf(filters ...Filter) Filter {
+	return FilterFunc(func(task Task) bool {
+		for _, filter := range filters {
+			if !filter.Match(task) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// CompileQuery parses a query, see ParseQuery, and checks its fields and
+// values. Dates are resolved against clock, see ParseDate, and statuses are
+// checked against workflow unless it's nil. The fields are:
+//
+//   - status and tag, with : (or =) and !=
+//   - priority and id, compared with :, !=, <, <=, > and >=
+//   - desc, with : for a case-insensitive substring, = and != for the whole
+//     description, and ~ for a regular expression
+//   - due, created, updated and completed, compared with dates such as
+//     friday, : meaning the same day; due.before:friday and due.after:friday
+//     are short for due<friday and due>friday, and due:none matches the tasks
+//     without a due date
+func CompileQuery(
+	query string,
+	clock TimeProvider,
+	workflow *Workflow,
+) (Filter, error) {
+	node, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &queryCompiler{query: query, clock: clock, workflow: workflow}
+	return c.compile(node)
+}
+
+type queryCompiler struct {
+	query    string
+	clock    TimeProvider
+	workflow *Workflow
+}
+
+func (c *queryCompiler) errorAt(pos int, format string, args ...any) error {
+	return &QueryError{c.query, pos, fmt.Sprintf(format, args...)}
+}
+
+func (c *queryCompiler) compile(node QueryNode) (FilterFunc, error) {
+	switch node := node.(type) {
+	case nil:
+		return func(Task) bool { return true }, nil
+	case *AndNode:
+		left, right, err := c.compilePair(node.Left, node.Right)
+		if err != nil {
+			return nil, err
+		}
+		return func(t Task) bool { return left(t) && right(t) }, nil
+	case *OrNode:
+		left, right, err := c.compilePair(node.Left, node.Right)
+		if err != nil {
+			return nil, err
+		}
+		return func(t Task) bool { return left(t) || right(t) }, nil
+	case *NotNode:
+		operand, err := c.compile(node.Operand)
+		if err != nil {
+			return nil, err
+		}
+		return func(t Task) bool { return !operand(t) }, nil
+	case *TermNode:
+		return c.compileTerm(node)
+	default:
+		return nil, fmt.Errorf("unknown query node %T", node)
+	}
+}
+
+func (c *queryCompiler) compilePair(
+	left, right QueryNode,
+) (FilterFunc, FilterFunc, error) {
+	l, err := c.compile(left)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := c.compile(right)
+	if err != nil {
+		return nil, nil, err
+	}
+	return l, r, nil
+}
+
+var (
+	equalityOps   = []string{":", "=", "!="}
+	comparisonOps = []string{":", "=", "!=", "<", "<=", ">", ">="}
+	textOps       = []string{":", "=", "!=", "~"}
+)
+
+var dateFields = map[string]func(Task) *time.Time{
+	"due":       func(t Task) *time.Time { return t.Due },
+	"created":   func(t Task) *time.Time { return &t.CreatedAt },
+	"updated":   func(t Task) *time.Time { return &t.UpdatedAt },
+	"completed": func(t Task) *time.Time { return t.CompletedAt },
+}
+
+func (c *queryCompiler) compileTerm(term *TermNode) (FilterFunc, error) {
+	if _, ok := dateFields[term.Field]; ok {
+		return c.compileDate(term)
+	}
+	if term.Modifier != "" {
+		return nil, c.errorAt(term.Pos, "unknown field %q",
+			term.Field+"."+term.Modifier)
+	}
+
+	switch term.Field {
+	case "status":
+		return c.compileStatus(term)
+	case "tag":
+		return c.compileTag(term)
+	case "priority":
+		return c.compilePriority(term)
+	case "id":
+		return c.compileID(term)
+	case "desc", "description":
+		return c.compileDescription(term)
+	default:
+		return nil, c.errorAt(term.Pos, "unknown field %q, expected one of "+
+			"status, tag, priority, id, desc, due, created, updated, completed",
+			term.Field)
+	}
+}
+
+func (c *queryCompiler) checkOp(term *TermNode, ops []string) error {
+	for _, op := range ops {
+		if op == term.Op {
+			return nil
+		}
+	}
+	return c.errorAt(term.OpPos, "%s doesn't take %s, expected one of %s",
+		term.Field, term.Op, strings.Join(ops, " "))
+}
+
+func (c *queryCompiler) compileStatus(term *TermNode) (FilterFunc, error) {
+	if err := c.checkOp(term, equalityOps); err != nil {
+		return nil, err
+	}
+	status := Status(term.Value)
+	if c.workflow != nil && !c.workflow.Has(status) {
+		return nil, c.errorAt(term.ValuePos,
+			"unknown status %q, expected one of %s",
+			term.Value, joinStatuses(c.workflow.Statuses))
+	}
+	return negate(term.Op, func(t Task) bool { return t.Status == status }), nil
+}
+
+func (c *queryCompiler) compileTag(term *TermNode) (FilterFunc, error) {
+	if err := c.checkOp(term, equalityOps); err != nil {
+		return nil, err
+	}
+	if err := validateTags([]string{term.Value}); err != nil {
+		return nil, c.errorAt(term.ValuePos, "%s", err)
+	}
+	return negate(term.Op, func(t Task) bool {
+		return hasTag(t.Tags, term.Value)
+	}), nil
+}
+
+func (c *queryCompiler) compilePriority(term *TermNode) (FilterFunc, error) {
+	if err := c.checkOp(term, comparisonOps); err != nil {
+		return nil, err
+	}
+	priority, err := ParsePriority(term.Value)
+	if err != nil {
+		return nil, c.errorAt(term.ValuePos, "%s", err)
+	}
+	return func(t Task) bool {
+		return compareWith(term.Op, cmp.Compare(t.Priority, priority))
+	}, nil
+}
+
+func (c *queryCompiler) compileID(term *TermNode) (FilterFunc, error) {
+	if err := c.checkOp(term, comparisonOps); err != nil {
+		return nil, err
+	}
+	id, err := strconv.ParseUint(term.Value, 10, 0)
+	if err != nil {
+		return nil, c.errorAt(term.ValuePos,
+			"expected a numeric task ID, but got %q", term.Value)
+	}
+	return func(t Task) bool {
+		return compareWith(term.Op, cmp.Compare(uint64(t.ID), id))
+	}, nil
+}
+
+func (c *queryCompiler) compileDescription(term *TermNode) (FilterFunc, error) {
+	if err := c.checkOp(term, textOps); err != nil {
+		return nil, err
+	}
+
+	switch term.Op {
+	case ":":
+		value := strings.ToLower(term.Value)
+		return func(t Task) bool {
+			return strings.Contains(strings.ToLower(t.Description), value)
+		}, nil
+	case "~":
+		pattern, err := regexp.Compile(term.Value)
+		if err != nil {
+			return nil, c.errorAt(term.ValuePos,
+				"invalid regular expression: %s", err)
+		}
+		return func(t Task) bool {
+			return pattern.MatchString(t.Description)
+		}, nil
+	}
+	return negate(term.Op, func(t Task) bool {
+		return t.Description == term.Value
+	}), nil
+}
+
+func (c *queryCompiler) compileDate(term *TermNode) (FilterFunc, error) {
+	field := dateFields[term.Field]
+	op := term.Op
+
+	switch term.Modifier {
+	case "":
+		if err := c.checkOp(term, comparisonOps); err != nil {
+			return nil, err
+		}
+	case "before", "after":
+		if op != ":" && op != "=" {
+			return nil, c.errorAt(term.OpPos, "%s.%s doesn't take %s, "+
+				"expected : or =", term.Field, term.Modifier, op)
+		}
+		op = map[string]string{"before": "<", "after": ">"}[term.Modifier]
+	default:
+		return nil, c.errorAt(term.Pos, "unknown field %q, expected %s, "+
+			"%s.before or %s.after", term.Field+"."+term.Modifier,
+			term.Field, term.Field, term.Field)
+	}
+
+	if strings.EqualFold(term.Value, "none") {
+		if op != ":" && op != "=" && op != "!=" {
+			return nil, c.errorAt(term.OpPos,
+				"%s can only be compared to none with : or !=", term.Field)
+		}
+		return negate(op, func(t Task) bool { return field(t) == nil }), nil
+	}
+
+	date, err := ParseDate(term.Value, c.clock)
+	if err != nil {
+		return nil, c.errorAt(term.ValuePos, "%s", err)
+	}
+
+	if op == ":" || op == "=" || op == "!=" {
+		return negate(op, func(t Task) bool {
+			value := field(t)
+			return value != nil && sameDay(*value, date)
+		}), nil
+	}
+	return func(t Task) bool {
+		value := field(t)
+		return value != nil && compareWith(op, value.Compare(date))
+	}, nil
+}
+
+// negate turns a match for : or = into one for !=.
+func negate(op string, match FilterFunc) FilterFunc {
+	if op != "!=" {
+		return match
+	}
+	return func(t Task) bool { return !match(t) }
+}
+
+// compareWith reports whether a comparison result, negative, zero or
+// positive, satisfies op.
+func compareWith(op string, result int) bool {
+	switch op {
+	case ":", "=":
+		return result == 0
+	case "!=":
+		return result != 0
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	case ">":
+		return result > 0
+	case ">=":
+		return result >= 0
+	}
+	return false
+}
+
+// sameDay reports whether t falls on the day of date, in the location of date.
+func sameDay(t, date time.Time) bool {
+	y1, m1, d1 := t.In(date.Location()).Date()
+	y2, m2, d2 := date.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2
+}
@@ -0,0 +1,157 @@
+package task_test
+
+import (
+	"testing"
+	"time"
+
+	tk "github.com/alnah/task-tracker/internal/task"
+	th "github.com/alnah/task-tracker/test_helpers"
+)
+
+func Test_CompileQuery_Happy(t *testing.T) {
+	// th.FixedTime is Monday 2006-01-02 15:04:05 UTC
+	clock := &th.StubTimeProvider{FixedTime: th.FixedTime}
+	tasks := tk.Tasks{}
+	for id, change := range map[uint]func(*tk.Task){
+		1: func(t *tk.Task) {
+			t.Description = "Fix the login page"
+			t.Tags = []string{"backend", "web"}
+			t.Priority = tk.PriorityHigh
+			t.Due = ptr(th.FixedTime.Add(72 * time.Hour))
+		},
+		2: func(t *tk.Task) {
+			t.Description = "Write the guide"
+			t.Tags = []string{"docs"}
+			t.Status = tk.Done
+		},
+		3: func(t *tk.Task) {
+			t.Description = "Rotate the keys"
+			t.Tags = []string{"ops"}
+			t.Priority = tk.PriorityCritical
+			t.Due = ptr(th.FixedTime.Add(-time.Hour))
+			t.Status = tk.InProgress
+		},
+		4: func(t *tk.Task) {
+			t.Description = "login audit"
+			t.Priority = tk.PriorityLow
+		},
+	} {
+		task := th.NewTestTask(id, "", tk.Todo)
+		change(&task)
+		tasks[id] = task
+	}
+
+	testCases := []struct {
+		name  string
+		query string
+		want  []uint
+	}{
+		{"matches everything with an empty query", "", []uint{1, 2, 3, 4}},
+		{"matches a status", "status:todo", []uint{1, 4}},
+		{"matches a bare status", "done", []uint{2}},
+		{"matches another status", "status!=todo", []uint{2, 3}},
+		{"matches a tag", "tag:backend", []uint{1}},
+		{"matches a missing tag", "tag!=backend", []uint{2, 3, 4}},
+		{"matches a minimum priority", "priority>=high", []uint{1, 3}},
+		{"matches a priority", "priority:low", []uint{4}},
+		{"matches below a priority", "priority<medium", []uint{2, 4}},
+		{"matches an ID", "id:3", []uint{3}},
+		{"matches IDs above", "id>2", []uint{3, 4}},
+		{"matches a substring of the description", "desc:LOGIN",
+			[]uint{1, 4}},
+		{"matches a whole description", `desc="Write the guide"`, []uint{2}},
+		{"matches a regular expression", `desc~"^[A-Z].* the "`,
+			[]uint{1, 2, 3}},
+		{"matches due dates before a day", "due.before:friday", []uint{1, 3}},
+		{"matches due dates after a day", "due>today", []uint{1}},
+		{"matches a due day", "due:thursday", []uint{1}},
+		{"matches no due date", "due:none", []uint{2, 4}},
+		{"matches a due date", "due!=none", []uint{1, 3}},
+		{"matches a creation day", "created:today", []uint{1, 2, 3, 4}},
+		{"combines with AND, OR and NOT",
+			"(tag:backend OR tag:ops) AND NOT priority:critical", []uint{1}},
+		{"matches ignoring the case of fields and keywords",
+			"TAG:docs or Status:in-progress", []uint{2, 3}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			filter, err := tk.CompileQuery(tc.query, clock, tk.DefaultWorkflow())
+			th.AssertNoError(t, err)
+
+			var got []uint
+			for _, id := range []uint{1, 2, 3, 4} {
+				if filter.Match(tasks[id]) {
+					got = append(got, id)
+				}
+			}
+			th.AssertDeepEqual(t, got, tc.want)
+		})
+	}
+}
+
+func Test_CompileQuery_Sad(t *testing.T) {
+	clock := &th.StubTimeProvider{FixedTime: th.FixedTime}
+
+	testCases := []struct {
+		name    string
+		query   string
+		wantPos int
+		wantMsg string
+	}{
+		{"rejects an unknown field", "todo colour:red", 5, "unknown field"},
+		{"rejects an unknown modifier", "due.during:friday", 0,
+			"due.before or due.after"},
+		{"rejects a modifier on a field without any", "tag.any:x", 0,
+			"unknown field"},
+		{"rejects an operator the field doesn't take", "tag>=x", 3,
+			"tag doesn't take >="},
+		{"rejects a comparison of a modifier", "due.before>friday", 10,
+			"expected : or ="},
+		{"rejects an unknown status", "status:blocked", 7, "todo, in-progress"},
+		{"rejects an invalid tag", "tag:Docs", 4, "invalid tag"},
+		{"rejects an unknown priority", "priority>=urgent", 10,
+			"invalid priority"},
+		{"rejects a non-numeric ID", "id:one", 3, "numeric task ID"},
+		{"rejects an invalid regular expression", `desc~"("`, 5,
+			"regular expression"},
+		{"rejects an invalid date", `due.before:"some day"`, 11,
+			"invalid date"},
+		{"rejects none in a comparison", "due<none", 3, "none"},
+		{"reports the error of a nested term", "NOT (todo OR tag:X)", 17,
+			"invalid tag"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := tk.CompileQuery(tc.query, clock, tk.DefaultWorkflow())
+			th.AssertError(t, err, &tk.QueryError{})
+			th.AssertErrorMessage(t, err, err.Error(), tc.wantMsg)
+			th.AssertDeepEqual(t, err.(*tk.QueryError).Pos, tc.wantPos)
+		})
+	}
+
+	t.Run("accepts any status without a workflow", func(t *testing.T) {
+		_, err := tk.CompileQuery("status:blocked", clock, nil)
+		th.AssertNoError(t, err)
+	})
+}
+
+func Test_FileTaskRepository_ReadManyTasks_Query(t *testing.T) {
+	t.Run("returns the tasks a compiled query matches successfully",
+		func(t *testing.T) {
+			_, taskRepo := setupTaskUnitTest(t)
+			filter, err := tk.CompileQuery("id<=3 NOT id:2", taskRepo.TimeProvider,
+				taskRepo.Workflow)
+			th.AssertNoError(t, err)
+
+			got, err := taskRepo.ReadManyTasks(tk.AllOf(filter,
+				tk.TaskFilter{Status: tk.Todo}))
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, len(got), 2)
+			th.AssertDeepEqual(t, got[1].ID, uint(1))
+			th.AssertDeepEqual(t, got[3].ID, uint(3))
+		})
+}
@@ -0,0 +1,211 @@
+package task
+
+import (
+	"cmp"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GroupFields are the fields tasks can be grouped by. A task with several
+// tags is in the group of each tag, and a task without tags in the group of
+// the empty tag.
+var GroupFields = []string{"status", "priority", "tag"}
+
+// GroupTasksParams selects, groups and orders tasks. Groups are ordered by
+// each field of By in turn: statuses in workflow order, the most urgent
+// priority first, and tags by name with the empty tag last. The tasks of a
+// group are sorted as ListTasks sorts them.
+type GroupTasksParams struct {
+	By     []string
+	Filter Filter
+	Sort   []SortKey
+}
+
+// Group is a group of tasks and its aggregates. Key holds the value of each
+// grouping field, such as {"status": "todo", "tag": "backend"}.
+type Group struct {
+	Key           map[string]string
+	Count         int
+	OldestCreated time.Time
+	NewestUpdated time.Time
+	Tasks         []Task
+}
+
+type GroupKeyError struct {
+	Key string
+}
+
+func (e *GroupKeyError) Error() string {
+	return fmt.Sprintf("invalid group key %q, expected one of %s", e.Key,
+		strings.Join(GroupFields, ", "))
+}
+
+// ParseGroupBy reads grouping fields separated by commas, such as
+// tag,priority.
+func ParseGroupBy(s string) ([]string, error) {
+	var fields []string
+	for _, part := range strings.Split(s, ",") {
+		field := strings.TrimSpace(part)
+		if !hasGroupField(field) {
+			return nil, &GroupKeyError{Key: part}
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// GroupTasks returns the groups of the tasks the filter matches, see
+// GroupTasksParams, ordering the statuses as workflow does.
+func GroupTasks(
+	tasks Tasks,
+	params GroupTasksParams,
+	workflow *Workflow,
+) ([]Group, error) {
+	if len(params.By) == 0 {
+		return nil, &GroupKeyError{}
+	}
+	for _, field := range params.By {
+		if !hasGroupField(field) {
+			return nil, &GroupKeyError{Key: field}
+		}
+	}
+
+	page, err := PageTasks(tasks, ListTasksParams{
+		Filter: params.Filter,
+		Sort:   params.Sort,
+	}, workflow)
+	if err != nil {
+		return nil, err
+	}
+
+	g := grouper{by: params.By, sorter: newTaskSorter(nil, workflow)}
+	byKey := map[string]*groupEntry{}
+	var entries []*groupEntry
+	for _, task := range page.Tasks {
+		for _, values := range g.keys(task) {
+			id := groupID(values)
+			entry, ok := byKey[id]
+			if !ok {
+				entry = &groupEntry{values: values}
+				byKey[id] = entry
+				entries = append(entries, entry)
+			}
+			entry.tasks = append(entry.tasks, task)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return compareGroupValues(entries[i].values, entries[j].values) < 0
+	})
+
+	groups := make([]Group, 0, len(entries))
+	for _, entry := range entries {
+		groups = append(groups, entry.group(params.By))
+	}
+	return groups, nil
+}
+
+// groupValue is the value of a task for a grouping field, and its rank in
+// the order of the groups.
+type groupValue struct {
+	name string
+	rank int
+}
+
+type groupEntry struct {
+	values []groupValue
+	tasks  []Task
+}
+
+func (e *groupEntry) group(by []string) Group {
+	group := Group{
+		Key:           make(map[string]string, len(by)),
+		Count:         len(e.tasks),
+		OldestCreated: e.tasks[0].CreatedAt,
+		NewestUpdated: e.tasks[0].UpdatedAt,
+		Tasks:         e.tasks,
+	}
+	for i, field := range by {
+		group.Key[field] = e.values[i].name
+	}
+	for _, task := range e.tasks {
+		if task.CreatedAt.Before(group.OldestCreated) {
+			group.OldestCreated = task.CreatedAt
+		}
+		if task.UpdatedAt.After(group.NewestUpdated) {
+			group.NewestUpdated = task.UpdatedAt
+		}
+	}
+	return group
+}
+
+type grouper struct {
+	by     []string
+	sorter taskSorter
+}
+
+// keys returns the values of the groups a task is in, one per grouping field.
+func (g grouper) keys(task Task) [][]groupValue {
+	keys := [][]groupValue{nil}
+	for _, field := range g.by {
+		values := g.values(field, task)
+		next := make([][]groupValue, 0, len(keys)*len(values))
+		for _, key := range keys {
+			for _, value := range values {
+				next = append(next, append(key[:len(key):len(key)], value))
+			}
+		}
+		keys = next
+	}
+	return keys
+}
+
+func (g grouper) values(field string, task Task) []groupValue {
+	switch field {
+	case "status":
+		status := task.Status
+		return []groupValue{{string(status), g.sorter.statusRank(status)}}
+	case "priority":
+		return []groupValue{{task.Priority.String(), -int(task.Priority)}}
+	default:
+		if len(task.Tags) == 0 {
+			return []groupValue{{"", 1}}
+		}
+		values := make([]groupValue, 0, len(task.Tags))
+		for _, tag := range task.Tags {
+			values = append(values, groupValue{tag, 0})
+		}
+		return values
+	}
+}
+
+func compareGroupValues(a, b []groupValue) int {
+	for i := range a {
+		if c := cmp.Compare(a[i].rank, b[i].rank); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a[i].name, b[i].name); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func groupID(values []groupValue) string {
+	names := make([]string, 0, len(values))
+	for _, value := range values {
+		names = append(names, value.name)
+	}
+	return strings.Join(names, "\x00")
+}
+
+func hasGroupField(field string) bool {
+	for _, f := range GroupFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
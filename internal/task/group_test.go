@@ -0,0 +1,185 @@
+package task_test
+
+import (
+	"testing"
+	"time"
+
+	tk "github.com/alnah/task-tracker/internal/task"
+	th "github.com/alnah/task-tracker/test_helpers"
+)
+
+func Test_GroupKeyError_Error(t *testing.T) {
+	t.Run("returns a string containing the key", func(t *testing.T) {
+		err := &tk.GroupKeyError{Key: "size"}
+		th.AssertErrorMessage(t, err, err.Error(), "size")
+	})
+}
+
+func Test_ParseGroupBy(t *testing.T) {
+	testCases := []struct {
+		name    string
+		s       string
+		want    []string
+		wantErr error
+	}{
+		{"happy: parses a field", "status", []string{"status"}, nil},
+		{"happy: parses fields", "tag, priority", []string{"tag", "priority"},
+			nil},
+		{"sad: rejects an unknown field", "due", nil, &tk.GroupKeyError{}},
+		{"edge: rejects an empty field", "tag,", nil, &tk.GroupKeyError{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := tk.ParseGroupBy(tc.s)
+			if tc.wantErr != nil {
+				th.AssertError(t, err, tc.wantErr)
+				return
+			}
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got, tc.want)
+		})
+	}
+}
+
+func Test_GroupTasks(t *testing.T) {
+	at := func(hours int) time.Time {
+		return th.FixedTime.Add(time.Duration(hours) * time.Hour)
+	}
+	tasks := tk.Tasks{}
+	for _, task := range []struct {
+		id       uint
+		status   tk.Status
+		priority tk.Priority
+		tags     []string
+		created  int
+		updated  int
+	}{
+		{1, tk.Done, tk.PriorityLow, []string{"ops"}, 1, 8},
+		{2, tk.Todo, tk.PriorityHigh, []string{"backend", "ops"}, 2, 3},
+		{3, tk.InProgress, tk.PriorityHigh, nil, 3, 4},
+		{4, tk.Todo, tk.PriorityNone, []string{"backend"}, 4, 9},
+	} {
+		tasks[task.id] = tk.Task{
+			ID:        task.id,
+			Status:    task.status,
+			Priority:  task.priority,
+			Tags:      task.tags,
+			CreatedAt: at(task.created),
+			UpdatedAt: at(task.updated),
+		}
+	}
+
+	type group struct {
+		key map[string]string
+		ids []uint
+	}
+	testCases := []struct {
+		name   string
+		params tk.GroupTasksParams
+		want   []group
+	}{
+		{"groups statuses in workflow order",
+			tk.GroupTasksParams{By: []string{"status"}}, []group{
+				{map[string]string{"status": "todo"}, []uint{2, 4}},
+				{map[string]string{"status": "in-progress"}, []uint{3}},
+				{map[string]string{"status": "done"}, []uint{1}},
+			}},
+		{"groups the most urgent priority first",
+			tk.GroupTasksParams{By: []string{"priority"}}, []group{
+				{map[string]string{"priority": "high"}, []uint{2, 3}},
+				{map[string]string{"priority": "low"}, []uint{1}},
+				{map[string]string{"priority": "none"}, []uint{4}},
+			}},
+		{"groups a task in each of its tags, untagged last",
+			tk.GroupTasksParams{By: []string{"tag"}}, []group{
+				{map[string]string{"tag": "backend"}, []uint{2, 4}},
+				{map[string]string{"tag": "ops"}, []uint{1, 2}},
+				{map[string]string{"tag": ""}, []uint{3}},
+			}},
+		{"groups by several fields", tk.GroupTasksParams{
+			By: []string{"tag", "priority"},
+		}, []group{
+			{map[string]string{"tag": "backend", "priority": "high"}, []uint{2}},
+			{map[string]string{"tag": "backend", "priority": "none"}, []uint{4}},
+			{map[string]string{"tag": "ops", "priority": "high"}, []uint{2}},
+			{map[string]string{"tag": "ops", "priority": "low"}, []uint{1}},
+			{map[string]string{"tag": "", "priority": "high"}, []uint{3}},
+		}},
+		{"filters and sorts the tasks of a group", tk.GroupTasksParams{
+			By: []string{"tag"},
+			Filter: tk.FilterFunc(func(t tk.Task) bool {
+				return t.Status != tk.Done
+			}),
+			Sort: []tk.SortKey{{Field: "id", Desc: true}},
+		}, []group{
+			{map[string]string{"tag": "backend"}, []uint{4, 2}},
+			{map[string]string{"tag": "ops"}, []uint{2}},
+			{map[string]string{"tag": ""}, []uint{3}},
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			groups, err := tk.GroupTasks(tasks, tc.params, tk.DefaultWorkflow())
+			th.AssertNoError(t, err)
+
+			var got []group
+			for _, g := range groups {
+				var ids []uint
+				for _, task := range g.Tasks {
+					ids = append(ids, task.ID)
+				}
+				th.AssertDeepEqual(t, g.Count, len(ids))
+				got = append(got, group{key: g.Key, ids: ids})
+			}
+			th.AssertDeepEqual(t, got, tc.want)
+		})
+	}
+
+	t.Run("aggregates each group successfully", func(t *testing.T) {
+		groups, err := tk.GroupTasks(tasks, tk.GroupTasksParams{
+			By: []string{"tag"},
+		}, tk.DefaultWorkflow())
+		th.AssertNoError(t, err)
+
+		th.AssertDeepEqual(t, groups[1].Key, map[string]string{"tag": "ops"})
+		th.AssertDeepEqual(t, groups[1].Count, 2)
+		th.AssertDeepEqual(t, groups[1].OldestCreated, at(1))
+		th.AssertDeepEqual(t, groups[1].NewestUpdated, at(8))
+	})
+
+	t.Run("returns no groups without tasks successfully", func(t *testing.T) {
+		groups, err := tk.GroupTasks(tk.Tasks{}, tk.GroupTasksParams{
+			By: []string{"status"},
+		}, tk.DefaultWorkflow())
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, len(groups), 0)
+	})
+
+	t.Run("returns an error successfully", func(t *testing.T) {
+		testCases := []struct {
+			name    string
+			params  tk.GroupTasksParams
+			wantErr error
+		}{
+			{"rejects no field", tk.GroupTasksParams{}, &tk.GroupKeyError{}},
+			{"rejects an unknown field",
+				tk.GroupTasksParams{By: []string{"due"}}, &tk.GroupKeyError{}},
+			{"rejects an unknown sort key", tk.GroupTasksParams{
+				By:   []string{"status"},
+				Sort: []tk.SortKey{{Field: "size"}},
+			}, &tk.SortKeyError{}},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+				_, err := tk.GroupTasks(tasks, tc.params, tk.DefaultWorkflow())
+				th.AssertError(t, err, tc.wantErr)
+			})
+		}
+	})
+}
@@ -0,0 +1,146 @@
+package task
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// IssueCode identifies a kind of integrity issue. Codes are stable, so scripts
+// can match on them.
+type IssueCode string
+
+const (
+	IDMismatch           IssueCode = "id-mismatch"
+	InvalidStatus        IssueCode = "invalid-status"
+	UpdatedBeforeCreated IssueCode = "updated-before-created"
+	EmptyDescription     IssueCode = "empty-description"
+	LongDescription      IssueCode = "long-description"
+)
+
+// Issue is an integrity violation found on the task stored under Key. Fixable
+// issues have a safe normalisation; Fixed tells whether it was applied.
+type Issue struct {
+	Code    IssueCode
+	Key     uint
+	Message string
+	Fixable bool
+	Fixed   bool
+}
+
+type IntegrityError struct {
+	Issues []Issue
+}
+
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf("found %d integrity issues", len(e.Issues))
+}
+
+// CheckTasks returns the integrity issues of tasks, ordered by key and code.
+func CheckTasks(tasks Tasks, workflow *Workflow) []Issue {
+	return checkTasks(tasks, workflow, false)
+}
+
+// FixTasks normalises, in place, the issues of tasks that can be fixed without
+// losing data, and returns every issue found.
+func FixTasks(tasks Tasks, workflow *Workflow) []Issue {
+	return checkTasks(tasks, workflow, true)
+}
+
+func checkTasks(tasks Tasks, workflow *Workflow, fix bool) []Issue {
+	var issues []Issue
+	for key, task := range tasks {
+		for _, issue := range checkTask(key, &task, workflow) {
+			if fix && issue.Fixable {
+				issue.Fixed = true
+				tasks[key] = task
+			}
+			issues = append(issues, issue)
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].Key != issues[j].Key {
+			return issues[i].Key < issues[j].Key
+		}
+		return issues[i].Code < issues[j].Code
+	})
+
+	return issues
+}
+
+// checkTask returns the issues of the task stored under key, and normalises
+// the task for the fixable ones, so the caller keeps it only when fixing.
+func checkTask(key uint, task *Task, workflow *Workflow) []Issue {
+	var issues []Issue
+
+	if task.ID != key {
+		issues = append(issues, Issue{
+			Code: IDMismatch,
+			Key:  key,
+			Message: fmt.Sprintf(
+				"task ID is %d, but it's stored under %d", task.ID, key,
+			),
+			Fixable: true,
+		})
+		task.ID = key
+	}
+
+	if !workflow.Has(task.Status) {
+		issue := Issue{
+			Code: InvalidStatus,
+			Key:  key,
+			Message: fmt.Sprintf("status %q isn't one of %s",
+				task.Status, joinStatuses(workflow.Statuses)),
+		}
+		if status, ok := normaliseStatus(task.Status, workflow); ok {
+			issue.Fixable = true
+			task.Status = status
+		}
+		issues = append(issues, issue)
+	}
+
+	if task.UpdatedAt.Before(task.CreatedAt) {
+		issues = append(issues, Issue{
+			Code:    UpdatedBeforeCreated,
+			Key:     key,
+			Message: "task was updated before it was created",
+			Fixable: true,
+		})
+		task.UpdatedAt = task.CreatedAt
+	}
+
+	if len(task.Description) == 0 {
+		issues = append(issues, Issue{
+			Code:    EmptyDescription,
+			Key:     key,
+			Message: "description is empty",
+		})
+	}
+
+	if len(task.Description) > maxDescriptionLength {
+		issues = append(issues, Issue{
+			Code: LongDescription,
+			Key:  key,
+			Message: fmt.Sprintf("description is %d characters long, "+
+				"over the %d characters limit",
+				len(task.Description), maxDescriptionLength),
+		})
+	}
+
+	return issues
+}
+
+// normaliseStatus recognises hand-written variants of a status, such as
+// "In Progress" or "DONE".
+func normaliseStatus(s Status, workflow *Workflow) (Status, bool) {
+	normalised := strings.ToLower(strings.TrimSpace(string(s)))
+	normalised = strings.NewReplacer("_", "-", " ", "-").Replace(normalised)
+
+	status := Status(normalised)
+	if normalised == "inprogress" {
+		status = InProgress
+	}
+
+	return status, workflow.Has(status)
+}
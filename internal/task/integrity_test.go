@@ -0,0 +1,152 @@
+package task_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tk "github.com/alnah/task-tracker/internal/task"
+	th "github.com/alnah/task-tracker/test_helpers"
+)
+
+func Test_IntegrityError_Error(t *testing.T) {
+	t.Run("returns a string containing the number of issues", func(t *testing.T) {
+		err := &tk.IntegrityError{Issues: make([]tk.Issue, 3)}
+		th.AssertErrorMessage(t, err, err.Error(), "3")
+	})
+}
+
+func Test_CheckTasks(t *testing.T) {
+	testCases := []struct {
+		name string
+		task tk.Task
+		want []tk.Issue
+	}{
+		{
+			"reports nothing for a consistent task",
+			th.NewTestTask(1, "test_task", tk.Todo),
+			nil,
+		},
+		{
+			"reports a task ID that differs from its key",
+			th.NewTestTask(5, "test_task", tk.Todo),
+			[]tk.Issue{{Code: tk.IDMismatch, Fixable: true}},
+		},
+		{
+			"reports an unknown status that can be normalised",
+			th.NewTestTask(1, "test_task", "In Progress"),
+			[]tk.Issue{{Code: tk.InvalidStatus, Fixable: true}},
+		},
+		{
+			"reports an unknown status that can't be normalised",
+			th.NewTestTask(1, "test_task", "someday"),
+			[]tk.Issue{{Code: tk.InvalidStatus}},
+		},
+		{
+			"reports an update before the creation",
+			func() tk.Task {
+				task := th.NewTestTask(1, "test_task", tk.Done)
+				task.UpdatedAt = task.CreatedAt.Add(-time.Hour)
+				return task
+			}(),
+			[]tk.Issue{{Code: tk.UpdatedBeforeCreated, Fixable: true}},
+		},
+		{
+			"reports an empty description",
+			th.NewTestTask(1, "", tk.Todo),
+			[]tk.Issue{{Code: tk.EmptyDescription}},
+		},
+		{
+			"reports a description over the limit",
+			th.NewTestTask(1, strings.Repeat("a", 301), tk.Todo),
+			[]tk.Issue{{Code: tk.LongDescription}},
+		},
+		{
+			"reports every issue of a task in code order",
+			th.NewTestTask(2, "", "DONE"),
+			[]tk.Issue{
+				{Code: tk.EmptyDescription},
+				{Code: tk.IDMismatch, Fixable: true},
+				{Code: tk.InvalidStatus, Fixable: true},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			tasks := tk.Tasks{1: tc.task}
+
+			got := tk.CheckTasks(tasks, tk.DefaultWorkflow())
+			th.AssertDeepEqual(t, len(got), len(tc.want))
+			for i := range tc.want {
+				th.AssertDeepEqual(t, got[i].Key, uint(1))
+				th.AssertDeepEqual(t, got[i].Code, tc.want[i].Code)
+				th.AssertDeepEqual(t, got[i].Fixable, tc.want[i].Fixable)
+				th.AssertDeepEqual(t, got[i].Fixed, false)
+			}
+
+			th.AssertDeepEqual(t, tasks[1], tc.task)
+		})
+	}
+
+	t.Run("accepts the statuses of a custom workflow", func(t *testing.T) {
+		tasks := tk.Tasks{
+			1: th.NewTestTask(1, "test_task_1", Review),
+			2: th.NewTestTask(2, "test_task_2", "Blocked"),
+		}
+
+		got := tk.CheckTasks(tasks, newTeamWorkflow())
+		th.AssertDeepEqual(t, len(got), 1)
+		th.AssertDeepEqual(t, got[0].Key, uint(2))
+		th.AssertDeepEqual(t, got[0].Fixable, true)
+	})
+
+	t.Run("reports the tasks of the test factory stored under other IDs",
+		func(t *testing.T) {
+			got := tk.CheckTasks(th.NewTestTasks(), tk.DefaultWorkflow())
+			th.AssertDeepEqual(t, len(got), 3)
+			for i, key := range []uint{6, 7, 8} {
+				th.AssertDeepEqual(t, got[i].Key, key)
+				th.AssertDeepEqual(t, got[i].Code, tk.IDMismatch)
+			}
+		})
+}
+
+func Test_FixTasks(t *testing.T) {
+	t.Run("normalises the fixable issues in place", func(t *testing.T) {
+		stale := th.NewTestTask(3, "test_task_3", "in_progress")
+		stale.UpdatedAt = stale.CreatedAt.Add(-time.Hour)
+		tasks := tk.Tasks{
+			1: th.NewTestTask(1, "test_task_1", tk.Todo),
+			2: th.NewTestTask(5, "test_task_2", tk.Done),
+			3: stale,
+			4: th.NewTestTask(4, strings.Repeat("a", 301), "someday"),
+		}
+
+		issues := tk.FixTasks(tasks, tk.DefaultWorkflow())
+
+		var fixed, unresolved []tk.IssueCode
+		for _, issue := range issues {
+			if issue.Fixed {
+				fixed = append(fixed, issue.Code)
+			} else {
+				unresolved = append(unresolved, issue.Code)
+			}
+		}
+		th.AssertDeepEqual(t, fixed, []tk.IssueCode{
+			tk.IDMismatch, tk.InvalidStatus, tk.UpdatedBeforeCreated,
+		})
+		th.AssertDeepEqual(t, unresolved, []tk.IssueCode{
+			tk.InvalidStatus, tk.LongDescription,
+		})
+
+		th.AssertDeepEqual(t, tasks[2].ID, uint(2))
+		th.AssertDeepEqual(t, tasks[3].Status, tk.InProgress)
+		th.AssertDeepEqual(t, tasks[3].UpdatedAt, tasks[3].CreatedAt)
+		th.AssertDeepEqual(t, tasks[4].Status, tk.Status("someday"))
+		th.AssertDeepEqual(t, len(tasks[4].Description), 301)
+
+		th.AssertDeepEqual(t, len(tk.FixTasks(tasks, tk.DefaultWorkflow())), 2)
+	})
+}
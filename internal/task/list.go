@@ -0,0 +1,284 @@
+package task
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SortKey orders tasks by one of SortFields, ascending unless Desc.
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// SortFields are the fields tasks can be sorted by. Statuses sort in workflow
+// order, and tasks without a due date come last either way.
+var SortFields = []string{
+	"id", "status", "priority", "due", "created", "updated", "description",
+}
+
+// ListTasksParams selects, orders and pages tasks. Tasks are sorted by Sort,
+// then by ID. A page starts after Cursor, the NextCursor of the previous page,
+// skips Offset tasks, and holds at most Limit tasks, or all of them if Limit
+// is 0.
+type ListTasksParams struct {
+	Filter Filter
+	Sort   []SortKey
+	Limit  int
+	Offset int
+	Cursor string
+}
+
+// TaskPage is a page of tasks. Total counts the tasks matching the filter
+// across every page, and NextCursor is empty on the last page.
+type TaskPage struct {
+	Tasks      []Task
+	Total      int
+	NextCursor string
+}
+
+type SortKeyError struct {
+	Key string
+}
+
+func (e *SortKeyError) Error() string {
+	return fmt.Sprintf("invalid sort key %q, expected one of %s, optionally "+
+		"followed by :asc or :desc", e.Key, strings.Join(SortFields, ", "))
+}
+
+type CursorError struct {
+	Cursor string
+}
+
+func (e *CursorError) Error() string {
+	return fmt.Sprintf("invalid cursor %q, it must come from a page with the "+
+		"same sort", e.Cursor)
+}
+
+// ParseSort reads sort keys separated by commas, such as priority:desc,due.
+func ParseSort(s string) ([]SortKey, error) {
+	var keys []SortKey
+	for _, part := range strings.Split(s, ",") {
+		field, order, _ := strings.Cut(strings.TrimSpace(part), ":")
+		key := SortKey{Field: field, Desc: order == "desc"}
+		if !hasSortField(field) || (order != "" && order != "asc" && !key.Desc) {
+			return nil, &SortKeyError{Key: part}
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// ListTasks returns a sorted page of the tasks the filter matches, see
+// ListTasksParams.
+func (tr *FileTaskRepository) ListTasks(
+	params ListTasksParams,
+	opts ...ReadOption,
+) (TaskPage, error) {
+	tasks, err := tr.ReadAllTasks(opts...)
+	if err != nil {
+		return TaskPage{}, err
+	}
+	return PageTasks(tasks, params, tr.Workflow)
+}
+
+// PageTasks sorts and pages tasks as ListTasks does, ordering the statuses as
+// workflow does.
+func PageTasks(
+	tasks Tasks,
+	params ListTasksParams,
+	workflow *Workflow,
+) (TaskPage, error) {
+	for _, key := range params.Sort {
+		if !hasSortField(key.Field) {
+			return TaskPage{}, &SortKeyError{Key: key.Field}
+		}
+	}
+	if params.Limit < 0 || params.Offset < 0 {
+		return TaskPage{}, fmt.Errorf("expected a positive limit and offset, "+
+			"but got %d and %d", params.Limit, params.Offset)
+	}
+
+	matched := make([]Task, 0, len(tasks))
+	for _, task := range tasks {
+		if params.Filter == nil || params.Filter.Match(task) {
+			matched = append(matched, task)
+		}
+	}
+
+	s := newTaskSorter(params.Sort, workflow)
+	sort.Slice(matched, func(i, j int) bool {
+		return s.compare(matched[i], matched[j]) < 0
+	})
+
+	page := matched
+	if params.Cursor != "" {
+		last, err := s.decodeCursor(params.Cursor)
+		if err != nil {
+			return TaskPage{}, err
+		}
+		start := sort.Search(len(page), func(i int) bool {
+			return s.compare(page[i], last) > 0
+		})
+		page = page[start:]
+	}
+	page = page[min(params.Offset, len(page)):]
+
+	next := ""
+	if params.Limit > 0 && len(page) > params.Limit {
+		page = page[:params.Limit]
+		next = s.encodeCursor(page[len(page)-1])
+	}
+
+	return TaskPage{Tasks: page, Total: len(matched), NextCursor: next}, nil
+}
+
+type taskSorter struct {
+	keys     []SortKey
+	statuses map[Status]int
+}
+
+func newTaskSorter(keys []SortKey, workflow *Workflow) taskSorter {
+	statuses := map[Status]int{}
+	if workflow != nil {
+		for i, status := range workflow.Statuses {
+			statuses[status] = i
+		}
+	}
+	return taskSorter{keys: keys, statuses: statuses}
+}
+
+// compare orders a before b by the sort keys, then by ID.
+func (s taskSorter) compare(a, b Task) int {
+	for _, key := range s.keys {
+		if c := s.compareBy(key, a, b); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(a.ID, b.ID)
+}
+
+func (s taskSorter) compareBy(key SortKey, a, b Task) int {
+	var c int
+	switch key.Field {
+	case "id":
+		c = cmp.Compare(a.ID, b.ID)
+	case "status":
+		c = cmp.Compare(s.statusRank(a.Status), s.statusRank(b.Status))
+		if c == 0 {
+			c = cmp.Compare(a.Status, b.Status)
+		}
+	case "priority":
+		c = cmp.Compare(a.Priority, b.Priority)
+	case "due":
+		switch {
+		case a.Due == nil || b.Due == nil:
+			// no due date comes last, whatever the order
+			return cmp.Compare(boolRank(a.Due == nil), boolRank(b.Due == nil))
+		default:
+			c = a.Due.Compare(*b.Due)
+		}
+	case "created":
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	case "updated":
+		c = a.UpdatedAt.Compare(b.UpdatedAt)
+	case "description":
+		c = cmp.Compare(strings.ToLower(a.Description),
+			strings.ToLower(b.Description))
+		if c == 0 {
+			c = cmp.Compare(a.Description, b.Description)
+		}
+	}
+
+	if key.Desc {
+		return -c
+	}
+	return c
+}
+
+// statusRank puts the statuses the workflow doesn't know after the others.
+func (s taskSorter) statusRank(status Status) int {
+	if rank, ok := s.statuses[status]; ok {
+		return rank
+	}
+	return len(s.statuses)
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// pageCursor is what a cursor encodes: the sort it belongs to, and the fields
+// of the last task of the page the sort compares.
+type pageCursor struct {
+	Sort []SortKey
+	Last Task
+}
+
+func (s taskSorter) encodeCursor(task Task) string {
+	last := Task{ID: task.ID}
+	for _, key := range s.keys {
+		switch key.Field {
+		case "status":
+			last.Status = task.Status
+		case "priority":
+			last.Priority = task.Priority
+		case "due":
+			last.Due = task.Due
+		case "created":
+			last.CreatedAt = task.CreatedAt
+		case "updated":
+			last.UpdatedAt = task.UpdatedAt
+		case "description":
+			last.Description = task.Description
+		}
+	}
+
+	// a Task always marshals
+	content, _ := json.Marshal(pageCursor{Sort: s.keys, Last: last})
+	return base64.RawURLEncoding.EncodeToString(content)
+}
+
+func (s taskSorter) decodeCursor(cursor string) (Task, error) {
+	content, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return Task{}, &CursorError{Cursor: cursor}
+	}
+
+	var decoded pageCursor
+	if err := json.Unmarshal(content, &decoded); err != nil {
+		return Task{}, &CursorError{Cursor: cursor}
+	}
+	if !equalSort(decoded.Sort, s.keys) {
+		return Task{}, &CursorError{Cursor: cursor}
+	}
+	return decoded.Last, nil
+}
+
+func equalSort(a, b []SortKey) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func hasSortField(field string) bool {
+	for _, f := range SortFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
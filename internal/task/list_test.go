@@ -0,0 +1,269 @@
+package task_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	tk "github.com/alnah/task-tracker/internal/task"
+	th "github.com/alnah/task-tracker/test_helpers"
+)
+
+func Test_SortKeyError_Error(t *testing.T) {
+	t.Run("returns a string containing the key", func(t *testing.T) {
+		err := &tk.SortKeyError{Key: "size"}
+		th.AssertErrorMessage(t, err, err.Error(), "size")
+	})
+}
+
+func Test_CursorError_Error(t *testing.T) {
+	t.Run("returns a string containing the cursor", func(t *testing.T) {
+		err := &tk.CursorError{Cursor: "abc"}
+		th.AssertErrorMessage(t, err, err.Error(), "abc")
+	})
+}
+
+func Test_ParseSort(t *testing.T) {
+	testCases := []struct {
+		name    string
+		s       string
+		want    []tk.SortKey
+		wantErr error
+	}{
+		{"happy: parses a key", "due", []tk.SortKey{{Field: "due"}}, nil},
+		{"happy: parses keys and orders", "priority:desc,due:asc,id",
+			[]tk.SortKey{
+				{Field: "priority", Desc: true}, {Field: "due"}, {Field: "id"},
+			}, nil},
+		{"sad: rejects an unknown field", "size", nil, &tk.SortKeyError{}},
+		{"sad: rejects an unknown order", "due:up", nil, &tk.SortKeyError{}},
+		{"edge: ignores spaces around keys", " status , updated:desc",
+			[]tk.SortKey{{Field: "status"}, {Field: "updated", Desc: true}}, nil},
+		{"edge: rejects an empty key", "due,", nil, &tk.SortKeyError{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := tk.ParseSort(tc.s)
+			if tc.wantErr != nil {
+				th.AssertError(t, err, tc.wantErr)
+				return
+			}
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got, tc.want)
+		})
+	}
+}
+
+// newSortTestTasks returns tasks that every sort key orders differently.
+func newSortTestTasks() tk.Tasks {
+	at := func(hours int) time.Time {
+		return th.FixedTime.Add(time.Duration(hours) * time.Hour)
+	}
+	tasks := tk.Tasks{}
+	for _, task := range []struct {
+		id       uint
+		desc     string
+		status   tk.Status
+		priority tk.Priority
+		due      *time.Time
+		created  int
+		updated  int
+	}{
+		{1, "walk the dog", tk.Done, tk.PriorityLow, ptr(at(48)), 3, 5},
+		{2, "Buy milk", tk.Todo, tk.PriorityHigh, nil, 1, 9},
+		{3, "call mum", tk.InProgress, tk.PriorityHigh, ptr(at(24)), 2, 1},
+		{4, "answer mail", tk.Todo, tk.PriorityNone, ptr(at(72)), 4, 2},
+	} {
+		t := th.NewTestTask(task.id, task.desc, task.status)
+		t.Priority = task.priority
+		t.Due = task.due
+		t.CreatedAt = at(task.created)
+		t.UpdatedAt = at(task.updated)
+		tasks[task.id] = t
+	}
+	return tasks
+}
+
+func Test_PageTasks_Sort(t *testing.T) {
+	testCases := []struct {
+		name string
+		sort []tk.SortKey
+		want []uint
+	}{
+		{"sorts by ID without keys", nil, []uint{1, 2, 3, 4}},
+		{"sorts by ID descending", []tk.SortKey{{Field: "id", Desc: true}},
+			[]uint{4, 3, 2, 1}},
+		{"sorts statuses in workflow order", []tk.SortKey{{Field: "status"}},
+			[]uint{2, 4, 3, 1}},
+		{"sorts by priority, then ID",
+			[]tk.SortKey{{Field: "priority", Desc: true}},
+			[]uint{2, 3, 1, 4}},
+		{"sorts by due date, without one last", []tk.SortKey{{Field: "due"}},
+			[]uint{3, 1, 4, 2}},
+		{"sorts by due date descending, without one last",
+			[]tk.SortKey{{Field: "due", Desc: true}}, []uint{4, 1, 3, 2}},
+		{"sorts by creation", []tk.SortKey{{Field: "created"}},
+			[]uint{2, 3, 1, 4}},
+		{"sorts by update", []tk.SortKey{{Field: "updated", Desc: true}},
+			[]uint{2, 1, 4, 3}},
+		{"sorts by description in any case",
+			[]tk.SortKey{{Field: "description"}}, []uint{4, 2, 3, 1}},
+		{"sorts by several keys",
+			[]tk.SortKey{{Field: "status"}, {Field: "priority", Desc: true}},
+			[]uint{2, 4, 3, 1}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			page, err := tk.PageTasks(newSortTestTasks(),
+				tk.ListTasksParams{Sort: tc.sort}, tk.DefaultWorkflow())
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, pageIDs(page), tc.want)
+			th.AssertDeepEqual(t, page.Total, 4)
+			th.AssertDeepEqual(t, page.NextCursor, "")
+		})
+	}
+}
+
+func Test_PageTasks_Page(t *testing.T) {
+	tasks := th.NewTestTasks()
+	for id, task := range tasks {
+		task.ID = id
+		tasks[id] = task
+	}
+
+	testCases := []struct {
+		name     string
+		params   tk.ListTasksParams
+		want     []uint
+		wantNext bool
+	}{
+		{"happy: returns the first page",
+			tk.ListTasksParams{Limit: 3}, []uint{1, 2, 3}, true},
+		{"happy: skips the offset",
+			tk.ListTasksParams{Limit: 3, Offset: 3}, []uint{4, 5, 6}, true},
+		{"happy: filters before paging", tk.ListTasksParams{
+			Filter: tk.FilterFunc(func(t tk.Task) bool { return t.ID%2 == 0 }),
+			Limit:  3,
+		}, []uint{2, 4, 6}, true},
+		{"edge: returns a last page without a cursor",
+			tk.ListTasksParams{Limit: 3, Offset: 6}, []uint{7, 8}, false},
+		{"edge: returns a full last page without a cursor",
+			tk.ListTasksParams{Limit: 4, Offset: 4}, []uint{5, 6, 7, 8}, false},
+		{"edge: returns nothing past the end",
+			tk.ListTasksParams{Limit: 3, Offset: 9}, nil, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			page, err := tk.PageTasks(tasks, tc.params, tk.DefaultWorkflow())
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, pageIDs(page), tc.want)
+			th.AssertDeepEqual(t, page.NextCursor != "", tc.wantNext)
+		})
+	}
+
+	t.Run("walks every page with cursors successfully", func(t *testing.T) {
+		params := tk.ListTasksParams{
+			Sort:  []tk.SortKey{{Field: "priority", Desc: true}},
+			Limit: 3,
+		}
+		sorted := newSortTestTasks()
+		for id := uint(5); id <= 9; id++ {
+			sorted[id] = th.NewTestTask(id, "test_task", tk.Todo)
+		}
+
+		var got []uint
+		for {
+			page, err := tk.PageTasks(sorted, params, tk.DefaultWorkflow())
+			th.AssertNoError(t, err)
+			got = append(got, pageIDs(page)...)
+			if page.NextCursor == "" {
+				break
+			}
+			params.Cursor = page.NextCursor
+
+			// a task removed from a page read doesn't shift the next one
+			delete(sorted, got[0])
+		}
+		th.AssertDeepEqual(t, got, []uint{2, 3, 1, 4, 5, 6, 7, 8, 9})
+	})
+
+	t.Run("returns an error successfully", func(t *testing.T) {
+		first, err := tk.PageTasks(tasks, tk.ListTasksParams{Limit: 3},
+			tk.DefaultWorkflow())
+		th.AssertNoError(t, err)
+
+		testCases := []struct {
+			name    string
+			params  tk.ListTasksParams
+			wantErr error
+		}{
+			{"rejects a cursor of another sort", tk.ListTasksParams{
+				Sort:   []tk.SortKey{{Field: "due"}},
+				Cursor: first.NextCursor,
+			}, &tk.CursorError{}},
+			{"rejects a cursor that isn't one",
+				tk.ListTasksParams{Cursor: "not a cursor"}, &tk.CursorError{}},
+			{"rejects an unknown sort key", tk.ListTasksParams{
+				Sort: []tk.SortKey{{Field: "size"}},
+			}, &tk.SortKeyError{}},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+				_, err := tk.PageTasks(tasks, tc.params, tk.DefaultWorkflow())
+				th.AssertError(t, err, tc.wantErr)
+			})
+		}
+
+		_, err = tk.PageTasks(tasks, tk.ListTasksParams{Limit: -1},
+			tk.DefaultWorkflow())
+		th.AssertNotNil(t, err)
+	})
+}
+
+func Test_FileTaskRepository_ListTasks(t *testing.T) {
+	t.Run("lists a page of the stored tasks successfully", func(t *testing.T) {
+		mockFs, taskRepo := setupTaskUnitTest(t)
+		mockFs.Tasks = newSortTestTasks()
+		_, err := taskRepo.DeleteTask(2)
+		th.AssertNoError(t, err)
+
+		page, err := taskRepo.ListTasks(tk.ListTasksParams{
+			Sort:  []tk.SortKey{{Field: "status"}},
+			Limit: 2,
+		})
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, pageIDs(page), []uint{4, 3})
+		th.AssertDeepEqual(t, page.Total, 3)
+
+		page, err = taskRepo.ListTasks(tk.ListTasksParams{
+			Sort:  []tk.SortKey{{Field: "status"}},
+			Limit: 2,
+		}, tk.IncludeTrashed)
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, pageIDs(page), []uint{2, 4})
+	})
+
+	t.Run("returns an error when loading fails", func(t *testing.T) {
+		mockFs, taskRepo := setupTaskUnitTest(t)
+		mockFs.LoadError = &os.PathError{}
+
+		_, err := taskRepo.ListTasks(tk.ListTasksParams{})
+		th.AssertError(t, err, &os.PathError{})
+	})
+}
+
+func pageIDs(page tk.TaskPage) []uint {
+	var ids []uint
+	for _, task := range page.Tasks {
+		ids = append(ids, task.ID)
+	}
+	return ids
+}
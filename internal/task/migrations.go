@@ -0,0 +1,96 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	st "github.com/alnah/task-tracker/internal/store"
+)
+
+// Migrations upgrade the tasks payload one schema version at a time, starting
+// from version 1. Append to the list, never edit or reorder released entries.
+var Migrations = []st.Migration{
+	{
+		Description: "wrap the tasks with the highest ID ever given",
+		Migrate:     addLastID,
+	},
+	{
+		Description: "give every task a long ID",
+		Migrate:     addUIDs,
+	},
+}
+
+// addLastID turns the version 1 map of tasks into a TaskList. The deleted IDs
+// are unknown by then, so the highest stored ID is the best guess.
+func addLastID(data any) (any, error) {
+	tasks, ok := data.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected the tasks as an object, but got %T", data)
+	}
+
+	var lastID uint64
+	for key := range tasks {
+		id, err := strconv.ParseUint(key, 10, 0)
+		if err != nil {
+			return nil, fmt.Errorf("expected a task ID as key, but got %q", key)
+		}
+		lastID = max(lastID, id)
+	}
+
+	return map[string]any{
+		"LastID": json.Number(strconv.FormatUint(lastID, 10)),
+		"Tasks":  tasks,
+	}, nil
+}
+
+// addUIDs gives each task a ULID made from its creation time, in ID order so
+// tasks created within the same millisecond keep their order.
+func addUIDs(data any) (any, error) {
+	list, ok := data.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf(
+			"expected the task list as an object, but got %T", data,
+		)
+	}
+	tasks, ok := list["Tasks"].(map[string]any)
+	if !ok {
+		return data, nil // no tasks yet
+	}
+
+	keys := make([]string, 0, len(tasks))
+	for key := range tasks {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) < len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+
+	generator := &ULIDGenerator{}
+	for _, key := range keys {
+		task, ok := tasks[key].(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("expected task %s as an object", key)
+		}
+
+		createdAt := time.UnixMilli(0)
+		if value, ok := task["CreatedAt"].(string); ok {
+			if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+				createdAt = t
+			}
+		}
+
+		uid, err := generator.NewUID(createdAt)
+		if err != nil {
+			return nil, err
+		}
+		task["UID"] = uid
+	}
+
+	return list, nil
+}
@@ -0,0 +1,93 @@
+package task_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	st "github.com/alnah/task-tracker/internal/store"
+	tk "github.com/alnah/task-tracker/internal/task"
+	th "github.com/alnah/task-tracker/test_helpers"
+)
+
+func Test_Migrations(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		want    tk.TaskList
+	}{
+		{
+			"seeds the highest ID from a legacy file",
+			`{"1": {"ID": 1}, "7": {"ID": 7}, "3": {"ID": 3}}`,
+			tk.TaskList{
+				LastID: 7,
+				Tasks:  tk.Tasks{1: {ID: 1}, 3: {ID: 3}, 7: {ID: 7}},
+			},
+		},
+		{
+			"seeds the highest ID from a version 1 file",
+			`{"schemaVersion": 1, "data": {"2": {"ID": 2}}}`,
+			tk.TaskList{LastID: 2, Tasks: tk.Tasks{2: {ID: 2}}},
+		},
+		{
+			"seeds no ID from an empty version 1 file",
+			`{"schemaVersion": 1, "data": {}}`,
+			tk.TaskList{LastID: 0, Tasks: tk.Tasks{}},
+		},
+		{
+			"leaves an up-to-date file as is",
+			`{"schemaVersion": 3, "data": {"LastID": 9, ` +
+				`"Tasks": {"2": {"ID": 2, "UID": "01J00000000000000000000002"}}}}`,
+			tk.TaskList{LastID: 9, Tasks: tk.Tasks{2: {ID: 2}}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got := migrate(t, tc.content)
+
+			for id, task := range got.Tasks {
+				th.AssertDeepEqual(t, len(task.UID), 26)
+				task.UID = ""
+				got.Tasks[id] = task
+			}
+			th.AssertDeepEqual(t, got, tc.want)
+		})
+	}
+
+	t.Run("gives tasks long IDs sorted by creation time", func(t *testing.T) {
+		got := migrate(t, `{"schemaVersion": 2, "data": {"LastID": 3, "Tasks": {`+
+			`"1": {"ID": 1, "CreatedAt": "2024-05-01T10:00:00Z"}, `+
+			`"2": {"ID": 2, "CreatedAt": "2024-05-01T10:00:00Z"}, `+
+			`"3": {"ID": 3, "CreatedAt": "2016-07-30T22:36:16.385Z"}}}}`)
+
+		th.AssertDeepEqual(t, got.Tasks[3].UID[:10], "01ARYZ6S41")
+		if !(got.Tasks[3].UID < got.Tasks[1].UID &&
+			got.Tasks[1].UID < got.Tasks[2].UID) {
+			t.Errorf("got UIDs %q, %q, %q, want them sorted by creation time",
+				got.Tasks[3].UID, got.Tasks[1].UID, got.Tasks[2].UID)
+		}
+	})
+
+	t.Run("returns an error for tasks not keyed by ID", func(t *testing.T) {
+		_, err := st.MigrateData(
+			[]byte(`{"schemaVersion": 1, "data": {"first": {"ID": 1}}}`),
+			st.JSONCodec{}, tk.Migrations,
+		)
+		th.AssertNotNil(t, err)
+	})
+}
+
+func migrate(t testing.TB, content string) tk.TaskList {
+	t.Helper()
+	migrated, err := st.MigrateData([]byte(content), st.JSONCodec{}, tk.Migrations)
+	th.AssertNoError(t, err)
+
+	var got struct {
+		SchemaVersion int `json:"schemaVersion"`
+		Data          tk.TaskList
+	}
+	th.AssertNoError(t, json.Unmarshal(migrated, &got))
+	th.AssertDeepEqual(t, got.SchemaVersion, len(tk.Migrations)+1)
+	return got.Data
+}
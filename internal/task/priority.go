@@ -0,0 +1,72 @@
+package task
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Priority ranks tasks from PriorityNone, which tasks without a priority get,
+// to PriorityCritical. It's stored by name.
+type Priority int
+
+const (
+	PriorityNone Priority = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+	PriorityCritical
+)
+
+var priorityNames = []string{"none", "low", "medium", "high", "critical"}
+
+type PriorityError struct {
+	Value string
+}
+
+func (e *PriorityError) Error() string {
+	return fmt.Sprintf("invalid priority %q, expected one of %s",
+		e.Value, strings.Join(priorityNames, ", "))
+}
+
+func ParsePriority(s string) (Priority, error) {
+	for i, name := range priorityNames {
+		if name == s {
+			return Priority(i), nil
+		}
+	}
+	return PriorityNone, &PriorityError{Value: s}
+}
+
+func (p Priority) String() string {
+	if !p.valid() {
+		return fmt.Sprintf("priority(%d)", int(p))
+	}
+	return priorityNames[p]
+}
+
+func (p Priority) MarshalText() ([]byte, error) {
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	return []byte(p.String()), nil
+}
+
+func (p *Priority) UnmarshalText(text []byte) error {
+	priority, err := ParsePriority(string(text))
+	if err != nil {
+		return err
+	}
+	*p = priority
+	return nil
+}
+
+func (p Priority) valid() bool {
+	return p >= PriorityNone && p <= PriorityCritical
+}
+
+func (p Priority) validate() error {
+	if !p.valid() {
+		return &PriorityError{Value: p.String()}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package task_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	tk "github.com/alnah/task-tracker/internal/task"
+	th "github.com/alnah/task-tracker/test_helpers"
+)
+
+func Test_PriorityError_Error(t *testing.T) {
+	t.Run("returns a string containing the value and the priorities",
+		func(t *testing.T) {
+			err := &tk.PriorityError{Value: "urgent"}
+			th.AssertErrorMessage(t, err, err.Error(), "urgent")
+			th.AssertErrorMessage(t, err, err.Error(), "none, low, medium")
+		})
+}
+
+func Test_ParsePriority(t *testing.T) {
+	testCases := []struct {
+		name    string
+		s       string
+		want    tk.Priority
+		wantErr error
+	}{
+		{"happy: parses none", "none", tk.PriorityNone, nil},
+		{"happy: parses low", "low", tk.PriorityLow, nil},
+		{"happy: parses medium", "medium", tk.PriorityMedium, nil},
+		{"happy: parses high", "high", tk.PriorityHigh, nil},
+		{"happy: parses critical", "critical", tk.PriorityCritical, nil},
+		{"sad: returns a PriorityError", "urgent", 0, &tk.PriorityError{}},
+		{"edge: rejects upper case", "High", 0, &tk.PriorityError{}},
+		{"edge: rejects an empty string", "", 0, &tk.PriorityError{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := tk.ParsePriority(tc.s)
+			if tc.wantErr != nil {
+				th.AssertError(t, err, tc.wantErr)
+				return
+			}
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got, tc.want)
+			th.AssertDeepEqual(t, got.String(), tc.s)
+		})
+	}
+}
+
+func Test_Priority_JSON(t *testing.T) {
+	t.Run("stores the priority by name successfully", func(t *testing.T) {
+		task := th.NewTestTask(1, "test_task_1", tk.Todo)
+		task.Priority = tk.PriorityHigh
+
+		content, err := json.Marshal(task)
+		th.AssertNoError(t, err)
+		th.AssertContains(t, string(content), `"Priority":"high"`)
+
+		var got tk.Task
+		th.AssertNoError(t, json.Unmarshal(content, &got))
+		th.AssertDeepEqual(t, got, task)
+	})
+
+	t.Run("leaves out no priority successfully", func(t *testing.T) {
+		content, err := json.Marshal(th.NewTestTask(1, "test_task_1", tk.Todo))
+		th.AssertNoError(t, err)
+
+		var fields map[string]any
+		th.AssertNoError(t, json.Unmarshal(content, &fields))
+		if _, ok := fields["Priority"]; ok {
+			t.Errorf("got %s, want no priority", content)
+		}
+	})
+
+	t.Run("loads a task without a priority successfully", func(t *testing.T) {
+		var got tk.Task
+		err := json.Unmarshal([]byte(`{"ID":1,"Status":"todo"}`), &got)
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, got.Priority, tk.PriorityNone)
+	})
+
+	t.Run("returns an error for an unknown priority", func(t *testing.T) {
+		var got tk.Task
+		err := json.Unmarshal([]byte(`{"ID":1,"Priority":"urgent"}`), &got)
+		th.AssertError(t, err, &tk.PriorityError{})
+
+		_, err = json.Marshal(tk.Task{Priority: tk.PriorityCritical + 1})
+		th.AssertError(t, err, &tk.PriorityError{})
+	})
+}
@@ -0,0 +1,322 @@
+package task
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// QueryError reports a query that can't be parsed or compiled. Pos is the byte
+// offset in Query where the problem is.
+type QueryError struct {
+	Query   string
+	Pos     int
+	Message string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("invalid query at column %d: %s", e.Column(), e.Message)
+}
+
+// Column is the 1-based column of Pos, counted in characters.
+func (e *QueryError) Column() int {
+	return queryColumn(e.Query, e.Pos)
+}
+
+func queryColumn(query string, pos int) int {
+	return utf8.RuneCountInString(query[:pos]) + 1
+}
+
+// QueryNode is a node of a parsed query: an AndNode, an OrNode, a NotNode or a
+// TermNode.
+type QueryNode interface {
+	queryNode()
+}
+
+type AndNode struct {
+	Left, Right QueryNode
+}
+
+type OrNode struct {
+	Left, Right QueryNode
+}
+
+type NotNode struct {
+	Operand QueryNode
+}
+
+// TermNode compares a field of a task to a value, such as priority>=high or
+// due.before:friday. A bare status such as todo is short for status:todo.
+type TermNode struct {
+	Field    string
+	Modifier string
+	Op       string
+	Value    string
+	Pos      int
+	OpPos    int
+	ValuePos int
+}
+
+func (*AndNode) queryNode()  {}
+func (*OrNode) queryNode()   {}
+func (*NotNode) queryNode()  {}
+func (*TermNode) queryNode() {}
+
+// ParseQuery parses a query such as
+//
+//	status:todo (tag:backend OR tag:ops) NOT priority<high
+//
+// into its syntax tree, nil for an empty query. Terms next to each other are
+// joined by AND, which binds tighter than OR; NOT binds tightest.
+func ParseQuery(query string) (QueryNode, error) {
+	tokens, err := lexQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &queryParser{query: query, tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return nil, nil
+	}
+
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.errorAt(tok.pos, "unexpected %s", tok)
+	}
+	return node, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOp
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of query"
+	case tokenString:
+		return fmt.Sprintf("string %q", t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// isKeyword reports whether the token is the keyword AND, OR or NOT, in any
+// case.
+func (t token) isKeyword(keyword string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, keyword)
+}
+
+const opChars = ":=!<>~"
+
+func lexQuery(query string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(query); {
+		r, size := utf8.DecodeRuneInString(query[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '(':
+			tokens = append(tokens, token{tokenLParen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokenRParen, ")", i})
+			i++
+		case r == '"':
+			text, end, ok := lexString(query, i)
+			if !ok {
+				return nil, &QueryError{query, i, "unterminated string"}
+			}
+			tokens = append(tokens, token{tokenString, text, i})
+			i = end
+		case strings.ContainsRune(opChars, r):
+			op := query[i : i+1]
+			if strings.Contains("!<>", op) && strings.HasPrefix(query[i+1:], "=") {
+				op += "="
+			}
+			if op == "!" {
+				return nil, &QueryError{query, i, `expected != after "!"`}
+			}
+			tokens = append(tokens, token{tokenOp, op, i})
+			i += len(op)
+		default:
+			end := i
+			for end < len(query) {
+				r, size := utf8.DecodeRuneInString(query[end:])
+				if unicode.IsSpace(r) || strings.ContainsRune(`()"`+opChars, r) {
+					break
+				}
+				end += size
+			}
+			tokens = append(tokens, token{tokenWord, query[i:end], i})
+			i = end
+		}
+	}
+	return append(tokens, token{tokenEOF, "", len(query)}), nil
+}
+
+// lexString reads the string quoted at start, in which \" and \\ escape a
+// quote and a backslash, and returns it with the offset after it.
+func lexString(query string, start int) (string, int, bool) {
+	var b strings.Builder
+	for i := start + 1; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '"':
+			return b.String(), i + 1, true
+		case c == '\\' && i+1 < len(query) && strings.ContainsRune(`"\`,
+			rune(query[i+1])):
+			i++
+			b.WriteByte(query[i])
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, false
+}
+
+type queryParser struct {
+	query  string
+	tokens []token
+	i      int
+}
+
+func (p *queryParser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *queryParser) next() token {
+	tok := p.tokens[p.i]
+	if tok.kind != tokenEOF {
+		p.i++
+	}
+	return tok
+}
+
+func (p *queryParser) errorAt(pos int, format string, args ...any) error {
+	return &QueryError{p.query, pos, fmt.Sprintf(format, args...)}
+}
+
+func (p *queryParser) parseOr() (QueryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().isKeyword("OR") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &OrNode{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (QueryNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		switch {
+		case tok.isKeyword("AND"):
+			p.next()
+		case tok.isKeyword("OR"):
+			return left, nil
+		case tok.kind != tokenWord && tok.kind != tokenLParen:
+			return left, nil
+		}
+
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &AndNode{Left: left, Right: right}
+	}
+}
+
+func (p *queryParser) parseNot() (QueryNode, error) {
+	if !p.peek().isKeyword("NOT") {
+		return p.parsePrimary()
+	}
+	p.next()
+	operand, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	return &NotNode{Operand: operand}, nil
+}
+
+func (p *queryParser) parsePrimary() (QueryNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenLParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, p.errorAt(closing.pos,
+				"expected ) to close the ( at column %d, but got %s",
+				queryColumn(p.query, tok.pos), closing)
+		}
+		return node, nil
+	case tokenWord:
+		if tok.isKeyword("AND") || tok.isKeyword("OR") {
+			return nil, p.errorAt(tok.pos, "expected a term, but got %s", tok)
+		}
+		return p.parseTerm(tok)
+	default:
+		return nil, p.errorAt(tok.pos, "expected a term, but got %s", tok)
+	}
+}
+
+func (p *queryParser) parseTerm(field token) (QueryNode, error) {
+	op := p.peek()
+	if op.kind != tokenOp {
+		return &TermNode{
+			Field:    "status",
+			Op:       ":",
+			Value:    field.text,
+			Pos:      field.pos,
+			OpPos:    field.pos,
+			ValuePos: field.pos,
+		}, nil
+	}
+	p.next()
+
+	value := p.next()
+	if value.kind != tokenWord && value.kind != tokenString {
+		return nil, p.errorAt(value.pos, "expected a value after %s%s, but got %s",
+			field.text, op.text, value)
+	}
+
+	name, modifier, _ := strings.Cut(field.text, ".")
+	return &TermNode{
+		Field:    strings.ToLower(name),
+		Modifier: strings.ToLower(modifier),
+		Op:       op.text,
+		Value:    value.text,
+		Pos:      field.pos,
+		OpPos:    op.pos,
+		ValuePos: value.pos,
+	}, nil
+}
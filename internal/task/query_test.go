@@ -0,0 +1,101 @@
+package task_test
+
+import (
+	"testing"
+
+	tk "github.com/alnah/task-tracker/internal/task"
+	th "github.com/alnah/task-tracker/test_helpers"
+)
+
+func Test_QueryError_Error(t *testing.T) {
+	t.Run("returns a string containing the column and the message",
+		func(t *testing.T) {
+			err := &tk.QueryError{Query: "tag:é x", Pos: 7, Message: "oops"}
+			th.AssertErrorMessage(t, err, err.Error(), "column 7")
+			th.AssertErrorMessage(t, err, err.Error(), "oops")
+		})
+}
+
+func Test_ParseQuery_Happy(t *testing.T) {
+	term := func(field, op, value string, pos, opPos, valuePos int) *tk.TermNode {
+		return &tk.TermNode{Field: field, Op: op, Value: value, Pos: pos,
+			OpPos: opPos, ValuePos: valuePos}
+	}
+
+	testCases := []struct {
+		name  string
+		query string
+		want  tk.QueryNode
+	}{
+		{"parses a term", "status:todo", term("status", ":", "todo", 0, 6, 7)},
+		{"parses a comparison", "priority>=high",
+			term("priority", ">=", "high", 0, 8, 10)},
+		{"parses a modifier", "due.before:friday", &tk.TermNode{
+			Field: "due", Modifier: "before", Op: ":", Value: "friday",
+			OpPos: 10, ValuePos: 11,
+		}},
+		{"parses a quoted value", `desc~"a \"b\" c"`,
+			term("desc", "~", `a "b" c`, 0, 4, 5)},
+		{"parses a bare status", "todo", term("status", ":", "todo", 0, 0, 0)},
+		{"joins terms with an implicit AND", "tag:a tag:b", &tk.AndNode{
+			Left:  term("tag", ":", "a", 0, 3, 4),
+			Right: term("tag", ":", "b", 6, 9, 10),
+		}},
+		{"binds AND tighter than OR", "tag:a OR tag:b and tag:c", &tk.OrNode{
+			Left: term("tag", ":", "a", 0, 3, 4),
+			Right: &tk.AndNode{
+				Left:  term("tag", ":", "b", 9, 12, 13),
+				Right: term("tag", ":", "c", 19, 22, 23),
+			},
+		}},
+		{"groups with parentheses", "(tag:a OR tag:b) NOT todo", &tk.AndNode{
+			Left: &tk.OrNode{
+				Left:  term("tag", ":", "a", 1, 4, 5),
+				Right: term("tag", ":", "b", 10, 13, 14),
+			},
+			Right: &tk.NotNode{Operand: term("status", ":", "todo", 21, 21, 21)},
+		}},
+		{"reads keywords as values", "tag:or", term("tag", ":", "or", 0, 3, 4)},
+		{"returns nil for an empty query", "  ", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := tk.ParseQuery(tc.query)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got, tc.want)
+		})
+	}
+}
+
+func Test_ParseQuery_Sad(t *testing.T) {
+	testCases := []struct {
+		name    string
+		query   string
+		wantPos int
+		wantMsg string
+	}{
+		{"rejects an unterminated string", `desc:"abc`, 5, "unterminated"},
+		{"rejects a lone !", "tag!x", 3, "!="},
+		{"rejects a missing value", "status:", 7, "expected a value"},
+		{"rejects a missing closing parenthesis", "(tag:a OR tag:b", 15,
+			"close the ( at column 1"},
+		{"rejects a stray closing parenthesis", "tag:a)", 5, `unexpected ")"`},
+		{"rejects a leading operator", ":todo", 0, "expected a term"},
+		{"rejects a dangling OR", "tag:a OR", 8, "end of query"},
+		{"rejects a dangling NOT", "NOT", 3, "end of query"},
+		{"rejects empty parentheses", "()", 1, "expected a term"},
+		{"rejects two operators", "tag::a", 4, "expected a value"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := tk.ParseQuery(tc.query)
+			th.AssertError(t, err, &tk.QueryError{})
+			th.AssertErrorMessage(t, err, err.Error(), tc.wantMsg)
+			th.AssertDeepEqual(t, err.(*tk.QueryError).Pos, tc.wantPos)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package task
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+const maxTagLength = 32
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+type TagError struct {
+	Tag string
+}
+
+func (e *TagError) Error() string {
+	return fmt.Sprintf("invalid tag %q, expected lowercase letters, digits, "+
+		"dashes and underscores, up to %d characters", e.Tag, maxTagLength)
+}
+
+// CountTags returns how many tasks have each tag.
+func (tr *FileTaskRepository) CountTags() (map[string]int, error) {
+	tasks, err := tr.ReadAllTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	for _, task := range tasks {
+		for _, tag := range task.Tags {
+			counts[tag]++
+		}
+	}
+	return counts, nil
+}
+
+func validateTags(tags []string) error {
+	for _, tag := range tags {
+		if len(tag) > maxTagLength || !tagPattern.MatchString(tag) {
+			return &TagError{Tag: tag}
+		}
+	}
+	return nil
+}
+
+// editTags returns tags with add added and remove removed, sorted and without
+// duplicates, or nil if no tag is left.
+func editTags(tags, add, remove []string) []string {
+	set := map[string]bool{}
+	for _, tag := range append(append([]string{}, tags...), add...) {
+		set[tag] = true
+	}
+	for _, tag := range remove {
+		delete(set, tag)
+	}
+	if len(set) == 0 {
+		return nil
+	}
+
+	edited := make([]string, 0, len(set))
+	for tag := range set {
+		edited = append(edited, tag)
+	}
+	sort.Strings(edited)
+	return edited
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func equalTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
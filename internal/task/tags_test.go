@@ -0,0 +1,197 @@
+package task_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	tk "github.com/alnah/task-tracker/internal/task"
+	th "github.com/alnah/task-tracker/test_helpers"
+)
+
+func Test_TagError_Error(t *testing.T) {
+	t.Run("returns a string containing the tag", func(t *testing.T) {
+		err := &tk.TagError{Tag: "Back End"}
+		th.AssertErrorMessage(t, err, err.Error(), "Back End")
+	})
+}
+
+func Test_FileTaskRepository_CreateTask_Tags(t *testing.T) {
+	testCases := []struct {
+		name    string
+		tags    []string
+		want    []string
+		wantErr error
+	}{
+		{"happy: sorts the tags", []string{"ops", "backend"},
+			[]string{"backend", "ops"}, nil},
+		{"happy: accepts digits, dashes and underscores",
+			[]string{"q3-goals", "on_call"}, []string{"on_call", "q3-goals"}, nil},
+		{"sad: rejects upper case", []string{"Backend"}, nil, &tk.TagError{}},
+		{"sad: rejects spaces", []string{"back end"}, nil, &tk.TagError{}},
+		{"sad: rejects a leading dash", []string{"-ops"}, nil, &tk.TagError{}},
+		{"sad: rejects a tag over 32 characters",
+			[]string{strings.Repeat("a", 33)}, nil, &tk.TagError{}},
+		{"edge: drops duplicates", []string{"ops", "ops"}, []string{"ops"}, nil},
+		{"edge: accepts a tag of 32 characters",
+			[]string{strings.Repeat("a", 32)},
+			[]string{strings.Repeat("a", 32)}, nil},
+		{"edge: rejects an empty tag", []string{""}, nil, &tk.TagError{}},
+		{"edge: stores no tags as nil", []string{}, nil, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			mockFs, taskRepo := setupTaskUnitTest(t)
+
+			got, err := taskRepo.CreateTask(tk.CreateTaskParams{
+				Description: "test_task_9",
+				Tags:        tc.tags,
+			})
+			if tc.wantErr != nil {
+				th.AssertError(t, err, tc.wantErr)
+				th.AssertDeepEqual(t, mockFs.Calls, Calls{LoadData})
+				return
+			}
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got.Tags, tc.want)
+			th.AssertDeepEqual(t, mockFs.Tasks[9].Tags, tc.want)
+		})
+	}
+}
+
+func Test_FileTaskRepository_UpdateTask_Tags(t *testing.T) {
+	testCases := []struct {
+		name      string
+		add       []string
+		remove    []string
+		want      []string
+		wantSaved bool
+		wantErr   error
+	}{
+		{"happy: adds tags to the others", []string{"docs"}, nil,
+			[]string{"backend", "docs", "ops"}, true, nil},
+		{"happy: removes a tag only", nil, []string{"ops"},
+			[]string{"backend"}, true, nil},
+		{"happy: adds and removes tags", []string{"docs"}, []string{"ops"},
+			[]string{"backend", "docs"}, true, nil},
+		{"sad: rejects an invalid tag", []string{"Docs"}, nil,
+			[]string{"backend", "ops"}, false, &tk.TagError{}},
+		{"edge: doesn't save a tag it already has", []string{"ops"}, nil,
+			[]string{"backend", "ops"}, false, nil},
+		{"edge: doesn't save removing a missing tag", nil, []string{"docs"},
+			[]string{"backend", "ops"}, false, nil},
+		{"edge: clears the last tags", nil, []string{"ops", "backend"},
+			nil, true, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			mockFs, taskRepo := setupTaskUnitTest(t)
+			task := mockFs.Tasks[1]
+			task.Tags = []string{"backend", "ops"}
+			mockFs.Tasks[1] = task
+
+			_, err := taskRepo.UpdateTask(tk.UpdateTaskParams{
+				ID:         1,
+				AddTags:    tc.add,
+				RemoveTags: tc.remove,
+			})
+			if tc.wantErr != nil {
+				th.AssertError(t, err, tc.wantErr)
+			} else {
+				th.AssertNoError(t, err)
+			}
+			th.AssertDeepEqual(t, mockFs.Tasks[1].Tags, tc.want)
+
+			wantCalls := Calls{LoadData}
+			if tc.wantSaved {
+				wantCalls = append(wantCalls, SaveData)
+			}
+			th.AssertDeepEqual(t, mockFs.Calls, wantCalls)
+		})
+	}
+}
+
+func Test_TaskFilter_Match_Tags(t *testing.T) {
+	tagged := func(tags ...string) tk.Task {
+		task := th.NewTestTask(1, "test_task_1", tk.Todo)
+		task.Tags = tags
+		return task
+	}
+
+	testCases := []struct {
+		name   string
+		filter tk.TaskFilter
+		task   tk.Task
+		want   bool
+	}{
+		{"happy: matches all the tags",
+			tk.TaskFilter{AllTags: []string{"backend", "ops"}},
+			tagged("backend", "docs", "ops"), true},
+		{"happy: matches any of the tags",
+			tk.TaskFilter{AnyTags: []string{"docs", "ops"}},
+			tagged("ops"), true},
+		{"happy: matches none of the tags",
+			tk.TaskFilter{NoTags: []string{"docs"}},
+			tagged("ops"), true},
+		{"sad: misses one of all the tags",
+			tk.TaskFilter{AllTags: []string{"backend", "ops"}},
+			tagged("ops"), false},
+		{"sad: misses every one of any tags",
+			tk.TaskFilter{AnyTags: []string{"docs", "ops"}},
+			tagged("backend"), false},
+		{"sad: has an excluded tag",
+			tk.TaskFilter{NoTags: []string{"docs"}},
+			tagged("docs", "ops"), false},
+		{"edge: matches a task without tags with no tag filter",
+			tk.TaskFilter{}, tagged(), true},
+		{"edge: matches a task without tags with an excluded tag",
+			tk.TaskFilter{NoTags: []string{"docs"}}, tagged(), true},
+		{"edge: combines the tag filters",
+			tk.TaskFilter{
+				AllTags: []string{"backend"},
+				AnyTags: []string{"ops", "docs"},
+				NoTags:  []string{"wip"},
+			},
+			tagged("backend", "docs"), true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			th.AssertDeepEqual(t, tc.filter.Match(tc.task), tc.want)
+		})
+	}
+}
+
+func Test_FileTaskRepository_CountTags(t *testing.T) {
+	t.Run("counts the tasks with each tag successfully", func(t *testing.T) {
+		mockFs, taskRepo := setupTaskUnitTest(t)
+		for id, tags := range map[uint][]string{
+			1: {"backend", "ops"},
+			2: {"ops"},
+			3: {"docs", "ops"},
+		} {
+			task := mockFs.Tasks[id]
+			task.Tags = tags
+			mockFs.Tasks[id] = task
+		}
+		_, err := taskRepo.DeleteTask(3)
+		th.AssertNoError(t, err)
+
+		got, err := taskRepo.CountTags()
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, got, map[string]int{"backend": 1, "ops": 2})
+	})
+
+	t.Run("returns an error when loading fails", func(t *testing.T) {
+		mockFs, taskRepo := setupTaskUnitTest(t)
+		mockFs.LoadError = &os.PathError{}
+
+		_, err := taskRepo.CountTags()
+		th.AssertError(t, err, &os.PathError{})
+	})
+}
@@ -1,6 +1,7 @@
 package task
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -17,18 +18,98 @@ const (
 
 type Task struct {
 	ID          uint
+	UID         string
 	Description string
 	Status      Status
+	Priority    Priority   `json:",omitempty"`
+	Due         *time.Time `json:",omitempty"`
+	Tags        []string   `json:",omitempty"`
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
+	StartedAt   *time.Time     `json:",omitempty"`
+	CompletedAt *time.Time     `json:",omitempty"`
+	DeletedAt   *time.Time     `json:",omitempty"`
+	ArchivedAt  *time.Time     `json:",omitempty"`
+	History     []StatusChange `json:",omitempty"`
+}
+
+// StatusChange records a task moving from one status to another.
+type StatusChange struct {
+	From Status
+	To   Status
+	At   time.Time
 }
 
 type Tasks map[uint]Task
 
+// TaskList is what the tasks file holds. LastID is the highest ID ever given to
+// a task, so the IDs of deleted tasks are never given again. Deleted tasks wait
+// in Trash until they're restored or purged.
+type TaskList struct {
+	LastID uint
+	Tasks  Tasks
+	Trash  Tasks `json:",omitempty"`
+}
+
+type CreateTaskParams struct {
+	Description string
+	Priority    Priority
+	Due         *time.Time
+	Tags        []string
+}
+
+// UpdateTaskParams holds the changes to a task; nil fields are left alone, and
+// a zero Due clears the due date. AddTags and RemoveTags edit the tags without
+// replacing them.
 type UpdateTaskParams struct {
 	ID          uint
 	Description *string
 	Status      *Status
+	Priority    *Priority
+	Due         *time.Time
+	AddTags     []string
+	RemoveTags  []string
+}
+
+// TaskFilter selects tasks. A zero field doesn't filter: an empty Status
+// matches every status, PriorityNone every priority, and no tags every task.
+// A task must have all of AllTags, at least one of AnyTags, and none of
+// NoTags.
+type TaskFilter struct {
+	Status      Status
+	MinPriority Priority
+	AllTags     []string
+	AnyTags     []string
+	NoTags      []string
+}
+
+func (f TaskFilter) Match(task Task) bool {
+	if f.Status != "" && task.Status != f.Status {
+		return false
+	}
+	if task.Priority < f.MinPriority {
+		return false
+	}
+
+	for _, tag := range f.AllTags {
+		if !hasTag(task.Tags, tag) {
+			return false
+		}
+	}
+	for _, tag := range f.NoTags {
+		if hasTag(task.Tags, tag) {
+			return false
+		}
+	}
+	if len(f.AnyTags) == 0 {
+		return true
+	}
+	for _, tag := range f.AnyTags {
+		if hasTag(task.Tags, tag) {
+			return true
+		}
+	}
+	return false
 }
 
 type TimeProvider interface {
@@ -36,14 +117,15 @@ type TimeProvider interface {
 }
 
 type IDGenerator interface {
-	Init(Tasks) uint
+	Init(TaskList) uint
 	NextID() uint
 }
 
 type TaskRepository interface {
-	CreateTask(string) (Task, error)
-	ReadAllTasks() (Tasks, error)
-	ReadManyTasks(Status) (Tasks, error)
+	CreateTask(CreateTaskParams) (Task, error)
+	ReadAllTasks(...ReadOption) (Tasks, error)
+	ReadManyTasks(Filter, ...ReadOption) (Tasks, error)
+	ListTasks(ListTasksParams, ...ReadOption) (TaskPage, error)
 	UpdateTask(UpdateTaskParams) (Task, error)
 	DeleteTask(uint) (Task, error)
 }
@@ -57,10 +139,14 @@ func (e *DescriptionError) Error() string {
 }
 
 type TaskNotFoundError struct {
-	ID uint
+	ID  uint
+	Ref string
 }
 
 func (e *TaskNotFoundError) Error() string {
+	if e.Ref != "" {
+		return fmt.Sprintf("task with ID %s not found", e.Ref)
+	}
 	return fmt.Sprintf("task with ID %d not found", e.ID)
 }
 
@@ -74,9 +160,11 @@ type TaskIDGenerator struct {
 	value uint
 }
 
-func (idg *TaskIDGenerator) Init(tasks Tasks) uint {
-	var max uint
-	for key := range tasks {
+// Init seeds the generator from the highest ID ever given, or from the highest
+// stored task ID if a file edited by hand holds a higher one.
+func (idg *TaskIDGenerator) Init(list TaskList) uint {
+	max := list.LastID
+	for key := range list.Tasks {
 		if key > max {
 			max = key
 		}
@@ -90,54 +178,139 @@ func (idg *TaskIDGenerator) NextID() uint {
 	return idg.value
 }
 
-type JSONFileTaskRepository struct {
-	Store        st.Store[Tasks]
+const DefaultLockTimeout = 5 * time.Second
+
+const maxDescriptionLength = 300
+
+type FileTaskRepository struct {
+	Store        st.Store[TaskList]
 	TimeProvider TimeProvider
 	IDGenerator  IDGenerator
+	UIDGenerator UIDGenerator
+	Workflow     *Workflow
+	Archive      *Archive
+	AutoArchive  *ArchivePolicy
+	LockTimeout  time.Duration
+	filepath     string
 }
 
-func (tr *JSONFileTaskRepository) CreateTask(
+func NewFileTaskRepository(
+	store st.Store[TaskList],
 	filepath string,
-	description string,
+	timeProvider TimeProvider,
+	idGenerator IDGenerator,
+) *FileTaskRepository {
+	return &FileTaskRepository{
+		Store:        store,
+		TimeProvider: timeProvider,
+		IDGenerator:  idGenerator,
+		UIDGenerator: &ULIDGenerator{},
+		Workflow:     DefaultWorkflow(),
+		LockTimeout:  DefaultLockTimeout,
+		filepath:     filepath,
+	}
+}
+
+func (tr *FileTaskRepository) Filepath() string {
+	return tr.filepath
+}
+
+func (tr *FileTaskRepository) CreateTask(
+	params CreateTaskParams,
 ) (Task, error) {
-	tasks, err := tr.Store.LoadData(filepath)
+	lock, err := tr.lock()
 	if err != nil {
-		return Task{}, fmt.Errorf("failed to load tasks data:\n>%w", err)
+		return Task{}, err
+	}
+	defer lock.Unlock()
+
+	list, err := tr.load()
+	if err != nil {
+		return Task{}, err
 	}
 
-	task, err := tr.newTask(description)
+	// the archive keeps the IDs it holds, which a restored tasks file may no
+	// longer count in its LastID
+	if tr.Archive != nil {
+		archive, err := tr.loadArchive()
+		if err != nil {
+			return Task{}, err
+		}
+		list.LastID = max(list.LastID, archive.LastID)
+		for id := range archive.Tasks {
+			list.LastID = max(list.LastID, id)
+		}
+	}
+
+	// another process may have added tasks since the generator was seeded
+	tr.IDGenerator.Init(list)
+
+	task, err := tr.newTask(params)
 	if err != nil {
 		return Task{}, fmt.Errorf("failed to build a new task:\n>%w", err)
 	}
 
-	tasks[task.ID] = task
-	if err := tr.Store.SaveData(tasks, filepath); err != nil {
-		return Task{}, fmt.Errorf("failed to save tasks data:\n>%w", err)
+	list.Tasks[task.ID] = task
+	list.LastID = max(list.LastID, task.ID)
+	if err := tr.save(list); err != nil {
+		return Task{}, err
 	}
 
 	return task, nil
 }
 
-func (tr *JSONFileTaskRepository) ReadAllTasks(filepath string) (Tasks, error) {
-	tasks, err := tr.Store.LoadData(filepath)
+// ReadAllTasks returns the tasks, without the trashed or archived ones unless
+// asked to with IncludeTrashed or IncludeArchived.
+func (tr *FileTaskRepository) ReadAllTasks(
+	opts ...ReadOption,
+) (Tasks, error) {
+	lock, err := tr.lock()
 	if err != nil {
-		return Tasks{}, fmt.Errorf("failed to load tasks data:\n>%w", err)
+		return Tasks{}, err
+	}
+	defer lock.Unlock()
+
+	list, err := tr.load()
+	if err != nil {
+		return Tasks{}, err
+	}
+
+	var extra []Tasks
+	if includes(opts, IncludeTrashed) {
+		extra = append(extra, list.Trash)
+	}
+	if includes(opts, IncludeArchived) {
+		archive, err := tr.loadArchive()
+		if err != nil {
+			return Tasks{}, err
+		}
+		extra = append(extra, archive.Tasks)
+	}
+	if len(extra) == 0 {
+		return list.Tasks, nil
+	}
+
+	tasks := Tasks{}
+	for _, more := range append(extra, list.Tasks) {
+		for id, task := range more {
+			tasks[id] = task
+		}
 	}
 	return tasks, nil
 }
 
-func (tr *JSONFileTaskRepository) ReadManyTasks(
-	filepath string,
-	status Status,
+func (tr *FileTaskRepository) ReadManyTasks(
+	filter Filter,
+	opts ...ReadOption,
 ) (Tasks, error) {
-	tasks, err := tr.ReadAllTasks(filepath)
+	tasks, err := tr.ReadAllTasks(opts...)
 	if err != nil {
 		return Tasks{}, err
 	}
 
 	var filteredTasks = make(Tasks)
 	for _, task := range tasks {
-		if task.Status == status {
+		if filter.Match(task) {
 			filteredTasks[task.ID] = task
 		}
 	}
@@ -145,99 +318,443 @@ func (tr *JSONFileTaskRepository) ReadManyTasks(
 	return filteredTasks, nil
 }
 
-func (tr *JSONFileTaskRepository) UpdateTask(
-	filepath string,
+func (tr *FileTaskRepository) UpdateTask(
 	update UpdateTaskParams,
 ) (Task, error) {
-	tasks, err := tr.Store.LoadData(filepath)
+	lock, err := tr.lock()
 	if err != nil {
-		return Task{}, fmt.Errorf("failed to load tasks data:\n>%w", err)
+		return Task{}, err
 	}
+	defer lock.Unlock()
 
-	updateTask, err := tr.findByID(tasks, update.ID)
+	list, err := tr.load()
 	if err != nil {
 		return Task{}, err
 	}
 
-	if update.Description == nil && update.Status == nil {
-		return updateTask, nil
+	updateTask, err := tr.findByID(list.Tasks, update.ID)
+	if err != nil {
+		return Task{}, err
 	}
 
-	if update.Description != nil {
+	changed := false
+
+	if update.Description != nil && *update.Description != updateTask.Description {
 		if err := tr.validateDescription(*update.Description); err != nil {
 			return Task{}, err
 		}
 		updateTask.Description = *update.Description
+		changed = true
 	}
 
 	if update.Status != nil {
-		updateTask.Status = *update.Status
+		err := tr.Workflow.Check(updateTask.Status, *update.Status)
+		if err != nil {
+			return Task{}, err
+		}
+		if *update.Status != updateTask.Status {
+			now := tr.TimeProvider.Now()
+			updateTask.setStatus(*update.Status, now, tr.Workflow)
+			changed = true
+		}
 	}
 
-	tasks[update.ID] = updateTask
-	if err := tr.Store.SaveData(tasks, filepath); err != nil {
-		return Task{}, fmt.Errorf("failed to save tasks data:\n>%w", err)
+	if update.Priority != nil && *update.Priority != updateTask.Priority {
+		if err := update.Priority.validate(); err != nil {
+			return Task{}, err
+		}
+		updateTask.Priority = *update.Priority
+		changed = true
+	}
+
+	if len(update.AddTags) > 0 || len(update.RemoveTags) > 0 {
+		if err := validateTags(update.AddTags); err != nil {
+			return Task{}, err
+		}
+		tags := editTags(updateTask.Tags, update.AddTags, update.RemoveTags)
+		if !equalTags(tags, updateTask.Tags) {
+			updateTask.Tags = tags
+			changed = true
+		}
+	}
+
+	if update.Due != nil && !sameDue(updateTask.Due, *update.Due) {
+		updateTask.Due = nil
+		if !update.Due.IsZero() {
+			due := *update.Due
+			updateTask.Due = &due
+		}
+		changed = true
+	}
+
+	if !changed {
+		return updateTask, nil
+	}
+	updateTask.UpdatedAt = tr.TimeProvider.Now()
+
+	list.Tasks[update.ID] = updateTask
+	if err := tr.save(list); err != nil {
+		return Task{}, err
 	}
 
 	return updateTask, nil
 }
 
-func (tr *JSONFileTaskRepository) DeleteTask(
-	filepath string,
+// TransitionTask moves a task through the named workflow transition.
+func (tr *FileTaskRepository) TransitionTask(
 	id uint,
+	name string,
 ) (Task, error) {
-	tasks, err := tr.Store.LoadData(filepath)
+	lock, err := tr.lock()
+	if err != nil {
+		return Task{}, err
+	}
+	defer lock.Unlock()
+
+	list, err := tr.load()
+	if err != nil {
+		return Task{}, err
+	}
+
+	task, err := tr.findByID(list.Tasks, id)
+	if err != nil {
+		return Task{}, err
+	}
+
+	status, err := tr.Workflow.Apply(name, task.Status)
+	if err != nil {
+		return Task{}, err
+	}
+	now := tr.TimeProvider.Now()
+	task.setStatus(status, now, tr.Workflow)
+	task.UpdatedAt = now
+
+	list.Tasks[id] = task
+	if err := tr.save(list); err != nil {
+		return Task{}, err
+	}
+
+	return task, nil
+}
+
+func (tr *FileTaskRepository) DeleteTask(id uint) (Task, error) {
+	lock, err := tr.lock()
+	if err != nil {
+		return Task{}, err
+	}
+	defer lock.Unlock()
+
+	list, err := tr.load()
 	if err != nil {
-		return Task{}, fmt.Errorf("failed to load tasks data:\n>%w", err)
+		return Task{}, err
 	}
 
-	_, err = tr.findByID(tasks, id)
+	task, err := tr.findByID(list.Tasks, id)
 	if err != nil {
 		return Task{}, err
 	}
 
-	delete(tasks, id)
+	now := tr.TimeProvider.Now()
+	task.DeletedAt = &now
+
+	delete(list.Tasks, id)
+	if list.Trash == nil {
+		list.Trash = Tasks{}
+	}
+	list.Trash[id] = task
+
+	if err := tr.save(list); err != nil {
+		return Task{}, err
+	}
+
+	return task, nil
+}
+
+// ResolveID returns the numeric ID of the task that ref names, see ResolveID.
+func (tr *FileTaskRepository) ResolveID(
+	ref string,
+	opts ...ReadOption,
+) (uint, error) {
+	tasks, err := tr.ReadAllTasks(opts...)
+	if err != nil {
+		return 0, err
+	}
+	return ResolveID(tasks, ref)
+}
+
+func (tr *FileTaskRepository) CheckIntegrity() ([]Issue, error) {
+	tasks, err := tr.ReadAllTasks()
+	if err != nil {
+		return nil, err
+	}
+	return CheckTasks(tasks, tr.Workflow), nil
+}
+
+// FixIntegrity normalises what FixTasks can fix safely and saves the tasks if
+// anything changed. It returns every issue found.
+func (tr *FileTaskRepository) FixIntegrity() ([]Issue, error) {
+	lock, err := tr.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	list, err := tr.load()
+	if err != nil {
+		return nil, err
+	}
 
-	if err := tr.Store.SaveData(tasks, filepath); err != nil {
-		return Task{}, fmt.Errorf("failed to save tasks data:\n>%w", err)
+	issues := FixTasks(list.Tasks, tr.Workflow)
+	for _, issue := range issues {
+		if issue.Fixed {
+			if err := tr.save(list); err != nil {
+				return nil, err
+			}
+			break
+		}
 	}
 
-	return tasks[id], nil
+	return issues, nil
 }
 
-func (tr *JSONFileTaskRepository) newTask(desc string) (Task, error) {
-	if err := tr.validateDescription(desc); err != nil {
+func (tr *FileTaskRepository) Backups() ([]st.Backup, error) {
+	store, err := tr.backupStore()
+	if err != nil {
+		return nil, err
+	}
+
+	backups, err := store.Backups(tr.filepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups:\n>%w", err)
+	}
+	return backups, nil
+}
+
+func (tr *FileTaskRepository) RestoreBackup(name string) error {
+	store, err := tr.backupStore()
+	if err != nil {
+		return err
+	}
+
+	lock, err := tr.lock()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	err = store.RestoreBackup(name, tr.filepath)
+	var notFoundErr *st.BackupNotFoundError
+	if errors.As(err, &notFoundErr) && tr.Archive != nil {
+		// the backups of the archive sit next to those of the tasks file
+		if archiveStore, ok := tr.Archive.Store.(st.BackupStore); ok {
+			err = archiveStore.RestoreBackup(name, tr.Archive.Filepath)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restore backup:\n>%w", err)
+	}
+	return nil
+}
+
+// Repair repairs a corrupt tasks file, see st.FileStore.Repair. A truncated
+// file also loses, unseen, the tasks that followed: every ID up to LastID
+// that isn't recovered, trashed or archived is reported lost too.
+func (tr *FileTaskRepository) Repair() (st.RepairReport, error) {
+	store, ok := tr.Store.(st.RepairStore)
+	if !ok {
+		return st.RepairReport{}, errors.New("the tasks store can't be repaired")
+	}
+
+	lock, err := tr.lock()
+	if err != nil {
+		return st.RepairReport{}, err
+	}
+	defer lock.Unlock()
+
+	report, err := store.Repair(tr.filepath)
+	if err != nil {
+		return st.RepairReport{}, fmt.Errorf("failed to repair tasks data:\n>%w", err)
+	}
+
+	for _, path := range report.Lost {
+		if path != st.TruncatedPath {
+			continue
+		}
+		unrecovered, err := tr.unrecovered(report.Lost)
+		if err != nil {
+			return st.RepairReport{}, err
+		}
+		report.Lost = append(report.Lost, unrecovered...)
+		break
+	}
+	return report, nil
+}
+
+// unrecovered returns the paths of the IDs up to LastID found nowhere, nor
+// in lost. Tasks purged from the trash can't be told apart from them.
+func (tr *FileTaskRepository) unrecovered(lost []string) ([]string, error) {
+	list, err := tr.load()
+	if err != nil {
+		return nil, err
+	}
+
+	archived := Tasks{}
+	if tr.Archive != nil {
+		archive, err := tr.loadArchive()
+		if err != nil {
+			return nil, err
+		}
+		archived = archive.Tasks
+	}
+
+	reported := map[string]bool{}
+	for _, path := range lost {
+		reported[path] = true
+	}
+
+	var paths []string
+	for id := uint(1); id <= list.LastID; id++ {
+		_, inTasks := list.Tasks[id]
+		_, inTrash := list.Trash[id]
+		_, inArchive := archived[id]
+		if inTasks || inTrash || inArchive {
+			continue
+		}
+		path := fmt.Sprintf("data.Tasks.%d", id)
+		if reported[path] || reported[fmt.Sprintf("data.Trash.%d", id)] {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func (tr *FileTaskRepository) backupStore() (st.BackupStore, error) {
+	store, ok := tr.Store.(st.BackupStore)
+	if !ok {
+		return nil, errors.New("the tasks store keeps no backups")
+	}
+	return store, nil
+}
+
+func (tr *FileTaskRepository) load() (TaskList, error) {
+	list, err := tr.Store.LoadData(tr.filepath)
+	if err != nil {
+		return TaskList{}, fmt.Errorf("failed to load tasks data:\n>%w", err)
+	}
+	if list.Tasks == nil {
+		list.Tasks = Tasks{}
+	}
+	return list, nil
+}
+
+// save writes the tasks, archiving first the done ones the AutoArchive policy
+// asks for.
+func (tr *FileTaskRepository) save(list TaskList) error {
+	if tr.AutoArchive != nil && tr.Archive != nil {
+		if _, err := tr.archiveDone(list, tr.AutoArchive.After); err != nil {
+			return err
+		}
+	}
+	return tr.writeTasks(list)
+}
+
+func (tr *FileTaskRepository) writeTasks(list TaskList) error {
+	if err := tr.Store.SaveData(list, tr.filepath); err != nil {
+		return fmt.Errorf("failed to save tasks data:\n>%w", err)
+	}
+	return nil
+}
+
+func (tr *FileTaskRepository) lock() (*st.FileLock, error) {
+	lock, err := st.LockFile(tr.filepath+".lock", tr.LockTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock tasks data:\n>%w", err)
+	}
+	return lock, nil
+}
+
+func (tr *FileTaskRepository) newTask(
+	params CreateTaskParams,
+) (Task, error) {
+	if err := tr.validateDescription(params.Description); err != nil {
+		return Task{}, err
+	}
+	if err := params.Priority.validate(); err != nil {
+		return Task{}, err
+	}
+	if err := validateTags(params.Tags); err != nil {
 		return Task{}, err
 	}
 	now := tr.TimeProvider.Now()
+	uid, err := tr.UIDGenerator.NewUID(now)
+	if err != nil {
+		return Task{}, err
+	}
 	return Task{
 		ID:          tr.IDGenerator.NextID(),
-		Description: desc,
-		Status:      Todo,
+		UID:         uid,
+		Description: params.Description,
+		Status:      tr.Workflow.Initial(),
+		Priority:    params.Priority,
+		Due:         params.Due,
+		Tags:        editTags(nil, params.Tags, nil),
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}, nil
 }
 
-func (tr *JSONFileTaskRepository) validateDescription(desc string) error {
+// setStatus moves the task to status, records the change, and keeps the
+// timestamps in step with workflow: StartedAt is set when work first starts,
+// CompletedAt when the task reaches a final status, and cleared when it leaves
+// it.
+func (t *Task) setStatus(status Status, at time.Time, workflow *Workflow) {
+	t.History = append(t.History, StatusChange{From: t.Status, To: status, At: at})
+	t.Status = status
+
+	switch {
+	case workflow.Final(status):
+		t.CompletedAt = &at
+	case workflow.Started(status):
+		if t.StartedAt == nil {
+			t.StartedAt = &at
+		}
+		t.CompletedAt = nil
+	default:
+		t.CompletedAt = nil
+	}
+}
+
+// sameDue reports whether setting due would leave current as it is, a zero
+// due standing for no due date.
+func sameDue(current *time.Time, due time.Time) bool {
+	if current == nil {
+		return due.IsZero()
+	}
+	return current.Equal(due)
+}
+
+func (tr *FileTaskRepository) validateDescription(desc string) error {
 	if len(desc) == 0 {
 		return &DescriptionError{
 			Message: fmt.Sprintf("description can't be empty"),
 		}
 	}
-	if len(desc) > 300 {
+	if len(desc) > maxDescriptionLength {
 		return &DescriptionError{
-			Message: "description can't be more than 300 characters, " +
-				fmt.Sprintf("but got %d characters", len(desc)),
+			Message: fmt.Sprintf("description can't be more than %d characters, "+
+				"but got %d characters", maxDescriptionLength, len(desc)),
 		}
 	}
 	return nil
 }
 
-func (tr *JSONFileTaskRepository) findByID(tasks Tasks, id uint) (Task, error) {
+func (tr *FileTaskRepository) findByID(tasks Tasks, id uint) (Task, error) {
 	updateTask, ok := tasks[id]
 	if !ok {
 		return Task{}, &TaskNotFoundError{ID: id}
 	}
 	return updateTask, nil
 }
+
+var _ TaskRepository = (*FileTaskRepository)(nil)
@@ -3,10 +3,12 @@ package task_test
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	st "github.com/alnah/task-tracker/internal/store"
 	tk "github.com/alnah/task-tracker/internal/task"
 	th "github.com/alnah/task-tracker/test_helpers"
 )
@@ -44,20 +46,33 @@ func Test_TaskIDGenerator_Init(t *testing.T) {
 	t.Run("initializes the ID generator successfully", func(t *testing.T) {
 		testTasks := th.NewTestTasks()
 		testCases := []struct {
-			name  string
-			tasks tk.Tasks
-			want  uint
+			name   string
+			lastID uint
+			tasks  tk.Tasks
+			want   uint
 		}{
 			{
 				name:  "happy: initializes with the highest existing task ID",
 				tasks: tk.Tasks{1: testTasks[1], 2: testTasks[2], 5: testTasks[3]},
 				want:  5,
 			},
+			{
+				name:   "happy: initializes with the highest ID ever given",
+				lastID: 9,
+				tasks:  tk.Tasks{1: testTasks[1], 2: testTasks[2]},
+				want:   9,
+			},
 			{
 				name:  "sad: initializes with an empty tasks map",
 				tasks: tk.Tasks{},
 				want:  0,
 			},
+			{
+				name:   "edge: initializes with a task ID above the highest ID",
+				lastID: 2,
+				tasks:  tk.Tasks{1: testTasks[1], 4: testTasks[4]},
+				want:   4,
+			},
 			{
 				name:  "edge: handles empty individual tasks",
 				tasks: tk.Tasks{1: tk.Task{}, 2: tk.Task{}, 3: tk.Task{}},
@@ -70,7 +85,7 @@ func Test_TaskIDGenerator_Init(t *testing.T) {
 				t.Parallel()
 
 				idGen := tk.TaskIDGenerator{}
-				got := idGen.Init(tc.tasks)
+				got := idGen.Init(tk.TaskList{LastID: tc.lastID, Tasks: tc.tasks})
 
 				if got != tc.want {
 					t.Errorf("got %v, want %v", got, tc.want)
@@ -110,7 +125,7 @@ func Test_TaskIDGenerator_NextID(t *testing.T) {
 				t.Parallel()
 
 				idGen := tk.TaskIDGenerator{}
-				idGen.Init(tc.tasks)
+				idGen.Init(tk.TaskList{Tasks: tc.tasks})
 
 				got := idGen.NextID()
 
@@ -122,12 +137,35 @@ func Test_TaskIDGenerator_NextID(t *testing.T) {
 	})
 }
 
-func Test_JSONFileTaskRepository_CreateTask_Happy(t *testing.T) {
+func Test_NewFileTaskRepository(t *testing.T) {
+	t.Run("binds the file location at construction successfully",
+		func(t *testing.T) {
+			mockFs := &MockFileStore[tk.TaskList]{Tasks: th.NewTestTasks()}
+			path := filepath.Join(t.TempDir(), "tasks.json")
+			var taskRepo tk.TaskRepository = tk.NewFileTaskRepository(
+				mockFs,
+				path,
+				&th.StubTimeProvider{FixedTime: th.FixedTime},
+				&tk.TaskIDGenerator{},
+			)
+
+			_, err := taskRepo.ReadAllTasks()
+			th.AssertNoError(t, err)
+
+			jsonRepo := taskRepo.(*tk.FileTaskRepository)
+			th.AssertDeepEqual(t, jsonRepo.Filepath(), path)
+			th.AssertDeepEqual(t, mockFs.Calls, Calls{LoadData})
+		})
+}
+
+func Test_FileTaskRepository_CreateTask_Happy(t *testing.T) {
 	t.Run("returns a task successfully", func(t *testing.T) {
-		_, taskRepo, file := setupTaskUnitTest(t)
+		_, taskRepo := setupTaskUnitTest(t) // tasks 1 to 8 already exist
 
-		wantTask := th.NewTestTask(1, "test_task_1", tk.Todo)
-		gotTask, err := taskRepo.CreateTask(file.Name(), wantTask.Description)
+		wantTask := th.NewTestTask(9, "test_task_9", tk.Todo)
+		gotTask, err := taskRepo.CreateTask(tk.CreateTaskParams{
+			Description: wantTask.Description,
+		})
 
 		th.AssertNoError(t, err)
 		th.AssertDeepEqual(t, gotTask, wantTask)
@@ -136,7 +174,7 @@ func Test_JSONFileTaskRepository_CreateTask_Happy(t *testing.T) {
 	t.Run("calls Store.LoadData and Store.SaveData for each task created "+
 		"successfully",
 		func(t *testing.T) {
-			mockFs, taskRepo, file := setupTaskUnitTest(t)
+			mockFs, taskRepo := setupTaskUnitTest(t)
 
 			numTasksToCreate := len(mockFs.Tasks)
 			taskDescriptions := make([]string, numTasksToCreate)
@@ -145,20 +183,197 @@ func Test_JSONFileTaskRepository_CreateTask_Happy(t *testing.T) {
 			}
 
 			for _, description := range taskDescriptions {
-				_, err := taskRepo.CreateTask(file.Name(), description)
+				_, err := taskRepo.CreateTask(tk.CreateTaskParams{
+					Description: description,
+				})
 				th.AssertNoError(t, err)
 			}
 
 			wantCalls := make(Calls, 0)
-			for range mockFs.Tasks {
+			for range numTasksToCreate {
 				wantCalls = append(wantCalls, LoadData, SaveData)
 			}
 
 			th.AssertDeepEqual(t, wantCalls, mockFs.Calls)
 		})
+
+	t.Run("seeds the ID generator from the stored tasks on each creation "+
+		"successfully",
+		func(t *testing.T) {
+			mockFs, taskRepo := setupTaskUnitTest(t)
+			mockFs.Tasks = tk.Tasks{}
+
+			// another process adds a task behind the repository's back
+			mockFs.Tasks[1] = th.NewTestTask(1, "test_task_1", tk.Todo)
+
+			got, err := taskRepo.CreateTask(tk.CreateTaskParams{
+				Description: "test_task_2",
+			})
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got.ID, uint(2))
+		})
+
+	t.Run("never reuses the ID of a deleted task successfully",
+		func(t *testing.T) {
+			mockFs, taskRepo := setupTaskUnitTest(t) // tasks 1 to 8 already exist
+			mockFs.LastID = 8
+
+			_, err := taskRepo.DeleteTask(8)
+			th.AssertNoError(t, err)
+
+			got, err := taskRepo.CreateTask(tk.CreateTaskParams{
+				Description: "test_task_9",
+			})
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got.ID, uint(9))
+			th.AssertDeepEqual(t, mockFs.LastID, uint(9))
+		})
+}
+
+func Test_FileTaskRepository_Lock(t *testing.T) {
+	t.Run("returns a LockTimeoutError while another process holds the lock",
+		func(t *testing.T) {
+			mockFs, taskRepo := setupTaskUnitTest(t)
+			taskRepo.LockTimeout = 20 * time.Millisecond
+
+			lock, err := st.LockFile(taskRepo.Filepath()+".lock", time.Second)
+			th.AssertNoError(t, err)
+			defer lock.Unlock()
+
+			_, err = taskRepo.CreateTask(tk.CreateTaskParams{
+				Description: "test_task",
+			})
+			th.AssertError(t, err, &st.LockTimeoutError{})
+			th.AssertDeepEqual(t, mockFs.Calls, Calls(nil))
+		})
+
+	t.Run("releases the lock after each operation successfully",
+		func(t *testing.T) {
+			_, taskRepo := setupTaskUnitTest(t)
+
+			_, err := taskRepo.CreateTask(tk.CreateTaskParams{
+				Description: "test_task",
+			})
+			th.AssertNoError(t, err)
+
+			lock, err := st.LockFile(taskRepo.Filepath()+".lock", 0)
+			th.AssertNoError(t, err)
+			th.AssertNoError(t, lock.Unlock())
+		})
+}
+
+func Test_FileTaskRepository_ResolveID(t *testing.T) {
+	t.Run("resolves a prefix of a stored long ID successfully",
+		func(t *testing.T) {
+			mockFs, taskRepo := setupTaskUnitTest(t)
+			mockFs.Tasks = tk.Tasks{2: th.NewTestTask(2, "test_task_2", tk.Todo)}
+
+			got, err := taskRepo.ResolveID(th.NewTestUID(2)[:22])
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got, uint(2))
+			th.AssertDeepEqual(t, mockFs.Calls, Calls{LoadData})
+		})
+
+	t.Run("returns an error when loading fails", func(t *testing.T) {
+		mockFs, taskRepo := setupTaskUnitTest(t)
+		mockFs.LoadError = &os.PathError{}
+
+		_, err := taskRepo.ResolveID("01J")
+		th.AssertError(t, err, &os.PathError{})
+	})
 }
 
-func Test_JSONFileTaskRepository_CreateTask_Sad_Edge(t *testing.T) {
+func Test_FileTaskRepository_Integrity(t *testing.T) {
+	t.Run("checks the tasks without saving them successfully",
+		func(t *testing.T) {
+			mockFs, taskRepo := setupTaskUnitTest(t)
+
+			issues, err := taskRepo.CheckIntegrity()
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, len(issues), 3)
+			th.AssertDeepEqual(t, mockFs.Calls, Calls{LoadData})
+		})
+
+	t.Run("saves the fixed tasks successfully", func(t *testing.T) {
+		mockFs, taskRepo := setupTaskUnitTest(t)
+
+		issues, err := taskRepo.FixIntegrity()
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, len(issues), 3)
+		th.AssertDeepEqual(t, mockFs.Calls, Calls{LoadData, SaveData})
+		th.AssertDeepEqual(t, mockFs.Tasks[8].ID, uint(8))
+	})
+
+	t.Run("doesn't save consistent tasks", func(t *testing.T) {
+		mockFs, taskRepo := setupTaskUnitTest(t)
+		mockFs.Tasks = tk.Tasks{1: th.NewTestTask(1, "test_task_1", tk.Todo)}
+
+		issues, err := taskRepo.FixIntegrity()
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, len(issues), 0)
+		th.AssertDeepEqual(t, mockFs.Calls, Calls{LoadData})
+	})
+}
+
+func Test_FileTaskRepository_Backups(t *testing.T) {
+	t.Run("returns an error when the store keeps no backups", func(t *testing.T) {
+		mockFs, taskRepo := setupTaskUnitTest(t)
+
+		_, err := taskRepo.Backups()
+		th.AssertNotNil(t, err)
+
+		err = taskRepo.RestoreBackup("tasks-20060102T150405.000Z.json")
+		th.AssertNotNil(t, err)
+		th.AssertDeepEqual(t, mockFs.Calls, Calls(nil))
+	})
+}
+
+func Test_FileTaskRepository_CreateTask_Priority(t *testing.T) {
+	t.Run("returns a task with a priority successfully", func(t *testing.T) {
+		mockFs, taskRepo := setupTaskUnitTest(t)
+
+		want := th.NewTestTask(9, "test_task_9", tk.Todo)
+		want.Priority = tk.PriorityHigh
+		got, err := taskRepo.CreateTask(tk.CreateTaskParams{
+			Description: "test_task_9",
+			Priority:    tk.PriorityHigh,
+		})
+
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, got, want)
+		th.AssertDeepEqual(t, mockFs.Tasks[9], want)
+	})
+
+	t.Run("returns a task with a due date successfully", func(t *testing.T) {
+		mockFs, taskRepo := setupTaskUnitTest(t)
+
+		due := th.FixedTime.Add(24 * time.Hour)
+		want := th.NewTestTask(9, "test_task_9", tk.Todo)
+		want.Due = &due
+		got, err := taskRepo.CreateTask(tk.CreateTaskParams{
+			Description: "test_task_9",
+			Due:         &due,
+		})
+
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, got, want)
+		th.AssertDeepEqual(t, mockFs.Tasks[9], want)
+	})
+
+	t.Run("returns a PriorityError for an unknown priority", func(t *testing.T) {
+		mockFs, taskRepo := setupTaskUnitTest(t)
+
+		_, err := taskRepo.CreateTask(tk.CreateTaskParams{
+			Description: "test_task_9",
+			Priority:    tk.PriorityCritical + 1,
+		})
+
+		th.AssertError(t, err, &tk.PriorityError{})
+		th.AssertDeepEqual(t, mockFs.Calls, Calls{LoadData})
+	})
+}
+
+func Test_FileTaskRepository_CreateTask_Sad_Edge(t *testing.T) {
 	t.Run("returns an error successfully", func(t *testing.T) {
 		testCases := []struct {
 			name      string
@@ -196,11 +411,13 @@ func Test_JSONFileTaskRepository_CreateTask_Sad_Edge(t *testing.T) {
 		for _, tc := range testCases {
 			t.Run(tc.name, func(t *testing.T) {
 				t.Parallel()
-				mockFs, taskRepo, file := setupTaskUnitTest(t)
+				mockFs, taskRepo := setupTaskUnitTest(t)
 				mockFs.LoadError = tc.loadError
 				mockFs.SaveError = tc.saveError
 
-				_, err := taskRepo.CreateTask(file.Name(), tc.desc)
+				_, err := taskRepo.CreateTask(tk.CreateTaskParams{
+					Description: tc.desc,
+				})
 
 				switch {
 				case tc.loadError != nil || tc.saveError != nil:
@@ -213,7 +430,7 @@ func Test_JSONFileTaskRepository_CreateTask_Sad_Edge(t *testing.T) {
 	})
 }
 
-func Test_JSONFileTaskRepository_UpdateTask_Happy(t *testing.T) {
+func Test_FileTaskRepository_UpdateTask_Happy(t *testing.T) {
 	t.Run("returns an updated task successfully", func(t *testing.T) {
 		updateDescription := "update_test"
 		updateStatus := tk.InProgress
@@ -236,23 +453,23 @@ func Test_JSONFileTaskRepository_UpdateTask_Happy(t *testing.T) {
 				id:     2,
 				desc:   nil,
 				status: &updateStatus,
-				want:   th.NewTestTask(2, "test_task_2", updateStatus),
+				want:   started(th.NewTestTask(2, "test_task_2", updateStatus)),
 			},
 			{
 				name:   "returns a task with an updated description and status",
 				id:     3,
 				desc:   &updateDescription,
 				status: &updateStatus,
-				want:   th.NewTestTask(3, updateDescription, updateStatus),
+				want:   started(th.NewTestTask(3, updateDescription, updateStatus)),
 			},
 		}
 
 		for _, tc := range testCases {
 			t.Run(tc.name, func(t *testing.T) {
 				t.Parallel()
-				_, taskRepo, file := setupTaskUnitTest(t)
+				_, taskRepo := setupTaskUnitTest(t)
 
-				got, err := taskRepo.UpdateTask(file.Name(), tk.UpdateTaskParams{
+				got, err := taskRepo.UpdateTask(tk.UpdateTaskParams{
 					ID:          tc.id,
 					Description: tc.desc,
 					Status:      tc.status,
@@ -267,11 +484,11 @@ func Test_JSONFileTaskRepository_UpdateTask_Happy(t *testing.T) {
 	t.Run("calls Store.LoadData and Store.SaveData for each task updated "+
 		"successfully",
 		func(t *testing.T) {
-			mockFs, taskRepo, file := setupTaskUnitTest(t)
+			mockFs, taskRepo := setupTaskUnitTest(t)
 
 			for _, task := range mockFs.Tasks {
-				updateDescription := "updated_task"
-				_, err := taskRepo.UpdateTask(file.Name(), tk.UpdateTaskParams{
+				updateDescription := "updated_" + task.Description
+				_, err := taskRepo.UpdateTask(tk.UpdateTaskParams{
 					ID:          task.ID,
 					Description: &updateDescription,
 				})
@@ -287,7 +504,102 @@ func Test_JSONFileTaskRepository_UpdateTask_Happy(t *testing.T) {
 		})
 }
 
-func Test_JSONFileTaskRepository_UpdateTask_Sad_Edge(t *testing.T) {
+func Test_FileTaskRepository_UpdateTask_Priority(t *testing.T) {
+	t.Run("returns a task with an updated priority successfully",
+		func(t *testing.T) {
+			mockFs, taskRepo := setupTaskUnitTest(t)
+			later := th.FixedTime.Add(time.Hour)
+			taskRepo.TimeProvider = &th.StubTimeProvider{FixedTime: later}
+
+			want := th.NewTestTask(1, "test_task_1", tk.Todo)
+			want.Priority = tk.PriorityLow
+			want.UpdatedAt = later
+			priority := tk.PriorityLow
+			got, err := taskRepo.UpdateTask(tk.UpdateTaskParams{
+				ID:       1,
+				Priority: &priority,
+			})
+
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got, want)
+			th.AssertDeepEqual(t, mockFs.Tasks[1], want)
+		})
+
+	t.Run("doesn't save an unchanged priority", func(t *testing.T) {
+		mockFs, taskRepo := setupTaskUnitTest(t)
+
+		priority := tk.PriorityNone
+		_, err := taskRepo.UpdateTask(tk.UpdateTaskParams{
+			ID:       1,
+			Priority: &priority,
+		})
+
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, mockFs.Calls, Calls{LoadData})
+	})
+
+	t.Run("returns a PriorityError for an unknown priority", func(t *testing.T) {
+		_, taskRepo := setupTaskUnitTest(t)
+
+		priority := tk.Priority(-1)
+		_, err := taskRepo.UpdateTask(tk.UpdateTaskParams{
+			ID:       1,
+			Priority: &priority,
+		})
+
+		th.AssertError(t, err, &tk.PriorityError{})
+	})
+}
+
+func Test_FileTaskRepository_UpdateTask_Due(t *testing.T) {
+	due := th.FixedTime.Add(24 * time.Hour)
+
+	testCases := []struct {
+		name      string
+		current   *time.Time
+		due       time.Time
+		want      *time.Time
+		wantSaved bool
+	}{
+		{"happy: sets a due date", nil, due, &due, true},
+		{"happy: moves a due date", ptr(due.Add(time.Hour)), due, &due, true},
+		{"happy: clears a due date", &due, time.Time{}, nil, true},
+		{"edge: doesn't save the same due date", &due, due.In(time.Local),
+			&due, false},
+		{"edge: doesn't save clearing no due date", nil, time.Time{}, nil,
+			false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			mockFs, taskRepo := setupTaskUnitTest(t)
+			task := mockFs.Tasks[1]
+			task.Due = tc.current
+			mockFs.Tasks[1] = task
+
+			got, err := taskRepo.UpdateTask(tk.UpdateTaskParams{
+				ID:  1,
+				Due: &tc.due,
+			})
+
+			th.AssertNoError(t, err)
+			if tc.want == nil || got.Due == nil {
+				th.AssertDeepEqual(t, got.Due, tc.want)
+			} else if !got.Due.Equal(*tc.want) {
+				t.Errorf("got due %v, want %v", got.Due, tc.want)
+			}
+
+			wantCalls := Calls{LoadData}
+			if tc.wantSaved {
+				wantCalls = append(wantCalls, SaveData)
+			}
+			th.AssertDeepEqual(t, mockFs.Calls, wantCalls)
+		})
+	}
+}
+
+func Test_FileTaskRepository_UpdateTask_Sad_Edge(t *testing.T) {
 	t.Run("returns an error successfully", func(t *testing.T) {
 		updateDescription := "updated_task_1"
 		emptyDescription := ""
@@ -341,11 +653,11 @@ func Test_JSONFileTaskRepository_UpdateTask_Sad_Edge(t *testing.T) {
 			t.Run(tc.name, func(t *testing.T) {
 				t.Parallel()
 
-				mockFs, taskRepo, file := setupTaskUnitTest(t)
+				mockFs, taskRepo := setupTaskUnitTest(t)
 				mockFs.LoadError = tc.loadError
 				mockFs.SaveError = tc.saveError
 
-				_, err := taskRepo.UpdateTask(file.Name(), tk.UpdateTaskParams{
+				_, err := taskRepo.UpdateTask(tk.UpdateTaskParams{
 					ID:          tc.id,
 					Description: tc.description,
 				})
@@ -365,9 +677,9 @@ func Test_JSONFileTaskRepository_UpdateTask_Sad_Edge(t *testing.T) {
 	t.Run("returns the original task when no updates are provided and "+
 		"calls store.LoadData without calling store.SaveData",
 		func(t *testing.T) {
-			mockFs, taskRepo, file := setupTaskUnitTest(t)
+			mockFs, taskRepo := setupTaskUnitTest(t)
 			for _, task := range mockFs.Tasks {
-				_, err := taskRepo.UpdateTask(file.Name(), tk.UpdateTaskParams{
+				_, err := taskRepo.UpdateTask(tk.UpdateTaskParams{
 					ID: task.ID,
 				})
 				th.AssertNoError(t, err)
@@ -382,18 +694,201 @@ func Test_JSONFileTaskRepository_UpdateTask_Sad_Edge(t *testing.T) {
 		})
 }
 
-func Test_JSONFileTaskRepository_ReadAllTasks_Happy(t *testing.T) {
+func Test_FileTaskRepository_Workflow(t *testing.T) {
+	t.Run("returns an InvalidTransitionError without saving", func(t *testing.T) {
+		mockFs, taskRepo := setupTaskUnitTest(t)
+		mockFs.Tasks[1] = th.NewTestTask(1, "test_task_1", tk.Done)
+
+		for _, status := range []tk.Status{tk.InProgress, tk.Todo, "banana"} {
+			_, err := taskRepo.UpdateTask(tk.UpdateTaskParams{
+				ID:     1,
+				Status: &status,
+			})
+			th.AssertError(t, err, &tk.InvalidTransitionError{})
+		}
+		th.AssertDeepEqual(t, mockFs.Calls, Calls{LoadData, LoadData, LoadData})
+		th.AssertDeepEqual(t, mockFs.Tasks[1].Status, tk.Done)
+	})
+
+	t.Run("moves a task through a named transition successfully",
+		func(t *testing.T) {
+			mockFs, taskRepo := setupTaskUnitTest(t)
+			mockFs.Tasks[1] = th.NewTestTask(1, "test_task_1", tk.Done)
+
+			got, err := taskRepo.TransitionTask(1, "reopen")
+			th.AssertNoError(t, err)
+			want := th.NewTestTask(1, "test_task_1", tk.Todo)
+			want.History = []tk.StatusChange{
+				{From: tk.Done, To: tk.Todo, At: th.FixedTime},
+			}
+			th.AssertDeepEqual(t, got, want)
+			th.AssertDeepEqual(t, mockFs.Calls, Calls{LoadData, SaveData})
+
+			_, err = taskRepo.TransitionTask(1, "reopen")
+			th.AssertError(t, err, &tk.InvalidTransitionError{})
+		})
+
+	t.Run("uses the statuses of a custom workflow successfully",
+		func(t *testing.T) {
+			mockFs, taskRepo := setupTaskUnitTest(t)
+			taskRepo.Workflow = newTeamWorkflow()
+			mockFs.Tasks = tk.Tasks{}
+
+			task, err := taskRepo.CreateTask(tk.CreateTaskParams{
+				Description: "test_task_1",
+			})
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, task.Status, tk.Todo)
+
+			for _, name := range []string{"start", "submit", "block"} {
+				task, err = taskRepo.TransitionTask(task.ID, name)
+				th.AssertNoError(t, err)
+			}
+
+			got, err := taskRepo.ReadManyTasks(tk.TaskFilter{Status: Blocked})
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got, tk.Tasks{task.ID: task})
+		})
+}
+
+func Test_FileTaskRepository_Lifecycle(t *testing.T) {
+	at := func(hours int) time.Time {
+		return th.FixedTime.Add(time.Duration(hours) * time.Hour)
+	}
+
+	t.Run("refreshes UpdatedAt on real changes only successfully",
+		func(t *testing.T) {
+			mockFs, taskRepo := setupTaskUnitTest(t)
+			clock := &th.StubTimeProvider{FixedTime: at(1)}
+			taskRepo.TimeProvider = clock
+
+			desc, status := "updated_task_1", tk.Todo
+			got, err := taskRepo.UpdateTask(tk.UpdateTaskParams{
+				ID:          1,
+				Description: &desc,
+			})
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got.UpdatedAt, at(1))
+
+			clock.FixedTime = at(2)
+			got, err = taskRepo.UpdateTask(tk.UpdateTaskParams{
+				ID:          1,
+				Description: &desc,
+				Status:      &status,
+			})
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got.UpdatedAt, at(1))
+			th.AssertDeepEqual(t, got.History, []tk.StatusChange(nil))
+			th.AssertDeepEqual(t, mockFs.Calls, Calls{LoadData, SaveData, LoadData})
+		})
+
+	t.Run("records the status changes and their timestamps successfully",
+		func(t *testing.T) {
+			_, taskRepo := setupTaskUnitTest(t)
+			clock := &th.StubTimeProvider{}
+			taskRepo.TimeProvider = clock
+
+			steps := []struct {
+				hours      int
+				transition string
+				started    *time.Time
+				completed  *time.Time
+			}{
+				{1, "start", ptr(at(1)), nil},
+				{2, "finish", ptr(at(1)), ptr(at(2))},
+				{3, "reopen", ptr(at(1)), nil},
+				{4, "start", ptr(at(1)), nil},
+				{5, "finish", ptr(at(1)), ptr(at(5))},
+			}
+
+			var task tk.Task
+			for _, step := range steps {
+				clock.FixedTime = at(step.hours)
+				var err error
+				task, err = taskRepo.TransitionTask(1, step.transition)
+				th.AssertNoError(t, err)
+
+				th.AssertDeepEqual(t, task.UpdatedAt, at(step.hours))
+				th.AssertDeepEqual(t, task.StartedAt, step.started)
+				th.AssertDeepEqual(t, task.CompletedAt, step.completed)
+			}
+
+			th.AssertDeepEqual(t, task.CreatedAt, th.FixedTime)
+			th.AssertDeepEqual(t, task.History, []tk.StatusChange{
+				{From: tk.Todo, To: tk.InProgress, At: at(1)},
+				{From: tk.InProgress, To: tk.Done, At: at(2)},
+				{From: tk.Done, To: tk.Todo, At: at(3)},
+				{From: tk.Todo, To: tk.InProgress, At: at(4)},
+				{From: tk.InProgress, To: tk.Done, At: at(5)},
+			})
+		})
+
+	t.Run("completes a task that never started successfully",
+		func(t *testing.T) {
+			_, taskRepo := setupTaskUnitTest(t)
+			taskRepo.TimeProvider = &th.StubTimeProvider{FixedTime: at(1)}
+
+			status := tk.Done
+			got, err := taskRepo.UpdateTask(tk.UpdateTaskParams{
+				ID:     1,
+				Status: &status,
+			})
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got.StartedAt, (*time.Time)(nil))
+			th.AssertDeepEqual(t, got.CompletedAt, ptr(at(1)))
+		})
+
+	t.Run("follows the statuses of a custom workflow successfully",
+		func(t *testing.T) {
+			mockFs, taskRepo := setupTaskUnitTest(t)
+			clock := &th.StubTimeProvider{}
+			taskRepo.TimeProvider = clock
+			taskRepo.Workflow = &tk.Workflow{
+				Statuses: []tk.Status{"open", "doing", "closed"},
+				Transitions: []tk.Transition{
+					{Name: "work", From: []tk.Status{"open"}, To: "doing"},
+					{Name: "close", From: []tk.Status{"doing"}, To: "closed"},
+					{Name: "reopen", From: []tk.Status{"closed"}, To: "open",
+						ByName: true},
+				},
+			}
+			task := mockFs.Tasks[1]
+			task.Status = "open"
+			mockFs.Tasks[1] = task
+
+			steps := []struct {
+				hours      int
+				transition string
+				started    *time.Time
+				completed  *time.Time
+			}{
+				{1, "work", ptr(at(1)), nil},
+				{2, "close", ptr(at(1)), ptr(at(2))},
+				{3, "reopen", ptr(at(1)), nil},
+			}
+
+			for _, step := range steps {
+				clock.FixedTime = at(step.hours)
+				got, err := taskRepo.TransitionTask(1, step.transition)
+				th.AssertNoError(t, err)
+				th.AssertDeepEqual(t, got.StartedAt, step.started)
+				th.AssertDeepEqual(t, got.CompletedAt, step.completed)
+			}
+		})
+}
+
+func Test_FileTaskRepository_ReadAllTasks_Happy(t *testing.T) {
 	t.Run("returns all tasks successfully", func(t *testing.T) {
-		mockFs, taskRepo, file := setupTaskUnitTest(t)
-		tasks, err := taskRepo.ReadAllTasks(file.Name())
+		mockFs, taskRepo := setupTaskUnitTest(t)
+		tasks, err := taskRepo.ReadAllTasks()
 		th.AssertNoError(t, err)
 		th.AssertDeepEqual(t, tasks, mockFs.Tasks)
 	})
 
 	t.Run("calls store.LoadData once to read all tasks successfully",
 		func(t *testing.T) {
-			mockFs, taskRepo, file := setupTaskUnitTest(t)
-			_, err := taskRepo.ReadAllTasks(file.Name())
+			mockFs, taskRepo := setupTaskUnitTest(t)
+			_, err := taskRepo.ReadAllTasks()
 			th.AssertNoError(t, err)
 
 			wantCalls := Calls{LoadData}
@@ -401,16 +896,16 @@ func Test_JSONFileTaskRepository_ReadAllTasks_Happy(t *testing.T) {
 		})
 }
 
-func Test_JSONFileTaskRepository_ReadAllTasks_Sad(t *testing.T) {
+func Test_FileTaskRepository_ReadAllTasks_Sad(t *testing.T) {
 	t.Run("returns an error when loading fails", func(t *testing.T) {
-		mockFs, taskRepo, file := setupTaskUnitTest(t)
+		mockFs, taskRepo := setupTaskUnitTest(t)
 		mockFs.LoadError = &os.PathError{}
-		_, err := taskRepo.ReadAllTasks(file.Name())
+		_, err := taskRepo.ReadAllTasks()
 		th.AssertError(t, err, mockFs.LoadError)
 	})
 }
 
-func Test_JSONFileTaskRepository_ReadManyTasks_Happy(t *testing.T) {
+func Test_FileTaskRepository_ReadManyTasks_Happy(t *testing.T) {
 	tasksForTest := tk.Tasks{
 		1: th.NewTestTask(1, "test_task_1", tk.Todo),
 		2: th.NewTestTask(2, "test_task_2", tk.Todo),
@@ -421,7 +916,7 @@ func Test_JSONFileTaskRepository_ReadManyTasks_Happy(t *testing.T) {
 	}
 
 	t.Run("returns filtered tasks by status successfully", func(t *testing.T) {
-		mockFs, taskRepo, file := setupTaskUnitTest(t)
+		mockFs, taskRepo := setupTaskUnitTest(t)
 		mockFs.Tasks = tasksForTest
 
 		testCases := []struct {
@@ -448,18 +943,69 @@ func Test_JSONFileTaskRepository_ReadManyTasks_Happy(t *testing.T) {
 
 		for _, tc := range testCases {
 			t.Run(tc.name, func(t *testing.T) {
-				gotTasks, err := taskRepo.ReadManyTasks(file.Name(), tc.status)
+				gotTasks, err := taskRepo.ReadManyTasks(
+					tk.TaskFilter{Status: tc.status},
+				)
 				th.AssertNoError(t, err)
 				th.AssertDeepEqual(t, gotTasks, tc.want)
 			})
 		}
 	})
 
+	t.Run("returns filtered tasks by minimum priority successfully",
+		func(t *testing.T) {
+			mockFs, taskRepo := setupTaskUnitTest(t)
+			mockFs.Tasks = tk.Tasks{}
+			for id, task := range tasksForTest {
+				task.Priority = tk.Priority(id % 5)
+				mockFs.Tasks[id] = task
+			}
+
+			testCases := []struct {
+				name   string
+				filter tk.TaskFilter
+				want   []uint
+			}{
+				{"returns every task for no minimum", tk.TaskFilter{},
+					[]uint{1, 2, 3, 4, 5, 6}},
+				{"returns the tasks at least high", tk.TaskFilter{
+					MinPriority: tk.PriorityHigh,
+				}, []uint{3, 4}},
+				{"returns the critical tasks", tk.TaskFilter{
+					MinPriority: tk.PriorityCritical,
+				}, []uint{4}},
+				{"combines the status and the priority", tk.TaskFilter{
+					Status:      tk.Todo,
+					MinPriority: tk.PriorityLow,
+				}, []uint{1, 2}},
+				{"returns nothing for a status without urgent tasks",
+					tk.TaskFilter{
+						Status:      tk.Done,
+						MinPriority: tk.PriorityMedium,
+					}, nil},
+			}
+
+			for _, tc := range testCases {
+				t.Run(tc.name, func(t *testing.T) {
+					gotTasks, err := taskRepo.ReadManyTasks(tc.filter)
+					th.AssertNoError(t, err)
+
+					var got []uint
+					for _, id := range []uint{1, 2, 3, 4, 5, 6} {
+						if _, ok := gotTasks[id]; ok {
+							got = append(got, id)
+						}
+					}
+					th.AssertDeepEqual(t, got, tc.want)
+				})
+			}
+		})
+
 	t.Run("calls store.LoadData once successfully", func(t *testing.T) {
-		mockFs, taskRepo, file := setupTaskUnitTest(t)
+		mockFs, taskRepo := setupTaskUnitTest(t)
 		mockFs.Tasks = tasksForTest
 
-		_, err := taskRepo.ReadManyTasks(file.Name(), tk.Todo)
+		_, err := taskRepo.ReadManyTasks(tk.TaskFilter{Status: tk.Todo})
 		th.AssertNoError(t, err)
 
 		wantCalls := Calls{LoadData}
@@ -467,33 +1013,33 @@ func Test_JSONFileTaskRepository_ReadManyTasks_Happy(t *testing.T) {
 	})
 }
 
-func Test_JSONFileTaskRepository_ReadManyTasks_Sad(t *testing.T) {
+func Test_FileTaskRepository_ReadManyTasks_Sad(t *testing.T) {
 	t.Run("returns an error context when loading fails successfully",
 		func(t *testing.T) {
-			mockFs, taskRepo, file := setupTaskUnitTest(t)
+			mockFs, taskRepo := setupTaskUnitTest(t)
 			mockFs.LoadError = &os.PathError{}
 
-			_, err := taskRepo.ReadManyTasks(file.Name(), tk.Todo)
+			_, err := taskRepo.ReadManyTasks(tk.TaskFilter{Status: tk.Todo})
 			th.AssertError(t, err, mockFs.LoadError)
 		})
 }
 
-func Test_JSONFileTaskRepository_ReadManyTasks_Edge(t *testing.T) {
+func Test_FileTaskRepository_ReadManyTasks_Edge(t *testing.T) {
 	t.Run("returns an empty task list when no tasks match the specified status "+
 		"successfully",
 		func(t *testing.T) {
-			_, taskRepo, file := setupTaskUnitTest(t) // all tasks are marked as todo
-			gotTasks, err := taskRepo.ReadManyTasks(file.Name(), tk.Done)
+			_, taskRepo := setupTaskUnitTest(t) // all tasks are marked as todo
+			gotTasks, err := taskRepo.ReadManyTasks(tk.TaskFilter{Status: tk.Done})
 			th.AssertNoError(t, err)
 			th.AssertDeepEqual(t, gotTasks, tk.Tasks{})
 		})
 }
 
-func Test_JSONFileTaskRepository_DeleteTask_Happy(t *testing.T) {
+func Test_FileTaskRepository_DeleteTask_Happy(t *testing.T) {
 	t.Run("deletes the specified task from the task list successfully",
 		func(t *testing.T) {
-			mockFs, taskRepo, file := setupTaskUnitTest(t)
-			_, err := taskRepo.DeleteTask(file.Name(), 2)
+			mockFs, taskRepo := setupTaskUnitTest(t)
+			_, err := taskRepo.DeleteTask(2)
 			th.AssertNoError(t, err)
 
 			wantTasks := make(tk.Tasks)
@@ -503,22 +1049,26 @@ func Test_JSONFileTaskRepository_DeleteTask_Happy(t *testing.T) {
 				}
 			}
 
-			gotTasks, err := taskRepo.Store.LoadData(file.Name())
+			gotList, err := taskRepo.Store.LoadData(taskRepo.Filepath())
 			th.AssertNoError(t, err)
-			th.AssertDeepEqual(t, gotTasks, wantTasks)
+			th.AssertDeepEqual(t, gotList.Tasks, wantTasks)
 		})
 
 	t.Run("returns the deleted task successfully", func(t *testing.T) {
-		mockFs, taskRepo, file := setupTaskUnitTest(t)
-		got, err := taskRepo.DeleteTask(file.Name(), 2)
+		mockFs, taskRepo := setupTaskUnitTest(t)
+		want := mockFs.Tasks[2]
+		want.DeletedAt = &th.FixedTime
+
+		got, err := taskRepo.DeleteTask(2)
 		th.AssertNoError(t, err)
-		th.AssertDeepEqual(t, got, mockFs.Tasks[2])
+		th.AssertDeepEqual(t, got, want)
+		th.AssertDeepEqual(t, mockFs.Trash, tk.Tasks{2: want})
 	})
 
 	t.Run("calls store.LoadData and store.SaveData once successfully",
 		func(t *testing.T) {
-			mockFs, taskRepo, file := setupTaskUnitTest(t)
-			_, err := taskRepo.DeleteTask(file.Name(), 2)
+			mockFs, taskRepo := setupTaskUnitTest(t)
+			_, err := taskRepo.DeleteTask(2)
 			th.AssertNoError(t, err)
 
 			wantCalls := Calls{LoadData, SaveData}
@@ -527,11 +1077,11 @@ func Test_JSONFileTaskRepository_DeleteTask_Happy(t *testing.T) {
 
 	t.Run("deletes multiple tasks and preserves task list order successfully",
 		func(t *testing.T) {
-			mockFs, taskRepo, file := setupTaskUnitTest(t)
+			mockFs, taskRepo := setupTaskUnitTest(t)
 
 			for id := 1; id <= 8; id++ {
 				if id%2 == 1 { // Delete tasks with odd IDs
-					_, err := taskRepo.DeleteTask(file.Name(), uint(id))
+					_, err := taskRepo.DeleteTask(uint(id))
 					th.AssertNoError(t, err)
 				}
 			}
@@ -543,15 +1093,15 @@ func Test_JSONFileTaskRepository_DeleteTask_Happy(t *testing.T) {
 				}
 			}
 
-			gotTasks, err := taskRepo.Store.LoadData(file.Name())
+			gotList, err := taskRepo.Store.LoadData(taskRepo.Filepath())
 			th.AssertNoError(t, err)
-			th.AssertDeepEqual(t, gotTasks, wantTasks)
+			th.AssertDeepEqual(t, gotList.Tasks, wantTasks)
 		})
 }
 
-func Test_JSONFileTaskRepository_DeleteTask_Sad_Edge(t *testing.T) {
+func Test_FileTaskRepository_DeleteTask_Sad_Edge(t *testing.T) {
 	t.Run("returns an error successfully", func(t *testing.T) {
-		mockFs, taskRepo, file := setupTaskUnitTest(t)
+		mockFs, taskRepo := setupTaskUnitTest(t)
 		testCases := []struct {
 			name      string
 			id        uint
@@ -593,7 +1143,7 @@ func Test_JSONFileTaskRepository_DeleteTask_Sad_Edge(t *testing.T) {
 					mockFs.SaveError = &os.PathError{}
 				}
 
-				_, err := taskRepo.DeleteTask(file.Name(), tc.id)
+				_, err := taskRepo.DeleteTask(tc.id)
 				th.AssertError(t, err, tc.wantErr)
 			})
 		}
@@ -611,62 +1161,87 @@ type Call string
 
 type Calls []Call
 
-type MockJSONFileStore[T tk.Tasks] struct {
+type MockFileStore[T tk.TaskList] struct {
 	Calls     Calls
+	LastID    uint
 	Tasks     tk.Tasks
+	Trash     tk.Tasks
 	LoadError error
 	SaveError error
 }
 
-func (mfs *MockJSONFileStore[T]) InitFile() (*os.File, error) {
+func (mfs *MockFileStore[T]) InitFile() (string, bool, error) {
 	mfs.Calls = append(mfs.Calls, InitFile)
-	return nil, nil
+	return "", false, nil
 }
 
-func (mfs *MockJSONFileStore[T]) LoadData(filepath string) (tk.Tasks, error) {
+func (mfs *MockFileStore[T]) LoadData(
+	filepath string,
+) (tk.TaskList, error) {
 	mfs.Calls = append(mfs.Calls, LoadData)
 	if mfs.LoadError != nil {
-		return tk.Tasks{}, mfs.LoadError
+		return tk.TaskList{}, mfs.LoadError
 	}
-	return tk.Tasks(mfs.Tasks), nil
+	return tk.TaskList{
+		LastID: mfs.LastID,
+		Tasks:  mfs.Tasks,
+		Trash:  mfs.Trash,
+	}, nil
 }
 
-func (mfs *MockJSONFileStore[T]) SaveData(
-	tasks tk.Tasks,
+func (mfs *MockFileStore[T]) SaveData(
+	list tk.TaskList,
 	filepath string,
 ) error {
 	mfs.Calls = append(mfs.Calls, SaveData)
 	if mfs.SaveError != nil {
 		return mfs.SaveError
 	}
+	mfs.LastID, mfs.Tasks, mfs.Trash = list.LastID, list.Tasks, list.Trash
 	return nil
 }
 
-func (mfs *MockJSONFileStore[T]) cleanCalls() {
+func (mfs *MockFileStore[T]) cleanCalls() {
 	mfs.Calls = []Call{}
 }
 
 func setupTaskUnitTest(t testing.TB) (
-	*MockJSONFileStore[tk.Tasks],
-	*tk.JSONFileTaskRepository,
-	*os.File,
+	*MockFileStore[tk.TaskList],
+	*tk.FileTaskRepository,
 ) {
 	t.Helper()
-	mockFileStore := &MockJSONFileStore[tk.Tasks]{Tasks: th.NewTestTasks()}
-	taskRepository := &tk.JSONFileTaskRepository{
-		Store:        mockFileStore,
-		TimeProvider: &th.StubTimeProvider{FixedTime: th.FixedTime},
-		IDGenerator:  &tk.TaskIDGenerator{},
-	}
-
+	mockFileStore := &MockFileStore[tk.TaskList]{Tasks: th.NewTestTasks()}
 	file, err := os.CreateTemp(os.TempDir(), "test_*.json")
 	th.AssertNoError(t, err)
+
+	taskRepository := tk.NewFileTaskRepository(
+		mockFileStore,
+		file.Name(),
+		&th.StubTimeProvider{FixedTime: th.FixedTime},
+		&tk.TaskIDGenerator{},
+	)
+	taskRepository.UIDGenerator = &th.StubUIDGenerator{Last: 8}
 	t.Cleanup(func() {
 		os.Remove(file.Name())
+		os.Remove(file.Name() + ".lock")
 		mockFileStore.cleanCalls()
 	})
 
-	return mockFileStore, taskRepository, file
+	return mockFileStore, taskRepository
+}
+
+// started returns an in-progress task as UpdateTask leaves it after starting
+// it from todo at th.FixedTime.
+func started(task tk.Task) tk.Task {
+	task.StartedAt = &th.FixedTime
+	task.History = []tk.StatusChange{
+		{From: tk.Todo, To: tk.InProgress, At: th.FixedTime},
+	}
+	return task
+}
+
+func ptr[T any](v T) *T {
+	return &v
 }
 
 func getTaskDesc(id uint) string {
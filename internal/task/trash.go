@@ -0,0 +1,107 @@
+package task
+
+import "time"
+
+type ReadOption int
+
+const (
+	// IncludeTrashed reads the trashed tasks along with the others.
+	IncludeTrashed ReadOption = iota + 1
+	// IncludeArchived reads the archived tasks along with the others.
+	IncludeArchived
+)
+
+func includes(opts []ReadOption, opt ReadOption) bool {
+	for _, o := range opts {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+func (tr *FileTaskRepository) ReadTrash() (Tasks, error) {
+	lock, err := tr.lock()
+	if err != nil {
+		return Tasks{}, err
+	}
+	defer lock.Unlock()
+
+	list, err := tr.load()
+	if err != nil {
+		return Tasks{}, err
+	}
+	if list.Trash == nil {
+		return Tasks{}, nil
+	}
+	return list.Trash, nil
+}
+
+// RestoreTask moves a task back from the trash.
+func (tr *FileTaskRepository) RestoreTask(id uint) (Task, error) {
+	lock, err := tr.lock()
+	if err != nil {
+		return Task{}, err
+	}
+	defer lock.Unlock()
+
+	list, err := tr.load()
+	if err != nil {
+		return Task{}, err
+	}
+
+	task, err := tr.findByID(list.Trash, id)
+	if err != nil {
+		return Task{}, err
+	}
+	if _, ok := list.Tasks[id]; ok {
+		return Task{}, &TaskConflictError{ID: id, In: "trash"}
+	}
+
+	task.DeletedAt = nil
+	task.UpdatedAt = tr.TimeProvider.Now()
+
+	delete(list.Trash, id)
+	list.Tasks[id] = task
+
+	if err := tr.save(list); err != nil {
+		return Task{}, err
+	}
+
+	return task, nil
+}
+
+// PurgeTrash deletes for good the tasks trashed more than olderThan ago, and
+// returns them.
+func (tr *FileTaskRepository) PurgeTrash(
+	olderThan time.Duration,
+) (Tasks, error) {
+	lock, err := tr.lock()
+	if err != nil {
+		return Tasks{}, err
+	}
+	defer lock.Unlock()
+
+	list, err := tr.load()
+	if err != nil {
+		return Tasks{}, err
+	}
+
+	cutoff := tr.TimeProvider.Now().Add(-olderThan)
+	purged := Tasks{}
+	for id, task := range list.Trash {
+		if task.DeletedAt == nil || !task.DeletedAt.After(cutoff) {
+			purged[id] = task
+			delete(list.Trash, id)
+		}
+	}
+
+	if len(purged) == 0 {
+		return purged, nil
+	}
+	if err := tr.save(list); err != nil {
+		return Tasks{}, err
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,192 @@
+package task_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	tk "github.com/alnah/task-tracker/internal/task"
+	th "github.com/alnah/task-tracker/test_helpers"
+)
+
+func Test_FileTaskRepository_Trash(t *testing.T) {
+	t.Run("leaves the trashed tasks out unless asked successfully",
+		func(t *testing.T) {
+			mockFs, taskRepo := setupTaskUnitTest(t)
+			all := th.NewTestTasks()
+
+			_, err := taskRepo.DeleteTask(2)
+			th.AssertNoError(t, err)
+
+			got, err := taskRepo.ReadAllTasks()
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, len(got), len(all)-1)
+
+			got, err = taskRepo.ReadAllTasks(tk.IncludeTrashed)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got[2], mockFs.Trash[2])
+			th.AssertDeepEqual(t, len(got), len(all))
+
+			got, err = taskRepo.ReadManyTasks(
+				tk.TaskFilter{Status: tk.Todo},
+				tk.IncludeTrashed,
+			)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got[2], mockFs.Trash[2])
+
+			_, err = taskRepo.ResolveID(th.NewTestUID(2))
+			th.AssertError(t, err, &tk.TaskNotFoundError{})
+			id, err := taskRepo.ResolveID(th.NewTestUID(2), tk.IncludeTrashed)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, id, uint(2))
+		})
+
+	t.Run("returns a TaskNotFoundError when updating a trashed task",
+		func(t *testing.T) {
+			_, taskRepo := setupTaskUnitTest(t)
+			_, err := taskRepo.DeleteTask(2)
+			th.AssertNoError(t, err)
+
+			desc := "updated_task_2"
+			_, err = taskRepo.UpdateTask(tk.UpdateTaskParams{
+				ID:          2,
+				Description: &desc,
+			})
+			th.AssertError(t, err, &tk.TaskNotFoundError{})
+
+			_, err = taskRepo.DeleteTask(2)
+			th.AssertError(t, err, &tk.TaskNotFoundError{})
+		})
+
+	t.Run("reads the trash successfully", func(t *testing.T) {
+		_, taskRepo := setupTaskUnitTest(t)
+
+		got, err := taskRepo.ReadTrash()
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, got, tk.Tasks{})
+
+		deleted, err := taskRepo.DeleteTask(3)
+		th.AssertNoError(t, err)
+
+		got, err = taskRepo.ReadTrash()
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, got, tk.Tasks{3: deleted})
+	})
+}
+
+func Test_FileTaskRepository_RestoreTask(t *testing.T) {
+	t.Run("moves a task back from the trash successfully", func(t *testing.T) {
+		mockFs, taskRepo := setupTaskUnitTest(t)
+		want := mockFs.Tasks[2]
+		_, err := taskRepo.DeleteTask(2)
+		th.AssertNoError(t, err)
+
+		later := th.FixedTime.Add(time.Hour)
+		taskRepo.TimeProvider = &th.StubTimeProvider{FixedTime: later}
+		want.UpdatedAt = later
+
+		got, err := taskRepo.RestoreTask(2)
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, got, want)
+		th.AssertDeepEqual(t, mockFs.Tasks[2], want)
+		th.AssertDeepEqual(t, len(mockFs.Trash), 0)
+	})
+
+	t.Run("returns a TaskConflictError for a task both live and trashed",
+		func(t *testing.T) {
+			mockFs, taskRepo := setupTaskUnitTest(t)
+			live := mockFs.Tasks[2]
+			_, err := taskRepo.DeleteTask(2)
+			th.AssertNoError(t, err)
+			mockFs.Tasks[2] = live
+			mockFs.cleanCalls()
+
+			_, err = taskRepo.RestoreTask(2)
+			th.AssertError(t, err, &tk.TaskConflictError{})
+			th.AssertDeepEqual(t, mockFs.Tasks[2], live)
+			th.AssertDeepEqual(t, mockFs.Calls, Calls{LoadData})
+		})
+
+	t.Run("returns an error successfully", func(t *testing.T) {
+		testCases := []struct {
+			name      string
+			loadError error
+			id        uint
+			wantErr   error
+		}{
+			{"returns a TaskNotFoundError for a live task", nil, 1,
+				&tk.TaskNotFoundError{}},
+			{"returns a TaskNotFoundError for a missing task", nil, 42,
+				&tk.TaskNotFoundError{}},
+			{"returns an error context when loading fails", &os.PathError{}, 1,
+				&os.PathError{}},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+				mockFs, taskRepo := setupTaskUnitTest(t)
+				mockFs.LoadError = tc.loadError
+
+				_, err := taskRepo.RestoreTask(tc.id)
+				th.AssertError(t, err, tc.wantErr)
+			})
+		}
+	})
+}
+
+func Test_FileTaskRepository_PurgeTrash(t *testing.T) {
+	at := func(days int) time.Time {
+		return th.FixedTime.Add(time.Duration(days) * 24 * time.Hour)
+	}
+
+	testCases := []struct {
+		name      string
+		olderThan time.Duration
+		want      []uint
+	}{
+		{"purges the whole trash", 0, []uint{1, 2, 3}},
+		{"purges the tasks trashed before the age", 15 * 24 * time.Hour,
+			[]uint{1, 2}},
+		{"purges a task trashed exactly at the age", 20 * 24 * time.Hour,
+			[]uint{1, 2}},
+		{"purges nothing newer than the age", 60 * 24 * time.Hour, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			mockFs, taskRepo := setupTaskUnitTest(t)
+			clock := &th.StubTimeProvider{}
+			taskRepo.TimeProvider = clock
+
+			for id, day := range map[uint]int{1: 0, 2: 10, 3: 25} {
+				clock.FixedTime = at(day)
+				_, err := taskRepo.DeleteTask(id)
+				th.AssertNoError(t, err)
+			}
+			clock.FixedTime = at(30)
+			mockFs.cleanCalls()
+
+			got, err := taskRepo.PurgeTrash(tc.olderThan)
+			th.AssertNoError(t, err)
+
+			var gotIDs []uint
+			for _, id := range []uint{1, 2, 3} {
+				if _, ok := got[id]; ok {
+					gotIDs = append(gotIDs, id)
+					if _, ok := mockFs.Trash[id]; ok {
+						t.Errorf("got task %d in the trash, want it purged", id)
+					}
+				}
+			}
+			th.AssertDeepEqual(t, gotIDs, tc.want)
+
+			wantCalls := Calls{LoadData}
+			if len(tc.want) > 0 {
+				wantCalls = append(wantCalls, SaveData)
+			}
+			th.AssertDeepEqual(t, mockFs.Calls, wantCalls)
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package task
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UIDGenerator gives the long ID of a task, unique across files and machines,
+// unlike the numeric ID which only means something inside one file.
+type UIDGenerator interface {
+	NewUID(time.Time) (string, error)
+}
+
+type AmbiguousIDError struct {
+	Prefix string
+	IDs    []uint
+}
+
+func (e *AmbiguousIDError) Error() string {
+	ids := make([]string, len(e.IDs))
+	for i, id := range e.IDs {
+		ids[i] = strconv.FormatUint(uint64(id), 10)
+	}
+	return fmt.Sprintf("ID prefix %q matches tasks %s",
+		e.Prefix, strings.Join(ids, ", "))
+}
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var errULIDOverflow = errors.New("too many ULIDs within one millisecond")
+
+// ULIDGenerator gives ULIDs: a 48 bits millisecond timestamp then 80 random
+// bits, in Crockford's base32, so they sort by creation time. ULIDs given
+// within the same millisecond increment the random bits instead, so they also
+// sort in the order they were given.
+type ULIDGenerator struct {
+	Entropy io.Reader // crypto/rand when nil
+
+	seeded     bool
+	lastMs     uint64
+	lastRandom [10]byte
+}
+
+func (g *ULIDGenerator) NewUID(t time.Time) (string, error) {
+	ms := uint64(t.UnixMilli())
+	if ms >= 1<<48 {
+		return "", fmt.Errorf("time %s is out of the ULID range", t)
+	}
+
+	if g.seeded && ms == g.lastMs {
+		if !increment(g.lastRandom[:]) {
+			return "", errULIDOverflow
+		}
+	} else {
+		entropy := g.Entropy
+		if entropy == nil {
+			entropy = rand.Reader
+		}
+		if _, err := io.ReadFull(entropy, g.lastRandom[:]); err != nil {
+			return "", fmt.Errorf("failed to read random bits:\n>%w", err)
+		}
+		g.seeded, g.lastMs = true, ms
+	}
+
+	var id [16]byte
+	binary.BigEndian.PutUint64(id[:8], ms<<16)
+	copy(id[6:], g.lastRandom[:])
+
+	return encodeULID(id), nil
+}
+
+// increment adds one to a big-endian number, and reports false on overflow.
+func increment(b []byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func encodeULID(id [16]byte) string {
+	hi := binary.BigEndian.Uint64(id[:8])
+	lo := binary.BigEndian.Uint64(id[8:])
+
+	// 26 characters hold 130 bits, the two leading ones are zero
+	out := make([]byte, 26)
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out)
+}
+
+// IsUIDPrefix reports whether s, in any case, can start a long ID.
+func IsUIDPrefix(s string) bool {
+	if s == "" || len(s) > 26 || s[0] > '7' {
+		return false
+	}
+	for _, r := range strings.ToUpper(s) {
+		if !strings.ContainsRune(crockford, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// ResolveID returns the numeric ID of the task that ref names: a numeric ID,
+// or a prefix of a long ID in any case. Long IDs start with a zero until the
+// year 3084, so a number with a leading zero is read as a prefix.
+func ResolveID(tasks Tasks, ref string) (uint, error) {
+	if !strings.HasPrefix(ref, "0") {
+		if id, err := strconv.ParseUint(ref, 10, 0); err == nil {
+			return uint(id), nil
+		}
+	}
+
+	prefix := strings.ToUpper(ref)
+	var ids []uint
+	for id, task := range tasks {
+		if prefix != "" && strings.HasPrefix(task.UID, prefix) {
+			ids = append(ids, id)
+		}
+	}
+
+	switch len(ids) {
+	case 0:
+		return 0, &TaskNotFoundError{Ref: ref}
+	case 1:
+		return ids[0], nil
+	default:
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		return 0, &AmbiguousIDError{Prefix: ref, IDs: ids}
+	}
+}
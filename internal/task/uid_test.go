@@ -0,0 +1,179 @@
+package task_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	tk "github.com/alnah/task-tracker/internal/task"
+	th "github.com/alnah/task-tracker/test_helpers"
+)
+
+func Test_AmbiguousIDError_Error(t *testing.T) {
+	t.Run("returns a string containing the prefix and the IDs", func(t *testing.T) {
+		err := &tk.AmbiguousIDError{Prefix: "01J", IDs: []uint{2, 7}}
+		th.AssertErrorMessage(t, err, err.Error(), "01J")
+		th.AssertErrorMessage(t, err, err.Error(), "2, 7")
+	})
+}
+
+func Test_ULIDGenerator_NewUID(t *testing.T) {
+	spec := time.UnixMilli(1469918176385)
+
+	t.Run("encodes the time and the random bits successfully",
+		func(t *testing.T) {
+			testCases := []struct {
+				name    string
+				time    time.Time
+				entropy []byte
+				want    string
+			}{
+				{
+					"encodes the zero ULID",
+					time.UnixMilli(0),
+					make([]byte, 10),
+					"00000000000000000000000000",
+				},
+				{
+					"encodes the time of the ULID specification",
+					spec,
+					make([]byte, 10),
+					"01ARYZ6S410000000000000000",
+				},
+				{
+					"encodes the random bits",
+					time.UnixMilli(0),
+					bytes.Repeat([]byte{0xff}, 10),
+					"0000000000ZZZZZZZZZZZZZZZZ",
+				},
+			}
+
+			for _, tc := range testCases {
+				t.Run(tc.name, func(t *testing.T) {
+					t.Parallel()
+					generator := tk.ULIDGenerator{Entropy: bytes.NewReader(tc.entropy)}
+
+					got, err := generator.NewUID(tc.time)
+					th.AssertNoError(t, err)
+					th.AssertDeepEqual(t, got, tc.want)
+				})
+			}
+		})
+
+	t.Run("increments the random bits within a millisecond successfully",
+		func(t *testing.T) {
+			generator := tk.ULIDGenerator{Entropy: bytes.NewReader(make([]byte, 10))}
+
+			first, err := generator.NewUID(spec)
+			th.AssertNoError(t, err)
+			second, err := generator.NewUID(spec)
+			th.AssertNoError(t, err)
+
+			th.AssertDeepEqual(t, first, "01ARYZ6S410000000000000000")
+			th.AssertDeepEqual(t, second, "01ARYZ6S410000000000000001")
+		})
+
+	t.Run("gives unique sortable IDs with the default entropy successfully",
+		func(t *testing.T) {
+			generator := tk.ULIDGenerator{}
+			previous := ""
+			for range 100 {
+				got, err := generator.NewUID(spec)
+				th.AssertNoError(t, err)
+				if got <= previous {
+					t.Fatalf("got %q after %q, want increasing IDs", got, previous)
+				}
+				previous = got
+			}
+		})
+
+	t.Run("returns an error when the random bits overflow", func(t *testing.T) {
+		generator := tk.ULIDGenerator{
+			Entropy: bytes.NewReader(bytes.Repeat([]byte{0xff}, 10)),
+		}
+
+		_, err := generator.NewUID(spec)
+		th.AssertNoError(t, err)
+		_, err = generator.NewUID(spec)
+		th.AssertNotNil(t, err)
+	})
+
+	t.Run("returns an error for a time before the Unix epoch", func(t *testing.T) {
+		generator := tk.ULIDGenerator{}
+		_, err := generator.NewUID(time.UnixMilli(-1))
+		th.AssertNotNil(t, err)
+	})
+
+	t.Run("returns an error when the entropy runs out", func(t *testing.T) {
+		generator := tk.ULIDGenerator{Entropy: strings.NewReader("short")}
+		_, err := generator.NewUID(spec)
+		th.AssertNotNil(t, err)
+	})
+}
+
+func Test_IsUIDPrefix(t *testing.T) {
+	testCases := []struct {
+		name string
+		s    string
+		want bool
+	}{
+		{"accepts a prefix", "01HZX3", true},
+		{"accepts a prefix in lower case", "01hzx3", true},
+		{"accepts a whole long ID", "01ARYZ6S41TSV4RRFFQ69G5FAV", true},
+		{"rejects letters out of the alphabet", "01HZXU", false},
+		{"rejects a prefix past the largest time", "8", false},
+		{"rejects a string longer than a long ID", strings.Repeat("0", 27), false},
+		{"rejects an empty string", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			th.AssertDeepEqual(t, tk.IsUIDPrefix(tc.s), tc.want)
+		})
+	}
+}
+
+func Test_ResolveID(t *testing.T) {
+	tasks := tk.Tasks{
+		1: {ID: 1, UID: "01HZX3A0000000000000000000"},
+		2: {ID: 2, UID: "01HZX3B0000000000000000000"},
+		3: {ID: 3, UID: "01J0000000000000000000000A"},
+	}
+
+	testCases := []struct {
+		name    string
+		ref     string
+		want    uint
+		wantErr error
+	}{
+		{"happy: resolves a numeric ID", "2", 2, nil},
+		{"happy: resolves a numeric ID of a missing task", "9", 9, nil},
+		{"happy: resolves a unique prefix", "01HZX3A", 1, nil},
+		{"happy: resolves a prefix in any case", "01hzx3b", 2, nil},
+		{"happy: resolves a whole long ID", "01J0000000000000000000000A", 3, nil},
+		{"sad: returns an AmbiguousIDError", "01HZX", 0, &tk.AmbiguousIDError{}},
+		{"sad: returns a TaskNotFoundError", "01K", 0, &tk.TaskNotFoundError{}},
+		{"edge: reads a leading zero as a prefix", "01", 0, &tk.AmbiguousIDError{}},
+		{"edge: returns a TaskNotFoundError if empty", "", 0, &tk.TaskNotFoundError{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := tk.ResolveID(tasks, tc.ref)
+			if tc.wantErr != nil {
+				th.AssertError(t, err, tc.wantErr)
+				return
+			}
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got, tc.want)
+		})
+	}
+
+	t.Run("lists the IDs an ambiguous prefix matches", func(t *testing.T) {
+		_, err := tk.ResolveID(tasks, "01H")
+		th.AssertErrorMessage(t, err, err.Error(), "1, 2")
+	})
+}
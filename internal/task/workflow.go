@@ -0,0 +1,275 @@
+package task
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	st "github.com/alnah/task-tracker/internal/store"
+)
+
+// Transition is a named move of a task from any of the From statuses to To.
+// A transition ByName is only taken through its name, such as reopen; setting
+// a task's status never makes its move.
+type Transition struct {
+	Name   string   `json:"name"`
+	From   []Status `json:"from"`
+	To     Status   `json:"to"`
+	ByName bool     `json:"byName,omitempty"`
+}
+
+// Workflow defines the statuses a task can have and how it moves between
+// them. New tasks get the first status.
+type Workflow struct {
+	Statuses    []Status     `json:"statuses"`
+	Transitions []Transition `json:"transitions"`
+}
+
+type InvalidTransitionError struct {
+	From       Status
+	To         Status
+	Transition string
+	Allowed    []Status
+	// ByName holds the transitions taken only by name that leave From.
+	ByName []Transition
+}
+
+func (e *InvalidTransitionError) Error() string {
+	move := fmt.Sprintf("move a task from %s to %s", e.From, e.To)
+	if e.Transition != "" {
+		move = fmt.Sprintf("%s a task that is %s", e.Transition, e.From)
+	}
+
+	var names, moves []string
+	for _, transition := range e.ByName {
+		if transition.To == e.To {
+			names = append(names, transition.Name)
+		}
+		moves = append(moves, fmt.Sprintf("only %s moves it, to %s",
+			transition.Name, transition.To))
+	}
+	if len(names) > 0 {
+		return fmt.Sprintf("can't %s, only %s does",
+			move, strings.Join(names, " or "))
+	}
+
+	if len(e.Allowed) == 0 {
+		if len(moves) > 0 {
+			return fmt.Sprintf("can't %s, %s", move, strings.Join(moves, ", or "))
+		}
+		return fmt.Sprintf("can't %s, it can't move anymore", move)
+	}
+	return fmt.Sprintf("can't %s, it can only move to %s",
+		move, joinStatuses(e.Allowed))
+}
+
+type WorkflowError struct {
+	Message string
+}
+
+func (e *WorkflowError) Error() string {
+	return fmt.Sprintf("invalid workflow: %s", e.Message)
+}
+
+// DefaultWorkflow is todo, in-progress and done. A done task goes back to todo
+// only through reopen, which is taken by name.
+func DefaultWorkflow() *Workflow {
+	return &Workflow{
+		Statuses: []Status{Todo, InProgress, Done},
+		Transitions: []Transition{
+			{Name: "start", From: []Status{Todo}, To: InProgress},
+			{Name: "stop", From: []Status{InProgress}, To: Todo},
+			{Name: "finish", From: []Status{Todo, InProgress}, To: Done},
+			{Name: "reopen", From: []Status{Done}, To: Todo, ByName: true},
+		},
+	}
+}
+
+// LoadWorkflow reads a workflow from a JSON, YAML or TOML file.
+func LoadWorkflow(path string) (*Workflow, error) {
+	codec, err := st.CodecFor(path, st.DefaultCodecs)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workflow:\n>%w", err)
+	}
+
+	var workflow Workflow
+	if err := codec.Unmarshal(content, &workflow); err != nil {
+		return nil, &WorkflowError{Message: err.Error()}
+	}
+
+	if err := workflow.Validate(); err != nil {
+		return nil, err
+	}
+	return &workflow, nil
+}
+
+var statusPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+func (w *Workflow) Validate() error {
+	if len(w.Statuses) == 0 {
+		return &WorkflowError{Message: "no statuses"}
+	}
+
+	seen := map[Status]bool{}
+	for _, status := range w.Statuses {
+		if !statusPattern.MatchString(string(status)) {
+			return &WorkflowError{Message: fmt.Sprintf(
+				"status %q isn't lowercase words joined by dashes", status,
+			)}
+		}
+		if seen[status] {
+			return &WorkflowError{Message: fmt.Sprintf(
+				"status %q is listed twice", status,
+			)}
+		}
+		seen[status] = true
+	}
+
+	for _, transition := range w.Transitions {
+		if !statusPattern.MatchString(transition.Name) {
+			return &WorkflowError{Message: fmt.Sprintf(
+				"transition name %q isn't lowercase words joined by dashes",
+				transition.Name,
+			)}
+		}
+		statuses := append([]Status{transition.To}, transition.From...)
+		for _, status := range statuses {
+			if !seen[status] {
+				return &WorkflowError{Message: fmt.Sprintf(
+					"transition %s uses the unknown status %q",
+					transition.Name, status,
+				)}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (w *Workflow) Initial() Status {
+	return w.Statuses[0]
+}
+
+func (w *Workflow) Has(status Status) bool {
+	for _, s := range w.Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Final reports whether a task in status is finished: status is the last of
+// the workflow, or no transition leaves it but those taken by name, as reopen
+// leaves done.
+func (w *Workflow) Final(status Status) bool {
+	if status == w.Statuses[len(w.Statuses)-1] {
+		return true
+	}
+	return len(w.next(status, false)) == 0
+}
+
+// Started reports whether work on a task in status has started: status is
+// neither the first of the workflow nor a final one.
+func (w *Workflow) Started(status Status) bool {
+	return status != w.Initial() && !w.Final(status)
+}
+
+// Check returns an InvalidTransitionError unless a transition, other than
+// those taken by name, moves a task from one status to the other. Staying in a
+// status is always allowed.
+func (w *Workflow) Check(from, to Status) error {
+	if from == to && w.Has(to) {
+		return nil
+	}
+
+	allowed := w.next(from, false)
+	for _, status := range allowed {
+		if status == to {
+			return nil
+		}
+	}
+
+	var byName []Transition
+	for _, transition := range w.Transitions {
+		if !transition.ByName {
+			continue
+		}
+		for _, status := range transition.From {
+			if status == from {
+				byName = append(byName, transition)
+			}
+		}
+	}
+	return &InvalidTransitionError{
+		From:    from,
+		To:      to,
+		Allowed: allowed,
+		ByName:  byName,
+	}
+}
+
+// Apply returns the status the named transition moves a task to from status.
+func (w *Workflow) Apply(name string, from Status) (Status, error) {
+	found := false
+	for _, transition := range w.Transitions {
+		if transition.Name != name {
+			continue
+		}
+		found = true
+		for _, status := range transition.From {
+			if status == from {
+				return transition.To, nil
+			}
+		}
+	}
+
+	if !found {
+		return "", &WorkflowError{Message: fmt.Sprintf(
+			"no transition named %q", name,
+		)}
+	}
+	return "", &InvalidTransitionError{
+		From:       from,
+		Transition: name,
+		Allowed:    w.next(from, true),
+	}
+}
+
+// next returns the statuses a task can move to from status, in workflow order,
+// counting the transitions taken by name when byName is set.
+func (w *Workflow) next(from Status, byName bool) []Status {
+	reachable := map[Status]bool{}
+	for _, transition := range w.Transitions {
+		if transition.ByName && !byName {
+			continue
+		}
+		for _, status := range transition.From {
+			if status == from {
+				reachable[transition.To] = true
+			}
+		}
+	}
+
+	var next []Status
+	for _, status := range w.Statuses {
+		if reachable[status] && status != from {
+			next = append(next, status)
+		}
+	}
+	return next
+}
+
+func joinStatuses(statuses []Status) string {
+	names := make([]string, len(statuses))
+	for i, status := range statuses {
+		names[i] = string(status)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,245 @@
+package task_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tk "github.com/alnah/task-tracker/internal/task"
+	th "github.com/alnah/task-tracker/test_helpers"
+)
+
+const (
+	Review  tk.Status = "review"
+	Blocked tk.Status = "blocked"
+)
+
+func newTeamWorkflow() *tk.Workflow {
+	return &tk.Workflow{
+		Statuses: []tk.Status{tk.Todo, tk.InProgress, Review, Blocked, tk.Done},
+		Transitions: []tk.Transition{
+			{Name: "start", From: []tk.Status{tk.Todo}, To: tk.InProgress},
+			{Name: "submit", From: []tk.Status{tk.InProgress}, To: Review},
+			{Name: "block", From: []tk.Status{tk.InProgress, Review}, To: Blocked},
+			{Name: "unblock", From: []tk.Status{Blocked}, To: tk.InProgress},
+			{Name: "approve", From: []tk.Status{Review}, To: tk.Done},
+			{
+				Name:   "reopen",
+				From:   []tk.Status{tk.Done},
+				To:     tk.Todo,
+				ByName: true,
+			},
+		},
+	}
+}
+
+func Test_InvalidTransitionError_Error(t *testing.T) {
+	t.Run("returns a string containing the statuses", func(t *testing.T) {
+		err := &tk.InvalidTransitionError{
+			From:    tk.Done,
+			To:      tk.InProgress,
+			Allowed: []tk.Status{tk.Todo},
+		}
+		th.AssertErrorMessage(t, err, err.Error(), "from done to in-progress")
+		th.AssertErrorMessage(t, err, err.Error(), "only move to todo")
+	})
+
+	t.Run("returns a string containing the transition", func(t *testing.T) {
+		err := &tk.InvalidTransitionError{From: tk.Todo, Transition: "reopen"}
+		th.AssertErrorMessage(t, err, err.Error(), "reopen a task that is todo")
+	})
+
+	t.Run("returns a string containing the transitions taken by name",
+		func(t *testing.T) {
+			reopen := tk.DefaultWorkflow().Transitions[3]
+			err := &tk.InvalidTransitionError{
+				From:   tk.Done,
+				To:     tk.Todo,
+				ByName: []tk.Transition{reopen},
+			}
+			th.AssertErrorMessage(t, err, err.Error(),
+				"from done to todo, only reopen does")
+
+			err.To = tk.InProgress
+			th.AssertErrorMessage(t, err, err.Error(),
+				"from done to in-progress, only reopen moves it, to todo")
+		})
+}
+
+func Test_Workflow_Validate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		workflow *tk.Workflow
+		wantErr  bool
+	}{
+		{"happy: accepts the default workflow", tk.DefaultWorkflow(), false},
+		{"happy: accepts a team workflow", newTeamWorkflow(), false},
+		{"sad: refuses no statuses", &tk.Workflow{}, true},
+		{
+			"sad: refuses a status listed twice",
+			&tk.Workflow{Statuses: []tk.Status{tk.Todo, tk.Todo}},
+			true,
+		},
+		{
+			"sad: refuses a status with spaces",
+			&tk.Workflow{Statuses: []tk.Status{"in review"}},
+			true,
+		},
+		{
+			"sad: refuses a transition to an unknown status",
+			&tk.Workflow{
+				Statuses: []tk.Status{tk.Todo},
+				Transitions: []tk.Transition{
+					{Name: "finish", From: []tk.Status{tk.Todo}, To: tk.Done},
+				},
+			},
+			true,
+		},
+		{
+			"edge: refuses a transition without a name",
+			&tk.Workflow{
+				Statuses: []tk.Status{tk.Todo, tk.Done},
+				Transitions: []tk.Transition{
+					{From: []tk.Status{tk.Todo}, To: tk.Done},
+				},
+			},
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := tc.workflow.Validate()
+			if tc.wantErr {
+				th.AssertError(t, err, &tk.WorkflowError{})
+				return
+			}
+			th.AssertNoError(t, err)
+		})
+	}
+}
+
+func Test_Workflow_Check(t *testing.T) {
+	defaults, team := tk.DefaultWorkflow(), newTeamWorkflow()
+	testCases := []struct {
+		name     string
+		workflow *tk.Workflow
+		from, to tk.Status
+		wantErr  bool
+	}{
+		{"happy: allows a transition", defaults, tk.Todo, tk.Done, false},
+		{"happy: allows staying", defaults, tk.Done, tk.Done, false},
+		{"happy: allows a custom status", team, tk.InProgress, Review, false},
+		{"sad: refuses reopening", defaults, tk.Done, tk.Todo, true},
+		{"sad: refuses a transition back", defaults, tk.Done, tk.InProgress, true},
+		{"sad: refuses an unknown status", defaults, tk.Todo, "banana", true},
+		{"edge: refuses an unknown status as is", defaults, "banana", "banana", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := tc.workflow.Check(tc.from, tc.to)
+			if tc.wantErr {
+				th.AssertError(t, err, &tk.InvalidTransitionError{})
+				return
+			}
+			th.AssertNoError(t, err)
+		})
+	}
+
+	t.Run("names the transitions taken by name that leave the status",
+		func(t *testing.T) {
+			err := defaults.Check(tk.Done, tk.InProgress)
+			th.AssertError(t, err, &tk.InvalidTransitionError{})
+			th.AssertErrorMessage(t, err, err.Error(),
+				"from done to in-progress, only reopen moves it, to todo")
+		})
+}
+
+func Test_Workflow_Final(t *testing.T) {
+	defaults, team := tk.DefaultWorkflow(), newTeamWorkflow()
+	closed := &tk.Workflow{
+		Statuses: []tk.Status{tk.Todo, "closed", "wont-do"},
+		Transitions: []tk.Transition{
+			{Name: "close", From: []tk.Status{tk.Todo}, To: "closed"},
+			{Name: "drop", From: []tk.Status{tk.Todo}, To: "wont-do"},
+		},
+	}
+	testCases := []struct {
+		name     string
+		workflow *tk.Workflow
+		status   tk.Status
+		final    bool
+		started  bool
+	}{
+		{"happy: the first status", defaults, tk.Todo, false, false},
+		{"happy: a status in between", defaults, tk.InProgress, false, true},
+		{"happy: the last status", defaults, tk.Done, true, false},
+		{"happy: a custom status in between", team, Blocked, false, true},
+		{"happy: the last custom status", team, tk.Done, true, false},
+		{"edge: a status with no way out", closed, "closed", true, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			th.AssertDeepEqual(t, tc.workflow.Final(tc.status), tc.final)
+			th.AssertDeepEqual(t, tc.workflow.Started(tc.status), tc.started)
+		})
+	}
+}
+
+func Test_Workflow_Apply(t *testing.T) {
+	workflow := newTeamWorkflow()
+
+	t.Run("returns the status the transition moves to successfully",
+		func(t *testing.T) {
+			got, err := workflow.Apply("block", Review)
+			th.AssertNoError(t, err)
+			th.AssertDeepEqual(t, got, Blocked)
+		})
+
+	t.Run("returns an InvalidTransitionError from another status",
+		func(t *testing.T) {
+			_, err := workflow.Apply("reopen", tk.Todo)
+			th.AssertError(t, err, &tk.InvalidTransitionError{})
+		})
+
+	t.Run("returns a WorkflowError for an unknown transition", func(t *testing.T) {
+		_, err := workflow.Apply("archive", tk.Done)
+		th.AssertError(t, err, &tk.WorkflowError{})
+	})
+}
+
+func Test_LoadWorkflow(t *testing.T) {
+	t.Run("loads a YAML workflow successfully", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "workflow.yaml")
+		content := "statuses: [todo, review, done]\n" +
+			"transitions:\n" +
+			"  - {name: submit, from: [todo], to: review}\n" +
+			"  - {name: approve, from: [review], to: done}\n"
+		th.AssertNoError(t, os.WriteFile(path, []byte(content), 0644))
+
+		got, err := tk.LoadWorkflow(path)
+		th.AssertNoError(t, err)
+		th.AssertDeepEqual(t, got.Statuses, []tk.Status{tk.Todo, Review, tk.Done})
+		th.AssertDeepEqual(t, got.Initial(), tk.Todo)
+		th.AssertNoError(t, got.Check(tk.Todo, Review))
+	})
+
+	t.Run("returns a WorkflowError for an invalid workflow", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "workflow.json")
+		err := os.WriteFile(path, []byte(`{"statuses": []}`), 0644)
+		th.AssertNoError(t, err)
+
+		_, err = tk.LoadWorkflow(path)
+		th.AssertError(t, err, &tk.WorkflowError{})
+	})
+
+	t.Run("returns an error for a missing file", func(t *testing.T) {
+		_, err := tk.LoadWorkflow(filepath.Join(t.TempDir(), "workflow.json"))
+		th.AssertError(t, err, &os.PathError{})
+	})
+}
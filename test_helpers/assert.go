@@ -8,6 +8,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/alnah/task-tracker/internal/render"
 	st "github.com/alnah/task-tracker/internal/store"
 	tk "github.com/alnah/task-tracker/internal/task"
 )
@@ -40,6 +41,13 @@ func AssertErrorMessage(t testing.TB, err error, got, want string) {
 	}
 }
 
+func AssertContains(t testing.TB, got, want string) {
+	t.Helper()
+	if !strings.Contains(got, want) {
+		t.Errorf("got %q, want a string containing %q", got, want)
+	}
+}
+
 func AssertDeepEqual(t testing.TB, got, want any) {
 	t.Helper()
 	if !reflect.DeepEqual(got, want) {
@@ -65,6 +73,36 @@ func AssertError(t testing.TB, err error, expectedType error) {
 			t.Errorf("got %T, want FilenameError", err)
 		}
 
+	case *st.FileContentError:
+		var contentErr *st.FileContentError
+		if !errors.As(err, &contentErr) {
+			t.Errorf("got %T, want FileContentError", err)
+		}
+
+	case *st.SchemaVersionError:
+		var versionErr *st.SchemaVersionError
+		if !errors.As(err, &versionErr) {
+			t.Errorf("got %T, want SchemaVersionError", err)
+		}
+
+	case *st.LockTimeoutError:
+		var lockErr *st.LockTimeoutError
+		if !errors.As(err, &lockErr) {
+			t.Errorf("got %T, want LockTimeoutError", err)
+		}
+
+	case *st.CorruptFileError:
+		var corruptErr *st.CorruptFileError
+		if !errors.As(err, &corruptErr) {
+			t.Errorf("got %T, want CorruptFileError", err)
+		}
+
+	case *st.BackupNotFoundError:
+		var backupErr *st.BackupNotFoundError
+		if !errors.As(err, &backupErr) {
+			t.Errorf("got %T, want BackupNotFoundError", err)
+		}
+
 	case *tk.DescriptionError:
 		var initDataErr *tk.DescriptionError
 		if !errors.As(err, &initDataErr) {
@@ -77,6 +115,71 @@ func AssertError(t testing.TB, err error, expectedType error) {
 			t.Errorf("got %T, want TaskNotFoundError", err)
 		}
 
+	case *tk.AmbiguousIDError:
+		var ambiguousErr *tk.AmbiguousIDError
+		if !errors.As(err, &ambiguousErr) {
+			t.Errorf("got %T, want AmbiguousIDError", err)
+		}
+	case *tk.InvalidTransitionError:
+		var transitionErr *tk.InvalidTransitionError
+		if !errors.As(err, &transitionErr) {
+			t.Errorf("got %T, want InvalidTransitionError", err)
+		}
+	case *tk.WorkflowError:
+		var workflowErr *tk.WorkflowError
+		if !errors.As(err, &workflowErr) {
+			t.Errorf("got %T, want WorkflowError", err)
+		}
+	case *tk.TaskConflictError:
+		var conflictErr *tk.TaskConflictError
+		if !errors.As(err, &conflictErr) {
+			t.Errorf("got %T, want TaskConflictError", err)
+		}
+	case *tk.PriorityError:
+		var priorityErr *tk.PriorityError
+		if !errors.As(err, &priorityErr) {
+			t.Errorf("got %T, want PriorityError", err)
+		}
+	case *tk.DateError:
+		var dateErr *tk.DateError
+		if !errors.As(err, &dateErr) {
+			t.Errorf("got %T, want DateError", err)
+		}
+	case *tk.TagError:
+		var tagErr *tk.TagError
+		if !errors.As(err, &tagErr) {
+			t.Errorf("got %T, want TagError", err)
+		}
+	case *tk.QueryError:
+		var queryErr *tk.QueryError
+		if !errors.As(err, &queryErr) {
+			t.Errorf("got %T, want QueryError", err)
+		}
+	case *tk.SortKeyError:
+		var sortErr *tk.SortKeyError
+		if !errors.As(err, &sortErr) {
+			t.Errorf("got %T, want SortKeyError", err)
+		}
+	case *tk.CursorError:
+		var cursorErr *tk.CursorError
+		if !errors.As(err, &cursorErr) {
+			t.Errorf("got %T, want CursorError", err)
+		}
+	case *tk.GroupKeyError:
+		var groupErr *tk.GroupKeyError
+		if !errors.As(err, &groupErr) {
+			t.Errorf("got %T, want GroupKeyError", err)
+		}
+	case *render.FormatError:
+		var formatErr *render.FormatError
+		if !errors.As(err, &formatErr) {
+			t.Errorf("got %T, want FormatError", err)
+		}
+	case *render.TemplateError:
+		var templateErr *render.TemplateError
+		if !errors.As(err, &templateErr) {
+			t.Errorf("got %T, want TemplateError", err)
+		}
 	// Go Errors
 	case *os.PathError:
 		var pathErr *os.PathError
@@ -0,0 +1,13 @@
+package test_helpers
+
+import "time"
+
+// StubUIDGenerator gives the long IDs of NewTestUID, counting up from Last.
+type StubUIDGenerator struct {
+	Last uint
+}
+
+func (sug *StubUIDGenerator) NewUID(time.Time) (string, error) {
+	sug.Last++
+	return NewTestUID(sug.Last), nil
+}
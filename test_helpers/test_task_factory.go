@@ -1,12 +1,15 @@
 package test_helpers
 
 import (
+	"fmt"
+
 	tk "github.com/alnah/task-tracker/internal/task"
 )
 
 func NewTestTask(id uint, description string, status tk.Status) tk.Task {
 	return tk.Task{
 		ID:          id,
+		UID:         NewTestUID(id),
 		Description: description,
 		Status:      status,
 		CreatedAt:   FixedTime,
@@ -26,3 +29,8 @@ func NewTestTasks() tk.Tasks {
 		8: NewTestTask(5, "test_task_8", tk.Todo),
 	}
 }
+
+// NewTestUID returns the long ID NewTestTask gives to the task id.
+func NewTestUID(id uint) string {
+	return fmt.Sprintf("01J00000000000000000%06d", id)
+}